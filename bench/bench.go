@@ -0,0 +1,249 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/andr1ww/odin/database"
+)
+
+const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+type Config struct {
+	Bucket      string
+	Keys        int
+	ValueSize   int
+	ReadRatio   float64
+	ScanRatio   float64
+	Repetitive  bool
+	Concurrency int
+	Duration    time.Duration
+	Operations  int
+	Seed        int64
+}
+
+type Latency struct {
+	Count int
+	Mean  time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+type Result struct {
+	Config      Config
+	Elapsed     time.Duration
+	Operations  int
+	Errors      int
+	Throughput  float64
+	AllocsPerOp float64
+	BytesPerOp  float64
+	Reads       Latency
+	Writes      Latency
+	Scans       Latency
+}
+
+type record struct {
+	Data string `json:"data"`
+}
+
+type worker struct {
+	reads  []time.Duration
+	writes []time.Duration
+	scans  []time.Duration
+	errors int
+}
+
+func (c Config) withDefaults() Config {
+	if c.Bucket == "" {
+		c.Bucket = "bench"
+	}
+	if c.Keys <= 0 {
+		c.Keys = 10000
+	}
+	if c.ValueSize <= 0 {
+		c.ValueSize = 256
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = 4
+	}
+	if c.Duration <= 0 && c.Operations <= 0 {
+		c.Duration = 10 * time.Second
+	}
+	c.ReadRatio = min(max(c.ReadRatio, 0), 1)
+	c.ScanRatio = min(max(c.ScanRatio, 0), 1)
+	return c
+}
+
+func Run(ctx context.Context, db *database.DB, config Config) (*Result, error) {
+	config = config.withDefaults()
+
+	if err := db.CreateBucket(config.Bucket); err != nil {
+		return nil, err
+	}
+	if err := preload(db, config); err != nil {
+		return nil, fmt.Errorf("preload: %w", err)
+	}
+
+	if config.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Duration)
+		defer cancel()
+	}
+
+	var issued atomic.Int64
+	workers := make([]*worker, config.Concurrency)
+	var wg sync.WaitGroup
+
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for i := range workers {
+		w := &worker{}
+		workers[i] = w
+		rng := rand.New(rand.NewSource(config.Seed + int64(i) + 1))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var target record
+			for ctx.Err() == nil {
+				if config.Operations > 0 && issued.Add(1) > int64(config.Operations) {
+					return
+				}
+
+				key := keyFor(rng.Intn(config.Keys))
+				opStart := time.Now()
+				if rng.Float64() < config.ScanRatio {
+					_, err := db.GetAll(config.Bucket, func() interface{} { return &record{} })
+					w.scans = append(w.scans, time.Since(opStart))
+					if err != nil {
+						w.errors++
+					}
+					continue
+				}
+				if rng.Float64() < config.ReadRatio {
+					err := db.Get(config.Bucket, key, &target)
+					w.reads = append(w.reads, time.Since(opStart))
+					if err != nil {
+						w.errors++
+					}
+					continue
+				}
+
+				err := db.Put(config.Bucket, key, record{Data: valueFor(rng, config)})
+				w.writes = append(w.writes, time.Since(opStart))
+				if err != nil {
+					w.errors++
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	result := &Result{Config: config, Elapsed: time.Since(start)}
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+
+	var reads, writes, scans []time.Duration
+	for _, w := range workers {
+		reads = append(reads, w.reads...)
+		writes = append(writes, w.writes...)
+		scans = append(scans, w.scans...)
+		result.Errors += w.errors
+	}
+	result.Operations = len(reads) + len(writes) + len(scans)
+	if result.Elapsed > 0 {
+		result.Throughput = float64(result.Operations) / result.Elapsed.Seconds()
+	}
+	if result.Operations > 0 {
+		result.AllocsPerOp = float64(after.Mallocs-before.Mallocs) / float64(result.Operations)
+		result.BytesPerOp = float64(after.TotalAlloc-before.TotalAlloc) / float64(result.Operations)
+	}
+	result.Reads, result.Writes, result.Scans = summarize(reads), summarize(writes), summarize(scans)
+	return result, nil
+}
+
+func preload(db *database.DB, config Config) error {
+	rng := rand.New(rand.NewSource(config.Seed))
+	const batchSize = 1000
+	batch := make(map[string]interface{}, batchSize)
+	for i := 0; i < config.Keys; i++ {
+		batch[keyFor(i)] = record{Data: valueFor(rng, config)}
+		if len(batch) == batchSize || i == config.Keys-1 {
+			if err := db.PutMany(config.Bucket, batch); err != nil {
+				return err
+			}
+			batch = make(map[string]interface{}, batchSize)
+		}
+	}
+	return nil
+}
+
+func keyFor(i int) string {
+	return fmt.Sprintf("key-%08d", i)
+}
+
+func valueFor(rng *rand.Rand, config Config) string {
+	if !config.Repetitive {
+		return randomString(rng, config.ValueSize)
+	}
+	pattern := randomString(rng, 16)
+	return strings.Repeat(pattern, config.ValueSize/len(pattern)+1)[:config.ValueSize]
+}
+
+func randomString(rng *rand.Rand, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = letters[rng.Intn(len(letters))]
+	}
+	return string(b)
+}
+
+func summarize(samples []time.Duration) Latency {
+	if len(samples) == 0 {
+		return Latency{}
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	var total time.Duration
+	for _, sample := range samples {
+		total += sample
+	}
+	percentile := func(p float64) time.Duration {
+		return samples[min(len(samples)-1, int(p*float64(len(samples))))]
+	}
+	return Latency{
+		Count: len(samples),
+		Mean:  total / time.Duration(len(samples)),
+		P50:   percentile(0.50),
+		P90:   percentile(0.90),
+		P99:   percentile(0.99),
+		Max:   samples[len(samples)-1],
+	}
+}
+
+func (r *Result) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d ops in %s (%.0f ops/s), %d errors\n", r.Operations, r.Elapsed.Round(time.Millisecond), r.Throughput, r.Errors)
+	fmt.Fprintf(&b, "%.1f allocs/op, %.0f B/op\n", r.AllocsPerOp, r.BytesPerOp)
+	for _, row := range []struct {
+		name    string
+		latency Latency
+	}{{"reads", r.Reads}, {"writes", r.Writes}, {"scans", r.Scans}} {
+		l := row.latency
+		if l.Count == 0 && row.name == "scans" {
+			continue
+		}
+		fmt.Fprintf(&b, "%-6s n=%-8d mean=%-10s p50=%-10s p90=%-10s p99=%-10s max=%s\n", row.name, l.Count, l.Mean, l.P50, l.P90, l.P99, l.Max)
+	}
+	return b.String()
+}
@@ -0,0 +1,249 @@
+package bucket
+
+import (
+	"bytes"
+	"encoding/json"
+	err "errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/andr1ww/odin/database"
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/compression"
+	"github.com/andr1ww/odin/internal/indexing"
+	"github.com/andr1ww/odin/internal/logger"
+	"github.com/andr1ww/odin/internal/reflection"
+	jsoniter "github.com/json-iterator/go"
+	bolt "go.etcd.io/bbolt"
+)
+
+const autoMigrateBatch = 500
+
+var js = jsoniter.ConfigCompatibleWithStandardLibrary
+
+type MigrationReport struct {
+	Bucket     string         `json:"bucket"`
+	Scanned    int            `json:"scanned"`
+	Rewritten  int            `json:"rewritten"`
+	Indexed    int            `json:"indexed"`
+	Failed     int            `json:"failed"`
+	Backfilled map[string]int `json:"backfilled"`
+	Duration   time.Duration  `json:"duration"`
+}
+
+type rawRecord struct {
+	key  string
+	data []byte
+}
+
+func AutoMigrate(models ...interface{}) ([]*MigrationReport, error) {
+	reports := make([]*MigrationReport, 0, len(models))
+	for _, model := range models {
+		report, err := autoMigrate(model)
+		if err != nil {
+			return reports, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func autoMigrate(model interface{}) (*MigrationReport, error) {
+	bucketName, err := reflection.GetBucketName(model)
+	if err != nil {
+		return nil, err
+	}
+	dbName, err := reflection.GetBucketDatabase(model)
+	if err != nil {
+		return nil, err
+	}
+	db, err := database.GetNamed(dbName)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.CreateBucket(bucketName); err != nil {
+		return nil, err
+	}
+
+	constructor := modelConstructor(bucketName, model)
+	fields := reflection.SerializedFields(reflect.TypeOf(model))
+	report := &MigrationReport{Bucket: bucketName, Backfilled: make(map[string]int)}
+	start := time.Now()
+
+	total, _ := db.Count(bucketName)
+	progress := database.Progress{Operation: database.OpAutoMigrate, Bucket: bucketName, Total: total}
+	db.ReportProgress(progress)
+	defer func() {
+		progress.Done, progress.Errors, progress.Finished = report.Scanned, report.Failed, true
+		db.ReportProgress(progress)
+	}()
+
+	var after []byte
+	for {
+		batch, err := readBatch(db, bucketName, after)
+		if err != nil {
+			return report, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		after = []byte(batch[len(batch)-1].key)
+
+		if err := migrateBatch(db, bucketName, batch, fields, constructor, report); err != nil {
+			return report, err
+		}
+		progress.Done, progress.Errors = report.Scanned, report.Failed
+		db.ReportProgress(progress)
+	}
+
+	report.Duration = time.Since(start)
+	logger.Success("automigrate of '%s': scanned %d, rewrote %d, indexed %d, failed %d%s in %s",
+		bucketName, report.Scanned, report.Rewritten, report.Indexed, report.Failed, backfillSummary(report.Backfilled), report.Duration)
+	return report, nil
+}
+
+func modelConstructor(bucketName string, model interface{}) func() interface{} {
+	if constructor, exists := BucketModels[bucketName]; exists {
+		return constructor
+	}
+
+	typ := reflect.TypeOf(model)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return func() interface{} {
+		return reflect.New(typ).Interface()
+	}
+}
+
+func readBatch(db *database.DB, bucketName string, after []byte) ([]rawRecord, error) {
+	batch := make([]rawRecord, 0, autoMigrateBatch)
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return nil
+		}
+
+		c := b.Cursor()
+		k, v := c.First()
+		if after != nil {
+			k, v = c.Seek(after)
+			if k != nil && bytes.Equal(k, after) {
+				k, v = c.Next()
+			}
+		}
+		for ; k != nil && len(batch) < autoMigrateBatch; k, v = c.Next() {
+			if len(v) == 0 {
+				continue
+			}
+			data := compression.DecompressData(db.ColdValue(bucketName, string(k), v))
+			batch = append(batch, rawRecord{key: string(k), data: append([]byte(nil), data...)})
+		}
+		return nil
+	})
+	return batch, err
+}
+
+func migrateBatch(db *database.DB, bucketName string, batch []rawRecord, fields []string, constructor func() interface{}, report *MigrationReport) error {
+	entities := make(map[string]interface{}, len(batch))
+	rewrites := make(map[string]interface{})
+	originals := make(map[string][]byte)
+
+	for _, record := range batch {
+		report.Scanned++
+
+		entity, missing, err := decodeRecord(record, fields, constructor)
+		if err != nil {
+			report.Failed++
+			logger.Warning("automigrate of '%s' key '%s' failed: %v", bucketName, record.key, err)
+			continue
+		}
+		entities[record.key] = entity
+
+		if len(missing) == 0 {
+			continue
+		}
+		if err := reflection.ApplyFieldDefaults(entity, missing); err != nil {
+			report.Failed++
+			logger.Warning("automigrate of '%s' key '%s' failed: %v", bucketName, record.key, err)
+			continue
+		}
+		computeFields(entity)
+		rewrites[record.key] = entity
+		originals[record.key] = record.data
+		for _, field := range missing {
+			report.Backfilled[field]++
+		}
+	}
+
+	for key, entity := range rewrites {
+		putErr := db.PutIf(bucketName, key, entity, database.Condition{MatchValue: originals[key]})
+		if err.Is(putErr, errors.ErrConditionFailed) {
+			delete(entities, key)
+			logger.Warning("automigrate of '%s' key '%s' skipped: record changed during migration", bucketName, key)
+			continue
+		}
+		if putErr != nil {
+			return putErr
+		}
+		if err := applyDerived(db, bucketName, key, entity); err != nil {
+			logger.Warning("automigrate of '%s' key '%s' failed: %v", bucketName, key, err)
+		}
+		report.Rewritten++
+	}
+
+	created := make(map[string]time.Time, len(entities))
+	for key, entity := range entities {
+		indexing.UpdateIndex(bucketName, key, entity)
+		report.Indexed++
+		if holder, ok := entity.(bucketHolder); ok {
+			created[key] = holder.bucketRef().CreatedAt
+		}
+	}
+	if len(created) > 0 {
+		if err := db.SetCreatedMany(bucketName, created); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeRecord(record rawRecord, fields []string, constructor func() interface{}) (interface{}, []string, error) {
+	var present map[string]json.RawMessage
+	if err := js.Unmarshal(record.data, &present); err != nil {
+		return nil, nil, err
+	}
+
+	entity := constructor()
+	if err := js.Unmarshal(record.data, entity); err != nil {
+		return nil, nil, err
+	}
+
+	var missing []string
+	for _, field := range fields {
+		if _, exists := present[field]; !exists {
+			missing = append(missing, field)
+		}
+	}
+	return entity, missing, nil
+}
+
+func backfillSummary(backfilled map[string]int) string {
+	if len(backfilled) == 0 {
+		return ""
+	}
+
+	fields := make([]string, 0, len(backfilled))
+	for field := range backfilled {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	summary := ", backfilled"
+	for _, field := range fields {
+		summary += fmt.Sprintf(" %s=%d", field, backfilled[field])
+	}
+	return summary
+}
@@ -2,10 +2,10 @@ package bucket
 
 import (
 	"errors"
+	"reflect"
 	"time"
 
 	"github.com/andr1ww/odin/database"
-	"github.com/andr1ww/odin/internal/indexing"
 	"github.com/andr1ww/odin/internal/reflection"
 )
 
@@ -15,10 +15,29 @@ type Bucket struct {
 	UpdatedAt time.Time  `json:"updated_at"`
 	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 	dbName    string
+	snapshot  []byte
 }
 
 var BucketModels = make(map[string]func() interface{})
 
+type FieldComputer interface {
+	ComputeFields()
+}
+
+func computeFields(entity interface{}) {
+	if computer, ok := entity.(FieldComputer); ok {
+		computer.ComputeFields()
+	}
+}
+
+type bucketHolder interface {
+	bucketRef() *Bucket
+}
+
+func (b *Bucket) bucketRef() *Bucket {
+	return b
+}
+
 func (b *Bucket) BeforeSave() {
 	now := time.Now()
 	if b.CreatedAt.IsZero() {
@@ -27,6 +46,22 @@ func (b *Bucket) BeforeSave() {
 	b.UpdatedAt = now
 }
 
+// key returns the record key of entity. A field tagged odin:"key" decides
+// it over the embedded ID, which is set to match.
+func (b *Bucket) key(entity interface{}) (string, error) {
+	if id, tagged, err := reflection.RecordKey(entity); tagged {
+		if err != nil {
+			return "", err
+		}
+		b.ID = id
+		return id, nil
+	}
+	if b.ID == "" {
+		return "", errors.New("ID field is required")
+	}
+	return b.ID, nil
+}
+
 func (b *Bucket) SetDatabase(dbName string) {
 	b.dbName = dbName
 }
@@ -45,21 +80,46 @@ func RegisterBucketModel(model interface{}, constructor func() interface{}) erro
 	return nil
 }
 
-func (b *Bucket) Save(entity interface{}) error {
+func RegisterKeyField(model interface{}, fieldName string) error {
+	return reflection.RegisterKeyField(model, fieldName)
+}
+
+type SaveOption func(*saveOptions)
+
+type saveOptions struct {
+	fields []string
+}
+
+func Fields(names ...string) SaveOption {
+	return func(o *saveOptions) {
+		o.fields = append(o.fields, names...)
+	}
+}
+
+func (b *Bucket) Save(entity interface{}, opts ...SaveOption) error {
 	dbName, err := reflection.GetBucketDatabase(entity)
 	if err != nil {
 		return err
 	}
 
-	return b.SaveToDatabase(dbName, entity)
+	return b.SaveToDatabase(dbName, entity, opts...)
 }
 
-func (b *Bucket) SaveToDatabase(dbName string, entity interface{}) error {
+func (b *Bucket) SaveToDatabase(dbName string, entity interface{}, opts ...SaveOption) error {
 	db, err := database.GetNamed(dbName)
 	if err != nil {
 		return err
 	}
 
+	var options saveOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if b.snapshot != nil && !b.IsDirty(entity) {
+		return nil
+	}
+
 	b.BeforeSave()
 
 	bucketName, err := reflection.GetBucketName(entity)
@@ -67,13 +127,54 @@ func (b *Bucket) SaveToDatabase(dbName string, entity interface{}) error {
 		return err
 	}
 
-	id := b.ID
-	if id == "" {
-		return errors.New("ID field is required")
+	id, err := b.key(entity)
+	if err != nil {
+		return err
 	}
 
-	indexing.UpdateIndex(bucketName, id, entity)
-	return db.Put(bucketName, id, entity)
+	computeFields(entity)
+
+	if len(options.fields) > 0 {
+		return b.saveFields(db, bucketName, id, entity, options.fields)
+	}
+
+	trackIndexed(bucketName, entity)
+	if err := db.Put(bucketName, id, entity); err != nil {
+		return err
+	}
+	if err := applyDerived(db, bucketName, id, entity); err != nil {
+		return err
+	}
+	markClean(entity)
+	return nil
+}
+
+func applyDerived(db *database.DB, bucketName, id string, entity interface{}) error {
+	if _, tagged := reflection.GetExpireField(entity); tagged {
+		at, ok := reflection.GetExpireTime(entity)
+		if !ok {
+			return errors.New("expire field must be a time.Time or *time.Time")
+		}
+		if err := db.SetExpiry(bucketName, id, at); err != nil {
+			return err
+		}
+	}
+
+	if lat, lng, tagged, ok := reflection.GetGeoPoint(entity); tagged {
+		if !ok {
+			return errors.New("geo fields must be numeric")
+		}
+		if err := db.SetLocation(bucketName, id, lat, lng); err != nil {
+			return err
+		}
+	}
+
+	if holder, ok := entity.(bucketHolder); ok && !holder.bucketRef().CreatedAt.IsZero() {
+		if err := db.SetCreated(bucketName, id, holder.bucketRef().CreatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (b *Bucket) Delete(entity interface{}) error {
@@ -96,12 +197,12 @@ func (b *Bucket) DeleteFromDatabase(dbName string, entity interface{}) error {
 		return err
 	}
 
-	id := b.ID
-	if id == "" {
-		return errors.New("ID field is required")
+	id, err := b.key(entity)
+	if err != nil {
+		return err
 	}
 
-	indexing.RemoveFromIndex(bucketName, id, entity)
+	trackIndexed(bucketName, entity)
 	return db.Delete(bucketName, id)
 }
 
@@ -119,3 +220,45 @@ func (b *Bucket) SoftDeleteFromDatabase(dbName string, entity interface{}) error
 	b.DeletedAt = &now
 	return b.SaveToDatabase(dbName, entity)
 }
+
+func (b *Bucket) Reload(entity interface{}) error {
+	dbName, err := reflection.GetBucketDatabase(entity)
+	if err != nil {
+		return err
+	}
+
+	return b.ReloadFromDatabase(dbName, entity)
+}
+
+func (b *Bucket) ReloadFromDatabase(dbName string, entity interface{}) error {
+	db, err := database.GetNamed(dbName)
+	if err != nil {
+		return err
+	}
+
+	bucketName, err := reflection.GetBucketName(entity)
+	if err != nil {
+		return err
+	}
+
+	id, err := b.key(entity)
+	if err != nil {
+		return err
+	}
+
+	target := reflect.ValueOf(entity)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return errors.New("entity must be a non-nil pointer")
+	}
+
+	fresh := reflect.New(target.Elem().Type())
+	if err := db.Get(bucketName, id, fresh.Interface()); err != nil {
+		return err
+	}
+
+	previousDB := b.dbName
+	target.Elem().Set(fresh.Elem())
+	b.dbName = previousDB
+	markClean(entity)
+	return nil
+}
@@ -1,47 +1,21 @@
 package bucket
 
 import (
-	"bytes"
-	"compress/gzip"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"io"
 	"reflect"
-	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/andr1ww/odin/database"
-	"github.com/andr1ww/odin/internal/compression"
+	"github.com/andr1ww/odin/errors"
 	"github.com/andr1ww/odin/internal/indexing"
+	"github.com/andr1ww/odin/internal/keys"
 	"github.com/andr1ww/odin/internal/reflection"
 )
 
 var (
-	gzipReaderPool = sync.Pool{
-		New: func() interface{} {
-			return &gzip.Reader{}
-		},
-	}
-	workerPool = sync.Pool{
-		New: func() interface{} {
-			slice := make([]interface{}, 0, 200)
-			return &slice
-		},
-	}
-	bufferPool = sync.Pool{
-		New: func() interface{} {
-			return &bytes.Buffer{}
-		},
-	}
-	dataBufferPool = sync.Pool{
-		New: func() interface{} {
-			buf := make([]byte, 0, 4096)
-			return &buf
-		},
-	}
 	fieldMatcherCache = sync.Map{}
 )
 
@@ -86,63 +60,40 @@ func FindInDatabase(dbName, bucketName, id string, entity interface{}) error {
 		return err
 	}
 
-	return db.Get(bucketName, id, entity)
+	if err := db.Get(bucketName, id, entity); err != nil {
+		return err
+	}
+	markClean(entity)
+	return nil
 }
 
 func FindWhereInDatabase(dbName, bucketName string, criteria map[string]interface{}, constructor func() interface{}) ([]interface{}, error) {
-	db, err := database.GetNamed(dbName)
+	keyed, err := FindWhereKeyedInDatabase(dbName, bucketName, criteria, constructor)
 	if err != nil {
 		return nil, err
 	}
+	return entitiesOf(keyed), nil
+}
 
-	if indexing.HasIndex(bucketName) {
-		if len(criteria) == 1 {
-			for field, value := range criteria {
-				if keys, found := indexing.GetIndexedKeys(bucketName, field, value); found {
-					results := make([]interface{}, 0, len(keys))
-					for _, key := range keys {
-						entity := constructor()
-						if err := db.Get(bucketName, key, entity); err == nil {
-							results = append(results, entity)
-						}
-					}
-					return results, nil
-				}
-			}
-		}
+func FindWhereKeyedInDatabase(dbName, bucketName string, criteria map[string]interface{}, constructor func() interface{}) ([]Keyed, error) {
+	db, err := database.GetNamed(dbName)
+	if err != nil {
+		return nil, err
+	}
 
-		if len(criteria) > 1 {
-			var candidateKeys []string
-			firstField := true
-
-			for field, value := range criteria {
-				if keys, found := indexing.GetIndexedKeys(bucketName, field, value); found {
-					if firstField {
-						candidateKeys = keys
-						firstField = false
-					} else {
-						candidateKeys = intersectStringSlices(candidateKeys, keys)
-						if len(candidateKeys) == 0 {
-							return []interface{}{}, nil
-						}
-					}
-				} else {
-					candidateKeys = nil
-					break
-				}
-			}
+	var results []Keyed
+	err = db.Intercept(database.OpInfo{Op: database.OpFindWhere, Bucket: bucketName, Criteria: criteria}, func() error {
+		var findErr error
+		results, findErr = findWhereKeyed(db, bucketName, criteria, constructor, &scanStats{})
+		return findErr
+	})
+	return results, err
+}
 
-			if candidateKeys != nil {
-				results := make([]interface{}, 0, len(candidateKeys))
-				for _, key := range candidateKeys {
-					entity := constructor()
-					if err := db.Get(bucketName, key, entity); err == nil {
-						results = append(results, entity)
-					}
-				}
-				return results, nil
-			}
-		}
+func findWhereKeyed(db *database.DB, bucketName string, criteria map[string]interface{}, constructor func() interface{}, stats *scanStats) ([]Keyed, error) {
+	criteria, err := reflection.CompileFilter(criteria)
+	if err != nil {
+		return nil, err
 	}
 
 	sampleEntity := constructor()
@@ -156,140 +107,76 @@ func FindWhereInDatabase(dbName, bucketName string, criteria map[string]interfac
 		fieldMatcherCache.Store(entityType, matcher)
 	}
 
-	numWorkers := runtime.NumCPU()
-	if numWorkers > 6 {
-		numWorkers = 6
-	}
-
-	workChan := make(chan []byte, numWorkers*2)
-	resultChan := make(chan []interface{}, numWorkers)
-	var wg sync.WaitGroup
-
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			localResultsPtr := workerPool.Get().(*[]interface{})
-			localResults := *localResultsPtr
-			defer func() {
-				*localResultsPtr = (*localResultsPtr)[:0]
-				workerPool.Put(localResultsPtr)
-			}()
-
-			buffer := bufferPool.Get().(*bytes.Buffer)
-			defer func() {
-				buffer.Reset()
-				bufferPool.Put(buffer)
-			}()
-
-			dataBufferPtr := dataBufferPool.Get().(*[]byte)
-			defer func() {
-				*dataBufferPtr = (*dataBufferPtr)[:0]
-				dataBufferPool.Put(dataBufferPtr)
-			}()
-
-			for data := range workChan {
-				if len(data) == 0 {
-					continue
-				}
-
-				var actualData []byte
-				if len(data) > 0 && (data[0] == 0 || data[0] == 1) {
-					if data[0] == 1 {
-						reader := gzipReaderPool.Get().(*gzip.Reader)
-						if err := reader.Reset(bytes.NewReader(data[1:])); err == nil {
-							*dataBufferPtr = (*dataBufferPtr)[:0]
-
-							chunk := make([]byte, 4096)
-							for {
-								n, err := reader.Read(chunk)
-								if n > 0 {
-									*dataBufferPtr = append(*dataBufferPtr, chunk[:n]...)
-								}
-								if err == io.EOF {
-									break
-								}
-								if err != nil {
-									actualData = data
-									break
-								}
-							}
-
-							if actualData == nil {
-								actualData = make([]byte, len(*dataBufferPtr))
-								copy(actualData, *dataBufferPtr)
-							}
-							reader.Close()
-						} else {
-							actualData = data
-						}
-						gzipReaderPool.Put(reader)
-					} else {
-						actualData = data[1:]
-					}
-				} else {
-					actualData = compression.DecompressData(data)
-				}
-
-				entity := constructor()
-
-				buffer.Reset()
-				buffer.Write(actualData)
-				decoder := json.NewDecoder(buffer)
-
-				if err := decoder.Decode(entity); err != nil {
-					continue
-				}
-
-				if reflection.MatchesCriteria(entity, criteria, matcher) {
-					localResults = append(localResults, entity)
-				}
-			}
-
-			if len(localResults) > 0 {
-				resultCopy := make([]interface{}, len(localResults))
-				copy(resultCopy, localResults)
-				resultChan <- resultCopy
-			} else {
-				resultChan <- nil
-			}
-		}()
-	}
-
-	go func() {
-		defer close(workChan)
-		db.ForEach(bucketName, func(_, v []byte) error {
-			dataCopy := make([]byte, len(v))
-			copy(dataCopy, v)
-			select {
-			case workChan <- dataCopy:
-			case <-time.After(10 * time.Second):
-				return fmt.Errorf("timeout writing to work channel")
-			}
-			return nil
-		})
+	started := time.Now()
+	defer func() {
+		db.ObserveOp(database.OpFindWhere, bucketName, summarizeCriteria(criteria), started, int(stats.scanned.Load()))
 	}()
 
-	go func() {
-		wg.Wait()
-		close(resultChan)
-	}()
+	if indexing.HasIndex(bucketName) {
+		results, keys, ok, err := findIndexed(db, bucketName, criteria, constructor, matcher, stats)
+		if ok || err != nil {
+			stats.strategy = PlanIndex
+			stats.scanned.Store(int64(keys))
+			return results, err
+		}
+	}
 
-	var results []interface{}
-	timeout := time.After(60 * time.Second)
-	for {
-		select {
-		case localResults, ok := <-resultChan:
-			if !ok {
-				return results, nil
-			}
-			if localResults != nil {
-				results = append(results, localResults...)
+	keys, field, ok, err := planTimeline(db, bucketName, criteria)
+	if err != nil && err != errors.ErrBucketMissing {
+		return nil, err
+	}
+	if ok {
+		stats.strategy, stats.indexed = PlanTimeline, []string{field}
+		stats.scanned.Store(int64(len(keys)))
+		return loadSnapshot(db, bucketName, keys, criteria, constructor, matcher, stats)
+	}
+
+	indexing.RecordFullScan(bucketName)
+	stats.strategy, stats.indexed = PlanFullScan, nil
+
+	var resultsMutex sync.Mutex
+	var results []Keyed
+	scanErr := db.ScanParallel(bucketName, func(k, v []byte) error {
+		stats.scanned.Add(1)
+
+		key := string(k)
+		entity := constructor()
+		if err := db.Decode(bucketName, key, v, entity); err != nil {
+			return stats.skip(db, bucketName, key, err)
+		}
+
+		if reflection.MatchesCriteria(entity, criteria, matcher) {
+			reflection.SetRecordKey(entity, key)
+			markClean(entity)
+			resultsMutex.Lock()
+			results = append(results, Keyed{Key: key, Entity: entity})
+			resultsMutex.Unlock()
+		}
+		return nil
+	})
+	if scanErr != nil && scanErr != errors.ErrBucketMissing {
+		return nil, scanErr
+	}
+	return results, nil
+}
+
+func summarizeCriteria(criteria map[string]interface{}) string {
+	fields := make([]string, 0, len(criteria))
+	for field, value := range criteria {
+		if clauses, ok := value.([]map[string]interface{}); ok {
+			parts := make([]string, len(clauses))
+			for i, clause := range clauses {
+				parts[i] = "{" + summarizeCriteria(clause) + "}"
 			}
-		case <-timeout:
-			return nil, fmt.Errorf("timeout waiting for results")
+			field += "(" + strings.Join(parts, " ") + ")"
+		} else if _, ok := value.(reflection.Operator); ok {
+			name := strings.TrimPrefix(fmt.Sprintf("%T", value), "reflection.")
+			field += " " + strings.TrimSuffix(name, "Operator")
 		}
+		fields = append(fields, field)
 	}
+	sort.Strings(fields)
+	return strings.Join(fields, ", ")
 }
 
 func intersectStringSlices(a, b []string) []string {
@@ -318,6 +205,17 @@ func intersectStringSlices(a, b []string) []string {
 }
 
 func CreateInDatabase(dbName string, entity interface{}) error {
+	if err := reflection.ApplyDefaults(entity); err != nil {
+		return err
+	}
+
+	if holder, ok := entity.(bucketHolder); ok {
+		bucket := holder.bucketRef()
+		bucket.SetDatabase(dbName)
+		bucket.BeforeSave()
+		return bucket.SaveToDatabase(dbName, entity)
+	}
+
 	val := reflect.ValueOf(entity)
 	if val.Kind() == reflect.Ptr {
 		val = val.Elem()
@@ -343,26 +241,67 @@ func CreateInDatabase(dbName string, entity interface{}) error {
 		return err
 	}
 
+	id, err := entityKey(entity)
+	if err != nil {
+		return err
+	}
+
+	computeFields(entity)
+
+	trackIndexed(bucketName, entity)
+	if err := db.Put(bucketName, id, entity); err != nil {
+		return err
+	}
+	return applyDerived(db, bucketName, id, entity)
+}
+
+func entityKey(entity interface{}) (string, error) {
+	if holder, ok := entity.(bucketHolder); ok {
+		return holder.bucketRef().key(entity)
+	}
+	if provider, ok := entity.(reflection.KeyProvider); ok {
+		if id := provider.OdinKey(); id != "" {
+			return id, nil
+		}
+	}
+	if id, tagged, err := reflection.RecordKey(entity); tagged {
+		return id, err
+	}
+
+	val := reflect.ValueOf(entity)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+
 	var id string
-	idField := val.FieldByName("ID")
-	if idField.IsValid() {
-		id = idField.String()
+	if idField := val.FieldByName("ID"); idField.IsValid() {
+		id = fieldKey(idField)
 	} else {
 		for i := 0; i < val.NumField(); i++ {
 			field := val.Type().Field(i)
 			if strings.HasSuffix(field.Name, "ID") {
-				id = val.Field(i).String()
+				id = fieldKey(val.Field(i))
 				break
 			}
 		}
 	}
 
 	if id == "" {
-		return errors.New("could not find ID field")
+		return "", fmt.Errorf("could not find ID field")
 	}
+	return id, nil
+}
 
-	indexing.UpdateIndex(bucketName, id, entity)
-	return db.Put(bucketName, id, entity)
+func fieldKey(field reflect.Value) string {
+	if field.IsZero() {
+		return ""
+	}
+	if field.Kind() != reflect.String {
+		if encoded, ok := keys.EncodeValue(field); ok {
+			return encoded
+		}
+	}
+	return field.String()
 }
 
 func FindAllInDatabase(dbName, bucketName string, constructor func() interface{}) ([]interface{}, error) {
@@ -371,5 +310,12 @@ func FindAllInDatabase(dbName, bucketName string, constructor func() interface{}
 		return nil, err
 	}
 
-	return db.GetAll(bucketName, constructor)
+	entities, err := db.GetAll(bucketName, constructor)
+	if err != nil {
+		return nil, err
+	}
+	for _, entity := range entities {
+		markClean(entity)
+	}
+	return entities, nil
 }
@@ -0,0 +1,65 @@
+package bucket
+
+import "reflect"
+
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+func markClean(entity interface{}) {
+	holder, ok := entity.(bucketHolder)
+	if !ok {
+		return
+	}
+
+	data, err := js.Marshal(entity)
+	if err != nil {
+		return
+	}
+	holder.bucketRef().snapshot = data
+}
+
+func (b *Bucket) IsDirty(entity interface{}) bool {
+	if b.snapshot == nil {
+		return true
+	}
+
+	current, err := js.Marshal(entity)
+	if err != nil {
+		return true
+	}
+	return string(current) != string(b.snapshot)
+}
+
+func (b *Bucket) Changes(entity interface{}) map[string]FieldChange {
+	var before map[string]interface{}
+	if b.snapshot != nil {
+		if err := js.Unmarshal(b.snapshot, &before); err != nil {
+			return nil
+		}
+	}
+
+	data, err := js.Marshal(entity)
+	if err != nil {
+		return nil
+	}
+	var after map[string]interface{}
+	if err := js.Unmarshal(data, &after); err != nil {
+		return nil
+	}
+
+	changes := make(map[string]FieldChange)
+	for field, value := range after {
+		old, existed := before[field]
+		if !existed || !reflect.DeepEqual(old, value) {
+			changes[field] = FieldChange{Old: old, New: value}
+		}
+	}
+	for field, old := range before {
+		if _, exists := after[field]; !exists {
+			changes[field] = FieldChange{Old: old}
+		}
+	}
+	return changes
+}
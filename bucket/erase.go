@@ -0,0 +1,189 @@
+package bucket
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/andr1ww/odin/database"
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/reflection"
+)
+
+type ErasedRecord struct {
+	Database string `json:"database"`
+	Bucket   string `json:"bucket"`
+	Key      string `json:"key"`
+	Action   string `json:"action"`
+}
+
+type ErasureReport struct {
+	Field     string         `json:"field"`
+	Subject   string         `json:"subject"`
+	At        time.Time      `json:"at"`
+	Records   []ErasedRecord `json:"records"`
+	Digest    string         `json:"digest"`
+	Signature string         `json:"signature,omitempty"`
+}
+
+type EraseOption func(*eraseOptions)
+
+type eraseOptions struct {
+	anonymize  bool
+	profile    ExportProfile
+	signingKey []byte
+}
+
+func Anonymize(profile ExportProfile) EraseOption {
+	return func(o *eraseOptions) {
+		o.anonymize = true
+		o.profile = profile
+	}
+}
+
+func SignWith(key []byte) EraseOption {
+	return func(o *eraseOptions) {
+		o.signingKey = key
+	}
+}
+
+func EraseSubject(field string, value interface{}, opts ...EraseOption) (*ErasureReport, error) {
+	var options eraseOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	subject := sha256.Sum256([]byte(fmt.Sprint(value)))
+	report := &ErasureReport{Field: field, Subject: hex.EncodeToString(subject[:]), At: time.Now().UTC()}
+
+	bucketNames := make([]string, 0, len(BucketModels))
+	for bucketName := range BucketModels {
+		bucketNames = append(bucketNames, bucketName)
+	}
+	sort.Strings(bucketNames)
+
+	for _, bucketName := range bucketNames {
+		constructor := BucketModels[bucketName]
+		model := constructor()
+		if _, exists := reflection.JSONFieldName(reflect.TypeOf(model), field); !exists {
+			continue
+		}
+
+		dbName, err := reflection.GetBucketDatabase(model)
+		if err != nil {
+			return report, err
+		}
+		if err := eraseInBucket(dbName, bucketName, field, value, constructor, options, report); err != nil {
+			return report, fmt.Errorf("erase subject from bucket '%s': %w", bucketName, err)
+		}
+	}
+
+	if err := report.seal(options.signingKey); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+func eraseInBucket(dbName, bucketName, field string, value interface{}, constructor func() interface{}, options eraseOptions, report *ErasureReport) error {
+	matches, err := FindWhereKeyedInDatabase(dbName, bucketName, map[string]interface{}{field: value}, constructor)
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+
+	db, err := database.GetNamed(dbName)
+	if err != nil {
+		return err
+	}
+
+	fields := piiFields(reflect.TypeOf(constructor()))
+	for _, match := range matches {
+		action := "deleted"
+		if options.anonymize {
+			action = "anonymized"
+			err = anonymizeRecord(db, bucketName, match, fields, constructor, options.profile)
+		} else {
+			err = purgeRecord(db, bucketName, match)
+		}
+		if err != nil {
+			return err
+		}
+		report.Records = append(report.Records, ErasedRecord{Database: dbName, Bucket: bucketName, Key: match.Key, Action: action})
+	}
+	return nil
+}
+
+func purgeRecord(db *database.DB, bucketName string, match Keyed) error {
+	trackIndexed(bucketName, match.Entity)
+	if err := db.Delete(bucketName, match.Key); err != nil {
+		return err
+	}
+
+	if err := db.Delete(database.TrashBucketName(bucketName), match.Key); err != nil && err != errors.ErrBucketMissing {
+		return err
+	}
+	return nil
+}
+
+func anonymizeRecord(db *database.DB, bucketName string, match Keyed, fields map[string]string, constructor func() interface{}, profile ExportProfile) error {
+	doc, err := db.GetDoc(bucketName, match.Key)
+	if err != nil {
+		return err
+	}
+	redactRow(doc, fields, profile)
+
+	data, err := js.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	entity := constructor()
+	if err := js.Unmarshal(data, entity); err != nil {
+		return fmt.Errorf("anonymized record '%s' no longer decodes: %w", match.Key, err)
+	}
+
+	trackIndexed(bucketName, entity)
+	return db.Put(bucketName, match.Key, entity)
+}
+
+func (r *ErasureReport) payload() ([]byte, error) {
+	unsigned := *r
+	unsigned.Digest, unsigned.Signature = "", ""
+	return js.Marshal(unsigned)
+}
+
+func (r *ErasureReport) seal(key []byte) error {
+	payload, err := r.payload()
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(payload)
+	r.Digest = hex.EncodeToString(digest[:])
+	if len(key) > 0 {
+		mac := hmac.New(sha256.New, key)
+		mac.Write(payload)
+		r.Signature = hex.EncodeToString(mac.Sum(nil))
+	}
+	return nil
+}
+
+func (r *ErasureReport) Verify(key []byte) bool {
+	payload, err := r.payload()
+	if err != nil || r.Signature == "" {
+		return false
+	}
+
+	signature, err := hex.DecodeString(r.Signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return hmac.Equal(signature, mac.Sum(nil))
+}
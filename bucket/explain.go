@@ -0,0 +1,145 @@
+package bucket
+
+import (
+	"sort"
+
+	"github.com/andr1ww/odin/database"
+	"github.com/andr1ww/odin/internal/indexing"
+	"github.com/andr1ww/odin/internal/reflection"
+)
+
+const (
+	PlanIndex    = "index"
+	PlanTimeline = "timeline"
+	PlanFullScan = "full scan"
+)
+
+type FieldPlan struct {
+	Field   string
+	Indexed bool
+	Keys    int
+	Reason  string
+}
+
+type Plan struct {
+	Database   string
+	Bucket     string
+	Strategy   string
+	Reason     string
+	Fields     []FieldPlan
+	Candidates int
+	Records    int
+	Workers    int
+}
+
+func Explain(bucketName string, criteria map[string]interface{}, constructor func() interface{}) (*Plan, error) {
+	dbName, err := reflection.GetBucketDatabase(constructor())
+	if err != nil {
+		return nil, err
+	}
+	return ExplainInDatabase(dbName, bucketName, criteria)
+}
+
+func ExplainInDatabase(dbName, bucketName string, criteria map[string]interface{}) (*Plan, error) {
+	db, err := database.GetNamed(dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	criteria, err = reflection.CompileFilter(criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := db.Count(bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Database: dbName, Bucket: bucketName, Records: records}
+
+	fields := make([]string, 0, len(criteria))
+	for field := range criteria {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	hasIndex := indexing.HasIndex(bucketName)
+	for _, field := range fields {
+		plan.Fields = append(plan.Fields, explainField(bucketName, field, criteria[field], hasIndex))
+	}
+
+	if hasIndex {
+		if keys, ok := planCriteria(bucketName, criteria, false, nil); ok {
+			plan.Strategy, plan.Candidates, plan.Workers = PlanIndex, len(keys), 1
+			return plan, nil
+		}
+	}
+
+	if keys, _, ok, err := planTimeline(db, bucketName, criteria); err != nil {
+		return nil, err
+	} else if ok {
+		plan.Strategy, plan.Candidates, plan.Workers = PlanTimeline, len(keys), 1
+		return plan, nil
+	}
+
+	plan.Strategy, plan.Candidates, plan.Workers = PlanFullScan, records, db.ScanWorkers()
+	switch {
+	case len(criteria) == 0:
+		plan.Reason = "no criteria"
+	case !hasIndex:
+		plan.Reason = "bucket has no index"
+	default:
+		plan.Reason = "no criterion could be answered from the index"
+	}
+	return plan, nil
+}
+
+func explainField(bucketName, field string, value interface{}, hasIndex bool) FieldPlan {
+	fieldPlan := FieldPlan{Field: field}
+	if !hasIndex {
+		fieldPlan.Reason = "bucket has no index"
+		return fieldPlan
+	}
+
+	switch field {
+	case "$and", "$or":
+		var keys []string
+		var ok bool
+		if field == "$and" {
+			keys, ok = planAll(bucketName, value.([]map[string]interface{}), false, nil)
+		} else {
+			keys, ok = planAny(bucketName, value.([]map[string]interface{}), false, nil)
+		}
+		fieldPlan.Indexed, fieldPlan.Keys = ok, len(keys)
+		if !ok {
+			fieldPlan.Reason = "a clause could not be answered from the index"
+		}
+		return fieldPlan
+	case "$nor":
+		fieldPlan.Reason = "exclusions only narrow other indexed criteria"
+		return fieldPlan
+	}
+
+	if keys, ok := indexing.LookupKeys(bucketName, field, value); ok {
+		fieldPlan.Indexed, fieldPlan.Keys = true, len(keys)
+		return fieldPlan
+	}
+
+	switch _, isOperator := value.(reflection.Operator); {
+	case !indexing.FieldIndexed(bucketName, field):
+		fieldPlan.Reason = "field is not indexed"
+	case isOperator:
+		fieldPlan.Reason = "operator cannot use the index"
+	case !indexable(value):
+		fieldPlan.Reason = "comparator has no index key"
+	default:
+		fieldPlan.Reason = "value not found in the index"
+	}
+	return fieldPlan
+}
+
+func indexable(value interface{}) bool {
+	_, ok := reflection.IndexKey(value)
+	return ok
+}
@@ -0,0 +1,146 @@
+package bucket
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/andr1ww/odin/database"
+	"github.com/andr1ww/odin/internal/reflection"
+)
+
+type Redaction int
+
+const (
+	RedactDrop Redaction = iota
+	RedactMask
+	RedactHash
+	RedactKeep
+)
+
+type ExportProfile struct {
+	Redact  map[string]Redaction
+	Default Redaction
+	Salt    string
+}
+
+func (p ExportProfile) redaction(category string) Redaction {
+	if action, exists := p.Redact[category]; exists {
+		return action
+	}
+	return p.Default
+}
+
+func Export(model interface{}, w io.Writer, profile ExportProfile) (int, error) {
+	bucketName, err := reflection.GetBucketName(model)
+	if err != nil {
+		return 0, err
+	}
+	dbName, err := reflection.GetBucketDatabase(model)
+	if err != nil {
+		return 0, err
+	}
+	return ExportInDatabase(dbName, bucketName, model, w, profile)
+}
+
+func ExportInDatabase(dbName, bucketName string, model interface{}, w io.Writer, profile ExportProfile) (int, error) {
+	if model == nil {
+		return 0, fmt.Errorf("nil model provided")
+	}
+
+	db, err := database.GetNamed(dbName)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := db.GetAllMaps(bucketName)
+	if err != nil {
+		return 0, err
+	}
+
+	fields := piiFields(reflect.TypeOf(model))
+	encoder := js.NewEncoder(w)
+	for i, row := range rows {
+		redactRow(row, fields, profile)
+		if err := encoder.Encode(row); err != nil {
+			return i, fmt.Errorf("export bucket '%s': %w", bucketName, err)
+		}
+	}
+	return len(rows), nil
+}
+
+func piiFields(typ reflect.Type) map[string]string {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	fields := make(map[string]string)
+	if typ.Kind() != reflect.Struct {
+		return fields
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if jsonName == "-" {
+			continue
+		}
+
+		if field.Anonymous && jsonName == "" {
+			for name, category := range piiFields(field.Type) {
+				if _, exists := fields[name]; !exists {
+					fields[name] = category
+				}
+			}
+			continue
+		}
+
+		category, tagged := field.Tag.Lookup("pii")
+		if !tagged || !field.IsExported() {
+			continue
+		}
+		if jsonName == "" {
+			jsonName = field.Name
+		}
+		fields[jsonName] = category
+	}
+	return fields
+}
+
+func redactRow(row map[string]interface{}, fields map[string]string, profile ExportProfile) {
+	for name, category := range fields {
+		value, exists := row[name]
+		if !exists || value == nil {
+			continue
+		}
+
+		switch profile.redaction(category) {
+		case RedactDrop:
+			delete(row, name)
+		case RedactMask:
+			row[name] = maskValue(category, value)
+		case RedactHash:
+			sum := sha256.Sum256([]byte(profile.Salt + fmt.Sprint(value)))
+			row[name] = hex.EncodeToString(sum[:])
+		}
+	}
+}
+
+func maskValue(category string, value interface{}) interface{} {
+	text, ok := value.(string)
+	if !ok || text == "" {
+		return "***"
+	}
+
+	if category == "email" {
+		if local, domain, found := strings.Cut(text, "@"); found && local != "" {
+			return local[:1] + "***@" + domain
+		}
+	}
+
+	runes := []rune(text)
+	return string(runes[0]) + "***"
+}
@@ -0,0 +1,36 @@
+package bucket
+
+import (
+	"github.com/andr1ww/odin/database"
+	"github.com/andr1ww/odin/internal/reflection"
+)
+
+func FindNear(bucketName string, lat, lng, radius float64, constructor func() interface{}) ([]interface{}, error) {
+	dbName, err := reflection.GetBucketDatabase(constructor())
+	if err != nil {
+		return nil, err
+	}
+	return FindNearInDatabase(dbName, bucketName, lat, lng, radius, constructor)
+}
+
+func FindNearInDatabase(dbName, bucketName string, lat, lng, radius float64, constructor func() interface{}) ([]interface{}, error) {
+	db, err := database.GetNamed(dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	hits, err := db.Near(bucketName, lat, lng, radius)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]interface{}, 0, len(hits))
+	for _, hit := range hits {
+		entity := constructor()
+		if err := db.Get(bucketName, hit.Key, entity); err == nil {
+			markClean(entity)
+			results = append(results, entity)
+		}
+	}
+	return results, nil
+}
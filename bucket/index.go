@@ -0,0 +1,93 @@
+package bucket
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/andr1ww/odin/database"
+	"github.com/andr1ww/odin/internal/indexing"
+	bolt "go.etcd.io/bbolt"
+)
+
+var indexedTypes sync.Map
+
+func init() {
+	database.OnConnect(func(_ string, db *database.DB) {
+		db.OnCommit(database.CommitHook{Stage: database.StageIndex, Buckets: indexesBucket, Run: applyIndexChanges, RunInTx: beginIndexChange, Discard: endIndexChanges})
+	})
+}
+
+func trackIndexed(bucketName string, entity interface{}) {
+	if _, known := indexedTypes.Load(bucketName); known {
+		return
+	}
+	entityType := reflect.TypeOf(entity)
+	if entityType != nil && entityType.Kind() == reflect.Ptr {
+		entityType = entityType.Elem()
+	}
+	if entityType != nil && entityType.Kind() == reflect.Struct {
+		indexedTypes.Store(bucketName, entityType)
+	}
+}
+
+func indexesBucket(bucketName string) bool {
+	if _, registered := BucketModels[bucketName]; registered {
+		return true
+	}
+	_, known := indexedTypes.Load(bucketName)
+	return known
+}
+
+func decodeIndexed(bucketName string, data []byte) (interface{}, bool) {
+	var entity interface{}
+	if constructor, registered := BucketModels[bucketName]; registered {
+		entity = constructor()
+	} else if entityType, known := indexedTypes.Load(bucketName); known {
+		entity = reflect.New(entityType.(reflect.Type)).Interface()
+	} else {
+		return nil, false
+	}
+	if err := js.Unmarshal(data, entity); err != nil {
+		return nil, false
+	}
+	return entity, true
+}
+
+func beginIndexChange(tx *bolt.Tx, change database.Change) error {
+	indexing.BeginChange(change.Bucket)
+	if !indexing.DiskIndexed(change.Bucket) {
+		return nil
+	}
+	if change.Value != nil {
+		if entity, ok := decodeIndexed(change.Bucket, change.Value); ok {
+			return indexing.UpdateDiskIndex(tx, change.Bucket, change.Key, entity)
+		}
+	}
+	return indexing.RemoveFromDiskIndex(tx, change.Bucket, change.Key)
+}
+
+func applyIndexChanges(changes []database.Change) {
+	defer endIndexChanges(changes)
+
+	for _, change := range changes {
+		if indexing.DiskIndexed(change.Bucket) {
+			continue
+		}
+		if change.Old != nil {
+			if old, ok := decodeIndexed(change.Bucket, change.Old); ok {
+				indexing.RemoveFromIndex(change.Bucket, change.Key, old)
+			}
+		}
+		if change.Value != nil {
+			if entity, ok := decodeIndexed(change.Bucket, change.Value); ok {
+				indexing.UpdateIndex(change.Bucket, change.Key, entity)
+			}
+		}
+	}
+}
+
+func endIndexChanges(changes []database.Change) {
+	for _, change := range changes {
+		indexing.EndChange(change.Bucket)
+	}
+}
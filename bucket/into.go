@@ -0,0 +1,103 @@
+package bucket
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/andr1ww/odin/internal/reflection"
+)
+
+func FindAllInto(bucketName string, dest interface{}) error {
+	dbName, err := destDatabase(dest)
+	if err != nil {
+		return err
+	}
+	return FindAllIntoDatabase(dbName, bucketName, dest)
+}
+
+func FindAllIntoDatabase(dbName, bucketName string, dest interface{}) error {
+	slice, elem, err := destSlice(dest)
+	if err != nil {
+		return err
+	}
+
+	entities, err := FindAllInDatabase(dbName, bucketName, elemConstructor(elem))
+	if err != nil {
+		return err
+	}
+	return appendInto(slice, elem, entities)
+}
+
+func FindWhereInto(bucketName string, criteria map[string]interface{}, dest interface{}) error {
+	dbName, err := destDatabase(dest)
+	if err != nil {
+		return err
+	}
+	return FindWhereIntoDatabase(dbName, bucketName, criteria, dest)
+}
+
+func FindWhereIntoDatabase(dbName, bucketName string, criteria map[string]interface{}, dest interface{}) error {
+	slice, elem, err := destSlice(dest)
+	if err != nil {
+		return err
+	}
+
+	entities, err := FindWhereInDatabase(dbName, bucketName, criteria, elemConstructor(elem))
+	if err != nil {
+		return err
+	}
+	return appendInto(slice, elem, entities)
+}
+
+func destSlice(dest interface{}) (reflect.Value, reflect.Type, error) {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return reflect.Value{}, nil, fmt.Errorf("destination must be a pointer to a slice, got %T", dest)
+	}
+
+	slice := v.Elem()
+	elem := slice.Type().Elem()
+	model := elem
+	if model.Kind() == reflect.Ptr {
+		model = model.Elem()
+	}
+	if model.Kind() != reflect.Struct {
+		return reflect.Value{}, nil, fmt.Errorf("destination elements must be structs or struct pointers, got %s", elem)
+	}
+	return slice, elem, nil
+}
+
+func destDatabase(dest interface{}) (string, error) {
+	_, elem, err := destSlice(dest)
+	if err != nil {
+		return "", err
+	}
+	return reflection.GetBucketDatabase(elemConstructor(elem)())
+}
+
+func elemConstructor(elem reflect.Type) func() interface{} {
+	model := elem
+	if model.Kind() == reflect.Ptr {
+		model = model.Elem()
+	}
+	return func() interface{} {
+		return reflect.New(model).Interface()
+	}
+}
+
+func appendInto(slice reflect.Value, elem reflect.Type, entities []interface{}) error {
+	grown := reflect.MakeSlice(slice.Type(), 0, slice.Len()+len(entities))
+	grown = reflect.AppendSlice(grown, slice)
+	for _, entity := range entities {
+		v := reflect.ValueOf(entity)
+		if elem.Kind() != reflect.Ptr {
+			v = v.Elem()
+		}
+		if !v.Type().AssignableTo(elem) {
+			return fmt.Errorf("cannot store %s in slice of %s", v.Type(), elem)
+		}
+		grown = reflect.Append(grown, v)
+	}
+	slice.Set(grown)
+	return nil
+}
@@ -0,0 +1,104 @@
+package bucket
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/andr1ww/odin/internal/reflection"
+)
+
+type Join struct {
+	Bucket       string
+	LocalField   string
+	ForeignField string
+	Where        map[string]interface{}
+	Constructor  func() interface{}
+}
+
+type Joined struct {
+	Key     string
+	Entity  interface{}
+	Related Keyed
+}
+
+func FindWhereJoined(bucketName string, criteria map[string]interface{}, constructor func() interface{}, join Join) ([]Joined, error) {
+	dbName, err := reflection.GetBucketDatabase(constructor())
+	if err != nil {
+		return nil, err
+	}
+	return FindWhereJoinedInDatabase(dbName, bucketName, criteria, constructor, join)
+}
+
+func FindWhereJoinedInDatabase(dbName, bucketName string, criteria map[string]interface{}, constructor func() interface{}, join Join) ([]Joined, error) {
+	if join.Bucket == "" || join.LocalField == "" || join.Constructor == nil {
+		return nil, fmt.Errorf("join needs a bucket, a local field and a constructor")
+	}
+
+	joinDB, err := reflection.GetBucketDatabase(join.Constructor())
+	if err != nil {
+		joinDB = dbName
+	}
+
+	related, err := FindWhereKeyedInDatabase(joinDB, join.Bucket, join.Where, join.Constructor)
+	if err != nil {
+		return nil, fmt.Errorf("join bucket '%s': %w", join.Bucket, err)
+	}
+	if len(related) == 0 {
+		return nil, nil
+	}
+
+	byValue := make(map[string]Keyed, len(related))
+	values := make([]interface{}, 0, len(related))
+	for _, row := range related {
+		value, ok := joinValue(row, join.ForeignField)
+		if !ok {
+			continue
+		}
+		id := fmt.Sprint(value)
+		if _, exists := byValue[id]; exists {
+			continue
+		}
+		byValue[id] = row
+		values = append(values, value)
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	joined := map[string]interface{}{join.LocalField: reflection.In(values...)}
+	if len(criteria) > 0 {
+		joined = reflection.And(criteria, joined)
+	}
+
+	parents, err := FindWhereKeyedInDatabase(dbName, bucketName, joined, constructor)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Joined, 0, len(parents))
+	for _, parent := range parents {
+		value, ok := joinValue(parent, join.LocalField)
+		if !ok {
+			continue
+		}
+		if row, exists := byValue[fmt.Sprint(value)]; exists {
+			results = append(results, Joined{Key: parent.Key, Entity: parent.Entity, Related: row})
+		}
+	}
+	return results, nil
+}
+
+func joinValue(row Keyed, field string) (interface{}, bool) {
+	if field == "" {
+		return row.Key, true
+	}
+
+	v := reflect.ValueOf(row.Entity)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+	return reflection.GetFieldMatcher(v.Type()).GetFieldValue(v, field)
+}
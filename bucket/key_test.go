@@ -0,0 +1,68 @@
+package bucket
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/andr1ww/odin/database"
+	"github.com/andr1ww/odin/internal/logger"
+)
+
+type keyedDevice struct {
+	Bucket `bucket:"devices" database:"bucket-key"`
+	Serial string `json:"serial" odin:"key"`
+	Name   string `json:"name"`
+}
+
+func TestKeyTagWinsOverEmbeddedID(t *testing.T) {
+	logger.DisableLogging()
+	name := "bucket-key"
+
+	if err := database.Connect(name, filepath.Join(t.TempDir(), "main.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close(name)
+
+	db, err := database.GetNamed(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateBucket("devices"); err != nil {
+		t.Fatal(err)
+	}
+
+	created := &keyedDevice{Serial: "sn-1", Name: "created"}
+	created.ID = "ignored"
+	if err := Create(created); err != nil {
+		t.Fatal(err)
+	}
+	if created.ID != "sn-1" {
+		t.Fatalf("ID = %q, want it set to the tagged key", created.ID)
+	}
+
+	saved := &keyedDevice{Serial: "sn-2", Name: "saved"}
+	if err := saved.Save(saved); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]string{"sn-1": "created", "sn-2": "saved"} {
+		var device keyedDevice
+		if err := db.Get("devices", key, &device); err != nil || device.Name != want {
+			t.Fatalf("record under %s = %+v, %v", key, device, err)
+		}
+	}
+	if exists, _ := db.Has("devices", "ignored"); exists {
+		t.Fatal("record was stored under the embedded ID")
+	}
+
+	saved.Name = "stale"
+	if err := saved.Reload(saved); err != nil || saved.Name != "saved" {
+		t.Fatalf("reload = %q, %v", saved.Name, err)
+	}
+	if err := saved.Delete(saved); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := db.Has("devices", "sn-2"); exists {
+		t.Fatal("delete missed the record stored under the tagged key")
+	}
+}
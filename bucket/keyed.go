@@ -0,0 +1,67 @@
+package bucket
+
+import (
+	"github.com/andr1ww/odin/database"
+	"github.com/andr1ww/odin/internal/reflection"
+)
+
+type Keyed struct {
+	Key    string
+	Entity interface{}
+}
+
+func FindWhereKeyed(bucketName string, criteria map[string]interface{}, constructor func() interface{}) ([]Keyed, error) {
+	dbName, err := reflection.GetBucketDatabase(constructor())
+	if err != nil {
+		return nil, err
+	}
+	return FindWhereKeyedInDatabase(dbName, bucketName, criteria, constructor)
+}
+
+func FindAllKeyed(bucketName string, constructor func() interface{}) ([]Keyed, error) {
+	dbName, err := reflection.GetBucketDatabase(constructor())
+	if err != nil {
+		return nil, err
+	}
+	return FindAllKeyedInDatabase(dbName, bucketName, constructor)
+}
+
+func FindAllKeyedInDatabase(dbName, bucketName string, constructor func() interface{}) ([]Keyed, error) {
+	db, err := database.GetNamed(dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Keyed
+	err = db.ForEach(bucketName, func(k, v []byte) error {
+		if len(v) == 0 {
+			return nil
+		}
+
+		entity := constructor()
+		if err := js.Unmarshal(v, entity); err != nil {
+			return db.SkipRecord(bucketName, string(k), err)
+		}
+		key := string(k)
+		reflection.SetRecordKey(entity, key)
+		markClean(entity)
+		results = append(results, Keyed{Key: key, Entity: entity})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func entitiesOf(keyed []Keyed) []interface{} {
+	if keyed == nil {
+		return nil
+	}
+
+	entities := make([]interface{}, len(keyed))
+	for i, k := range keyed {
+		entities[i] = k.Entity
+	}
+	return entities
+}
@@ -0,0 +1,157 @@
+package bucket
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/reflection"
+)
+
+type morphField struct {
+	target reflect.Value
+	typ    reflect.Value
+	id     reflect.Value
+}
+
+func lookupMorph(entity interface{}, field string) (morphField, error) {
+	val := reflect.ValueOf(entity)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return morphField{}, fmt.Errorf("expected pointer to struct, got %T", entity)
+	}
+	val = val.Elem()
+
+	structField, ok := val.Type().FieldByName(field)
+	if !ok {
+		return morphField{}, fmt.Errorf("field %s not found on %s", field, val.Type().Name())
+	}
+
+	tag := structField.Tag.Get("morph")
+	parts := strings.Split(tag, ",")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return morphField{}, fmt.Errorf("field %s needs a morph:\"TypeField,IDField\" tag", field)
+	}
+
+	target := val.FieldByIndex(structField.Index)
+	typ := val.FieldByName(strings.TrimSpace(parts[0]))
+	id := val.FieldByName(strings.TrimSpace(parts[1]))
+	if !typ.IsValid() || typ.Kind() != reflect.String {
+		return morphField{}, fmt.Errorf("morph type field %s must be a string", parts[0])
+	}
+	if !id.IsValid() || id.Kind() != reflect.String {
+		return morphField{}, fmt.Errorf("morph id field %s must be a string", parts[1])
+	}
+
+	return morphField{target: target, typ: typ, id: id}, nil
+}
+
+func (m morphField) set(owner interface{}) {
+	if owner == nil {
+		m.target.Set(reflect.Zero(m.target.Type()))
+		return
+	}
+
+	ownerValue := reflect.ValueOf(owner)
+	if ownerValue.Type().AssignableTo(m.target.Type()) {
+		m.target.Set(ownerValue)
+	}
+}
+
+func SetMorph(entity interface{}, field string, owner interface{}) error {
+	m, err := lookupMorph(entity, field)
+	if err != nil {
+		return err
+	}
+
+	if owner == nil {
+		m.typ.SetString("")
+		m.id.SetString("")
+		m.set(nil)
+		return nil
+	}
+
+	ownerType, err := reflection.GetBucketName(owner)
+	if err != nil {
+		return err
+	}
+	ownerID, err := entityKey(owner)
+	if err != nil {
+		return err
+	}
+
+	m.typ.SetString(ownerType)
+	m.id.SetString(ownerID)
+	m.set(owner)
+	return nil
+}
+
+func loadMorph(ownerType, ownerID string) (interface{}, error) {
+	constructor, exists := BucketModels[ownerType]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", errors.ErrModelNotRegistered, ownerType)
+	}
+
+	owner := constructor()
+	dbName, _ := reflection.GetBucketDatabase(owner)
+	if err := FindInDatabase(dbName, ownerType, ownerID, owner); err != nil {
+		return nil, err
+	}
+	return owner, nil
+}
+
+func ResolveMorph(entity interface{}, field string) (interface{}, error) {
+	m, err := lookupMorph(entity, field)
+	if err != nil {
+		return nil, err
+	}
+
+	ownerType, ownerID := m.typ.String(), m.id.String()
+	if ownerType == "" || ownerID == "" {
+		m.set(nil)
+		return nil, nil
+	}
+
+	owner, err := loadMorph(ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.set(owner)
+	return owner, nil
+}
+
+func PreloadMorph(entities interface{}, field string) error {
+	list := reflect.ValueOf(entities)
+	if list.Kind() != reflect.Slice {
+		return fmt.Errorf("expected slice, got %T", entities)
+	}
+
+	loaded := make(map[string]interface{})
+	for i := 0; i < list.Len(); i++ {
+		entity := list.Index(i).Interface()
+
+		m, err := lookupMorph(entity, field)
+		if err != nil {
+			return err
+		}
+
+		ownerType, ownerID := m.typ.String(), m.id.String()
+		if ownerType == "" || ownerID == "" {
+			m.set(nil)
+			continue
+		}
+
+		cacheKey := ownerType + "\x00" + ownerID
+		owner, cached := loaded[cacheKey]
+		if !cached {
+			owner, err = loadMorph(ownerType, ownerID)
+			if err != nil && err != errors.ErrNotFound {
+				return err
+			}
+			loaded[cacheKey] = owner
+		}
+		m.set(owner)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package bucket
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/andr1ww/odin/database"
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/reflection"
+	bolt "go.etcd.io/bbolt"
+)
+
+func FindAfter(bucketName, lastKey string, limit int, constructor func() interface{}) ([]interface{}, string, error) {
+	dbName, err := reflection.GetBucketDatabase(constructor())
+	if err != nil {
+		return nil, "", err
+	}
+	return FindAfterInDatabase(dbName, bucketName, lastKey, limit, constructor)
+}
+
+func FindAfterInDatabase(dbName, bucketName, lastKey string, limit int, constructor func() interface{}) ([]interface{}, string, error) {
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("limit must be positive, got %d", limit)
+	}
+
+	db, err := database.GetNamed(dbName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	results := make([]interface{}, 0, limit)
+	var last, next string
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrBucketMissing
+		}
+
+		c := b.Cursor()
+		k, v := c.First()
+		if lastKey != "" {
+			k, v = c.Seek([]byte(lastKey))
+			if k != nil && bytes.Equal(k, []byte(lastKey)) {
+				k, v = c.Next()
+			}
+		}
+
+		for ; k != nil; k, v = c.Next() {
+			if len(v) == 0 {
+				continue
+			}
+			if len(results) == limit {
+				next = last
+				return nil
+			}
+
+			entity := constructor()
+			if err := db.Decode(bucketName, string(k), v, entity); err != nil {
+				if skipErr := db.SkipRecord(bucketName, string(k), err); skipErr != nil {
+					return skipErr
+				}
+				continue
+			}
+			reflection.SetRecordKey(entity, string(k))
+			markClean(entity)
+			results = append(results, entity)
+			last = string(k)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return results, next, nil
+}
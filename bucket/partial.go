@@ -0,0 +1,55 @@
+package bucket
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/andr1ww/odin/database"
+	"github.com/andr1ww/odin/internal/jsonpatch"
+	"github.com/andr1ww/odin/internal/reflection"
+)
+
+func (b *Bucket) saveFields(db *database.DB, bucketName, id string, entity interface{}, fields []string) error {
+	data, err := js.Marshal(entity)
+	if err != nil {
+		return err
+	}
+	var doc map[string]interface{}
+	if err := js.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	typ := reflect.TypeOf(entity)
+	patch := map[string]interface{}{"updated_at": doc["updated_at"]}
+	for _, field := range fields {
+		name, ok := reflection.JSONFieldName(typ, field)
+		if !ok {
+			return fmt.Errorf("unknown field %q", field)
+		}
+		patch[name] = doc[name]
+	}
+
+	patchData, err := js.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	trackIndexed(bucketName, entity)
+	if err := db.Merge(bucketName, id, patchData); err != nil {
+		return err
+	}
+
+	stored := reflect.New(reflect.TypeOf(entity).Elem()).Interface()
+	if err := db.Get(bucketName, id, stored); err != nil {
+		return err
+	}
+	if err := applyDerived(db, bucketName, id, stored); err != nil {
+		return err
+	}
+
+	if b.snapshot != nil {
+		if merged, err := jsonpatch.MergePatch(b.snapshot, patchData); err == nil {
+			b.snapshot = merged
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package bucket
+
+import (
+	"github.com/andr1ww/odin/internal/indexing"
+)
+
+func planKeys(bucketName string, criteria map[string]interface{}, used *[]string) ([]string, bool) {
+	return planCriteria(bucketName, criteria, true, used)
+}
+
+func planCriteria(bucketName string, criteria map[string]interface{}, record bool, used *[]string) ([]string, bool) {
+	lookup := indexing.LookupKeys
+	if record {
+		lookup = indexing.GetIndexedKeys
+	}
+
+	var candidates, excluded []string
+	resolved := false
+
+	for field, value := range criteria {
+		var keys []string
+		var ok bool
+
+		switch field {
+		case "$and":
+			keys, ok = planAll(bucketName, value.([]map[string]interface{}), record, used)
+		case "$or":
+			keys, ok = planAny(bucketName, value.([]map[string]interface{}), record, used)
+		case "$nor":
+			if nor, found := planAny(bucketName, value.([]map[string]interface{}), record, used); found {
+				excluded = append(excluded, nor...)
+			}
+			continue
+		default:
+			keys, ok = lookup(bucketName, field, value)
+			if ok && used != nil {
+				*used = append(*used, field)
+			}
+		}
+
+		if !ok {
+			continue
+		}
+		if !resolved {
+			candidates, resolved = keys, true
+		} else {
+			input := len(candidates)
+			candidates = intersectStringSlices(candidates, keys)
+			if record {
+				indexing.RecordIntersection(bucketName, field, input, len(candidates))
+			}
+		}
+	}
+
+	if !resolved {
+		return nil, false
+	}
+	return subtractStringSlices(candidates, excluded), true
+}
+
+func planAll(bucketName string, clauses []map[string]interface{}, record bool, used *[]string) ([]string, bool) {
+	var candidates []string
+	resolved := false
+
+	for _, clause := range clauses {
+		keys, ok := planCriteria(bucketName, clause, record, used)
+		if !ok {
+			continue
+		}
+		if !resolved {
+			candidates, resolved = keys, true
+		} else {
+			candidates = intersectStringSlices(candidates, keys)
+		}
+	}
+	return candidates, resolved
+}
+
+func planAny(bucketName string, clauses []map[string]interface{}, record bool, used *[]string) ([]string, bool) {
+	seen := make(map[string]bool)
+	var union, fields []string
+
+	for _, clause := range clauses {
+		keys, ok := planCriteria(bucketName, clause, record, &fields)
+		if !ok {
+			return nil, false
+		}
+		for _, key := range keys {
+			if !seen[key] {
+				seen[key] = true
+				union = append(union, key)
+			}
+		}
+	}
+	if used != nil {
+		*used = append(*used, fields...)
+	}
+	return union, true
+}
+
+func subtractStringSlices(a, b []string) []string {
+	if len(b) == 0 {
+		return a
+	}
+
+	exclude := make(map[string]bool, len(b))
+	for _, item := range b {
+		exclude[item] = true
+	}
+
+	result := make([]string, 0, len(a))
+	for _, item := range a {
+		if !exclude[item] {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,223 @@
+package bucket
+
+import (
+	"github.com/andr1ww/odin/database"
+	"github.com/andr1ww/odin/internal/reflection"
+)
+
+type ManyToMany[L, R any] struct {
+	dbName      string
+	name        string
+	leftBucket  string
+	rightBucket string
+}
+
+func JoinFor[L, R any]() *ManyToMany[L, R] {
+	left, right := new(L), new(R)
+	leftBucket, _ := reflection.GetBucketName(left)
+	rightBucket, _ := reflection.GetBucketName(right)
+	dbName, _ := reflection.GetBucketDatabase(left)
+
+	return &ManyToMany[L, R]{
+		dbName:      dbName,
+		name:        leftBucket + "_" + rightBucket,
+		leftBucket:  leftBucket,
+		rightBucket: rightBucket,
+	}
+}
+
+func (m *ManyToMany[L, R]) InDatabase(dbName string) *ManyToMany[L, R] {
+	c := *m
+	c.dbName = dbName
+	return &c
+}
+
+func (m *ManyToMany[L, R]) table() (*database.DB, *database.JoinTable, error) {
+	db, err := database.GetNamed(m.dbName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	table, err := db.JoinTable(m.name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, table, nil
+}
+
+func keysOf[T any](entities []*T) ([]string, error) {
+	ids := make([]string, 0, len(entities))
+	for _, entity := range entities {
+		id, err := entityKey(entity)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (m *ManyToMany[L, R]) Attach(left *L, rights ...*R) error {
+	leftID, err := entityKey(left)
+	if err != nil {
+		return err
+	}
+	rightIDs, err := keysOf(rights)
+	if err != nil {
+		return err
+	}
+
+	_, table, err := m.table()
+	if err != nil {
+		return err
+	}
+	return table.Attach(leftID, rightIDs...)
+}
+
+func (m *ManyToMany[L, R]) Detach(left *L, rights ...*R) error {
+	leftID, err := entityKey(left)
+	if err != nil {
+		return err
+	}
+	rightIDs, err := keysOf(rights)
+	if err != nil {
+		return err
+	}
+
+	_, table, err := m.table()
+	if err != nil {
+		return err
+	}
+	return table.Detach(leftID, rightIDs...)
+}
+
+func (m *ManyToMany[L, R]) Sync(left *L, rights []*R) error {
+	leftID, err := entityKey(left)
+	if err != nil {
+		return err
+	}
+	rightIDs, err := keysOf(rights)
+	if err != nil {
+		return err
+	}
+
+	_, table, err := m.table()
+	if err != nil {
+		return err
+	}
+	return table.Sync(leftID, rightIDs)
+}
+
+func (m *ManyToMany[L, R]) DetachLeft(left *L) error {
+	leftID, err := entityKey(left)
+	if err != nil {
+		return err
+	}
+
+	_, table, err := m.table()
+	if err != nil {
+		return err
+	}
+	return table.DetachLeft(leftID)
+}
+
+func (m *ManyToMany[L, R]) DetachRight(right *R) error {
+	rightID, err := entityKey(right)
+	if err != nil {
+		return err
+	}
+
+	_, table, err := m.table()
+	if err != nil {
+		return err
+	}
+	return table.DetachRight(rightID)
+}
+
+func (m *ManyToMany[L, R]) Load(left *L) ([]*R, error) {
+	leftID, err := entityKey(left)
+	if err != nil {
+		return nil, err
+	}
+
+	db, table, err := m.table()
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := table.RightIDs(leftID)
+	if err != nil {
+		return nil, err
+	}
+	return loadAll[R](db, m.rightBucket, ids), nil
+}
+
+func (m *ManyToMany[L, R]) Owners(right *R) ([]*L, error) {
+	rightID, err := entityKey(right)
+	if err != nil {
+		return nil, err
+	}
+
+	db, table, err := m.table()
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := table.LeftIDs(rightID)
+	if err != nil {
+		return nil, err
+	}
+	return loadAll[L](db, m.leftBucket, ids), nil
+}
+
+func (m *ManyToMany[L, R]) Preload(lefts []*L) (map[string][]*R, error) {
+	db, table, err := m.table()
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := make(map[string]*R)
+	result := make(map[string][]*R, len(lefts))
+	for _, left := range lefts {
+		leftID, err := entityKey(left)
+		if err != nil {
+			return nil, err
+		}
+
+		ids, err := table.RightIDs(leftID)
+		if err != nil {
+			return nil, err
+		}
+
+		related := make([]*R, 0, len(ids))
+		for _, id := range ids {
+			entity, cached := loaded[id]
+			if !cached {
+				entity = new(R)
+				if err := db.Get(m.rightBucket, id, entity); err != nil {
+					entity = nil
+				} else {
+					markClean(entity)
+				}
+				loaded[id] = entity
+			}
+			if entity != nil {
+				related = append(related, entity)
+			}
+		}
+		result[leftID] = related
+	}
+	return result, nil
+}
+
+func loadAll[T any](db *database.DB, bucketName string, ids []string) []*T {
+	entities := make([]*T, 0, len(ids))
+	for _, id := range ids {
+		entity := new(T)
+		if err := db.Get(bucketName, id, entity); err == nil {
+			markClean(entity)
+			entities = append(entities, entity)
+		}
+	}
+	return entities
+}
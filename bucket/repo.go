@@ -0,0 +1,258 @@
+package bucket
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	"github.com/andr1ww/odin/database"
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/reflection"
+)
+
+type Repo[T any] struct {
+	dbName     string
+	bucketName string
+}
+
+type Query[T any] struct {
+	repo     *Repo[T]
+	criteria []map[string]interface{}
+	orders   []order
+	limit    int
+	offset   int
+	err      error
+}
+
+type order struct {
+	field string
+	desc  bool
+}
+
+func RepoFor[T any]() *Repo[T] {
+	model := new(T)
+	bucketName, _ := reflection.GetBucketName(model)
+	dbName, _ := reflection.GetBucketDatabase(model)
+	return &Repo[T]{dbName: dbName, bucketName: bucketName}
+}
+
+func (r *Repo[T]) InDatabase(dbName string) *Repo[T] {
+	return &Repo[T]{dbName: dbName, bucketName: r.bucketName}
+}
+
+func (r *Repo[T]) BucketName() string {
+	return r.bucketName
+}
+
+func (r *Repo[T]) Query() *Query[T] {
+	return &Query[T]{repo: r}
+}
+
+func (r *Repo[T]) Where(criteria map[string]interface{}) *Query[T] {
+	return r.Query().Where(criteria)
+}
+
+func (r *Repo[T]) Find(ctx context.Context, id string) (*T, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	entity := new(T)
+	if err := FindInDatabase(r.dbName, r.bucketName, id, entity); err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+func (r *Repo[T]) Has(ctx context.Context, id string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	db, err := database.GetNamed(r.dbName)
+	if err != nil {
+		return false, err
+	}
+	return db.Has(r.bucketName, id)
+}
+
+func (r *Repo[T]) Create(ctx context.Context, entity *T) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return CreateInDatabase(r.dbName, entity)
+}
+
+func (q *Query[T]) clone() *Query[T] {
+	c := *q
+	c.criteria = append([]map[string]interface{}(nil), q.criteria...)
+	c.orders = append([]order(nil), q.orders...)
+	return &c
+}
+
+func (q *Query[T]) Where(criteria map[string]interface{}) *Query[T] {
+	c := q.clone()
+	if len(criteria) > 0 {
+		c.criteria = append(c.criteria, criteria)
+	}
+	return c
+}
+
+func (q *Query[T]) OrderBy(field string) *Query[T] {
+	c := q.clone()
+	c.orders = append(c.orders, order{field: field})
+	return c
+}
+
+func (q *Query[T]) OrderByDesc(field string) *Query[T] {
+	c := q.clone()
+	c.orders = append(c.orders, order{field: field, desc: true})
+	return c
+}
+
+func (q *Query[T]) Limit(limit int) *Query[T] {
+	c := q.clone()
+	c.limit = limit
+	return c
+}
+
+func (q *Query[T]) Offset(offset int) *Query[T] {
+	c := q.clone()
+	c.offset = offset
+	return c
+}
+
+func (q *Query[T]) filter() map[string]interface{} {
+	switch len(q.criteria) {
+	case 0:
+		return nil
+	case 1:
+		return q.criteria[0]
+	}
+	return reflection.And(q.criteria...)
+}
+
+func (q *Query[T]) matches(ctx context.Context) ([]*T, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	found, err := FindWhereInDatabase(q.repo.dbName, q.repo.bucketName, q.filter(), func() interface{} {
+		return new(T)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make([]*T, 0, len(found))
+	for _, item := range found {
+		if entity, ok := item.(*T); ok {
+			results = append(results, entity)
+		}
+	}
+	return results, nil
+}
+
+func (q *Query[T]) All(ctx context.Context) ([]*T, error) {
+	results, err := q.matches(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q.sort(results)
+	return q.page(results), nil
+}
+
+func (q *Query[T]) One(ctx context.Context) (*T, error) {
+	results, err := q.Limit(1).All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, errors.ErrNotFound
+	}
+	return results[0], nil
+}
+
+func (q *Query[T]) Count(ctx context.Context) (int, error) {
+	results, err := q.matches(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return len(results), nil
+}
+
+func (q *Query[T]) Delete(ctx context.Context) (int, error) {
+	results, err := q.All(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	db, err := database.GetNamed(q.repo.dbName)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, entity := range results {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+
+		id, err := entityKey(entity)
+		if err != nil {
+			return deleted, err
+		}
+
+		trackIndexed(q.repo.bucketName, entity)
+		if err := db.Delete(q.repo.bucketName, id); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+func (q *Query[T]) sort(results []*T) {
+	if len(q.orders) == 0 || len(results) < 2 {
+		return
+	}
+
+	matcher := reflection.GetFieldMatcher(reflect.TypeOf((*T)(nil)).Elem())
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := reflect.ValueOf(results[i]).Elem(), reflect.ValueOf(results[j]).Elem()
+		for _, o := range q.orders {
+			av, _ := matcher.GetFieldValue(a, o.field)
+			bv, _ := matcher.GetFieldValue(b, o.field)
+
+			cmp, ok := reflection.CompareValues(av, bv)
+			if !ok || cmp == 0 {
+				continue
+			}
+			if o.desc {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+}
+
+func (q *Query[T]) page(results []*T) []*T {
+	if q.offset > 0 {
+		if q.offset >= len(results) {
+			return results[:0]
+		}
+		results = results[q.offset:]
+	}
+	if q.limit > 0 && q.limit < len(results) {
+		results = results[:q.limit]
+	}
+	return results
+}
@@ -0,0 +1,74 @@
+package bucket
+
+import (
+	"context"
+
+	"github.com/andr1ww/odin/database"
+	"github.com/andr1ww/odin/internal/indexing"
+	"github.com/andr1ww/odin/internal/logger"
+)
+
+func init() {
+	rebuild := func(name string, db *database.DB) {
+		if err := rebuildIndexes(db); err != nil {
+			logger.Warning("failed to rebuild indexes of database '%s': %v", name, err)
+		}
+	}
+	database.OnRestore(rebuild)
+	database.OnReopen(rebuild)
+}
+
+func RebuildIndexes(dbName string) error {
+	db, err := database.GetNamed(dbName)
+	if err != nil {
+		return err
+	}
+	return rebuildIndexes(db)
+}
+
+func rebuildIndexes(db *database.DB) error {
+	buckets, err := db.ListBuckets()
+	if err != nil {
+		return err
+	}
+
+	for _, bucketName := range buckets {
+		if indexing.DiskIndexed(bucketName) {
+			continue
+		}
+		indexing.DropIndex(bucketName)
+
+		constructor, registered := BucketModels[bucketName]
+		if !registered {
+			continue
+		}
+
+		indexed := 0
+		var after []byte
+		for {
+			batch, err := readBatch(db, bucketName, after)
+			if err != nil {
+				return err
+			}
+			if len(batch) == 0 {
+				break
+			}
+			after = []byte(batch[len(batch)-1].key)
+			if err := db.YieldToForeground(context.Background(), database.JobReindex); err != nil {
+				return err
+			}
+
+			for _, record := range batch {
+				entity := constructor()
+				if err := js.Unmarshal(record.data, entity); err != nil {
+					logger.Warning("failed to index '%s' key '%s': %v", bucketName, record.key, err)
+					continue
+				}
+				indexing.UpdateIndex(bucketName, record.key, entity)
+				indexed++
+			}
+		}
+		logger.Success("rebuilt index of '%s' (%d records)", bucketName, indexed)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package bucket
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/andr1ww/odin/database"
+	"github.com/andr1ww/odin/internal/reflection"
+)
+
+type Result struct {
+	Records  []interface{}
+	Keys     []string
+	Strategy string
+	Indexed  []string
+	Scanned  int
+	Matched  int
+	Skipped  int
+	Corrupt  int
+	Duration time.Duration
+}
+
+type scanStats struct {
+	strategy string
+	indexed  []string
+	scanned  atomic.Int64
+	skipped  atomic.Int64
+	corrupt  atomic.Int64
+}
+
+func (s *scanStats) skip(db *database.DB, bucketName, key string, cause error) error {
+	if skipErr := db.SkipRecord(bucketName, key, cause); skipErr != nil {
+		return skipErr
+	}
+
+	s.skipped.Add(1)
+	var decodeErr *database.DecodeError
+	if errors.As(cause, &decodeErr) && decodeErr.Corrupt {
+		s.corrupt.Add(1)
+	}
+	return nil
+}
+
+func FindWhereResult(bucketName string, criteria map[string]interface{}, constructor func() interface{}) (*Result, error) {
+	dbName, err := reflection.GetBucketDatabase(constructor())
+	if err != nil {
+		return nil, err
+	}
+	return FindWhereResultInDatabase(dbName, bucketName, criteria, constructor)
+}
+
+func FindAllResult(bucketName string, constructor func() interface{}) (*Result, error) {
+	dbName, err := reflection.GetBucketDatabase(constructor())
+	if err != nil {
+		return nil, err
+	}
+	return FindAllResultInDatabase(dbName, bucketName, constructor)
+}
+
+func FindWhereResultInDatabase(dbName, bucketName string, criteria map[string]interface{}, constructor func() interface{}) (*Result, error) {
+	db, err := database.GetNamed(dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	stats := &scanStats{}
+	var keyed []Keyed
+	err = db.Intercept(database.OpInfo{Op: database.OpFindWhere, Bucket: bucketName, Criteria: criteria}, func() error {
+		var findErr error
+		keyed, findErr = findWhereKeyed(db, bucketName, criteria, constructor, stats)
+		return findErr
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Records:  entitiesOf(keyed),
+		Keys:     make([]string, len(keyed)),
+		Strategy: stats.strategy,
+		Indexed:  stats.indexed,
+		Scanned:  int(stats.scanned.Load()),
+		Matched:  len(keyed),
+		Skipped:  int(stats.skipped.Load()),
+		Corrupt:  int(stats.corrupt.Load()),
+		Duration: time.Since(started),
+	}
+	for i, item := range keyed {
+		result.Keys[i] = item.Key
+	}
+	return result, nil
+}
+
+func FindAllResultInDatabase(dbName, bucketName string, constructor func() interface{}) (*Result, error) {
+	db, err := database.GetNamed(dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	keys, entities, counts, err := db.GetAllCounted(bucketName, constructor)
+	if err != nil {
+		return nil, err
+	}
+	for _, entity := range entities {
+		markClean(entity)
+	}
+
+	return &Result{
+		Records:  entities,
+		Keys:     keys,
+		Strategy: PlanFullScan,
+		Scanned:  counts.Scanned,
+		Matched:  len(entities),
+		Skipped:  counts.Skipped,
+		Corrupt:  counts.Corrupt,
+		Duration: time.Since(started),
+	}, nil
+}
@@ -0,0 +1,44 @@
+package bucket
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/andr1ww/odin/internal/reflection"
+	"github.com/andr1ww/odin/internal/schema"
+)
+
+type Schema = schema.Schema
+
+func SchemaFor(model interface{}) (Schema, error) {
+	if model == nil {
+		return nil, fmt.Errorf("nil model provided")
+	}
+
+	typ := reflect.TypeOf(model)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected struct, got %s", typ.Kind())
+	}
+
+	bucketName, err := reflection.GetBucketName(model)
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.Generate(typ, bucketName), nil
+}
+
+func SchemaAll() (map[string]Schema, error) {
+	schemas := make(map[string]Schema, len(BucketModels))
+	for bucketName, constructor := range BucketModels {
+		s, err := SchemaFor(constructor())
+		if err != nil {
+			return nil, fmt.Errorf("schema for bucket '%s': %w", bucketName, err)
+		}
+		schemas[bucketName] = s
+	}
+	return schemas, nil
+}
@@ -0,0 +1,67 @@
+package bucket
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/reflection"
+)
+
+var (
+	scopeMutex   sync.RWMutex
+	bucketScopes = make(map[string]map[string]map[string]interface{})
+)
+
+func RegisterScope(model interface{}, name string, criteria map[string]interface{}) error {
+	bucketName, err := reflection.GetBucketName(model)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("scope name cannot be empty")
+	}
+
+	if _, err := reflection.CompileFilter(criteria); err != nil {
+		return fmt.Errorf("scope %s: %w", name, err)
+	}
+
+	scopeMutex.Lock()
+	defer scopeMutex.Unlock()
+
+	if _, exists := bucketScopes[bucketName]; !exists {
+		bucketScopes[bucketName] = make(map[string]map[string]interface{})
+	}
+	bucketScopes[bucketName][name] = criteria
+	return nil
+}
+
+func lookupScope(bucketName, name string) (map[string]interface{}, error) {
+	scopeMutex.RLock()
+	defer scopeMutex.RUnlock()
+
+	criteria, exists := bucketScopes[bucketName][name]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s on bucket %s", errors.ErrScopeNotFound, name, bucketName)
+	}
+	return criteria, nil
+}
+
+func (r *Repo[T]) Scope(names ...string) *Query[T] {
+	return r.Query().Scope(names...)
+}
+
+func (q *Query[T]) Scope(names ...string) *Query[T] {
+	c := q.clone()
+	for _, name := range names {
+		criteria, err := lookupScope(c.repo.bucketName, name)
+		if err != nil {
+			if c.err == nil {
+				c.err = err
+			}
+			continue
+		}
+		c.criteria = append(c.criteria, criteria)
+	}
+	return c
+}
@@ -0,0 +1,71 @@
+package bucket
+
+import (
+	"runtime"
+
+	"github.com/andr1ww/odin/database"
+	"github.com/andr1ww/odin/internal/indexing"
+	"github.com/andr1ww/odin/internal/reflection"
+	bolt "go.etcd.io/bbolt"
+)
+
+const snapshotRetries = 3
+
+func findIndexed(db *database.DB, bucketName string, criteria map[string]interface{}, constructor func() interface{}, matcher *reflection.FieldMatcher, stats *scanStats) ([]Keyed, int, bool, error) {
+	for attempt := 0; attempt < snapshotRetries; attempt++ {
+		epoch, settled := indexing.Epoch(bucketName)
+		if !settled {
+			runtime.Gosched()
+			continue
+		}
+
+		stats.indexed = stats.indexed[:0]
+		keys, ok := planKeys(bucketName, criteria, &stats.indexed)
+		if !ok {
+			return nil, 0, false, nil
+		}
+
+		results, err := loadSnapshot(db, bucketName, keys, criteria, constructor, matcher, stats)
+		if err != nil {
+			return nil, len(keys), true, err
+		}
+
+		if current, settled := indexing.Epoch(bucketName); settled && current == epoch {
+			return results, len(keys), true, nil
+		}
+	}
+	return nil, 0, false, nil
+}
+
+func loadSnapshot(db *database.DB, bucketName string, keys []string, criteria map[string]interface{}, constructor func() interface{}, matcher *reflection.FieldMatcher, stats *scanStats) ([]Keyed, error) {
+	results := make([]Keyed, 0, len(keys))
+
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return nil
+		}
+
+		for _, key := range keys {
+			data := b.Get([]byte(key))
+			if len(data) == 0 {
+				continue
+			}
+
+			entity := constructor()
+			if err := db.Decode(bucketName, key, data, entity); err != nil {
+				if skipErr := stats.skip(db, bucketName, key, err); skipErr != nil {
+					return skipErr
+				}
+				continue
+			}
+			if reflection.MatchesCriteria(entity, criteria, matcher) {
+				reflection.SetRecordKey(entity, key)
+				markClean(entity)
+				results = append(results, Keyed{Key: key, Entity: entity})
+			}
+		}
+		return nil
+	})
+	return results, err
+}
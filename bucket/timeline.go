@@ -0,0 +1,113 @@
+package bucket
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/andr1ww/odin/database"
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/reflection"
+	bolt "go.etcd.io/bbolt"
+)
+
+func FindRecent(bucketName string, since time.Time, limit int, constructor func() interface{}) ([]interface{}, error) {
+	dbName, err := reflection.GetBucketDatabase(constructor())
+	if err != nil {
+		return nil, err
+	}
+	return FindRecentInDatabase(dbName, bucketName, since, limit, constructor)
+}
+
+func FindRecentInDatabase(dbName, bucketName string, since time.Time, limit int, constructor func() interface{}) ([]interface{}, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive, got %d", limit)
+	}
+
+	db, err := database.GetNamed(dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := db.CreatedBetween(bucketName, since, time.Time{}, limit, true)
+	if err != nil {
+		return nil, err
+	}
+	return loadKeys(db, bucketName, ids, constructor)
+}
+
+func FindBetween(bucketName string, from, to time.Time, constructor func() interface{}) ([]interface{}, error) {
+	dbName, err := reflection.GetBucketDatabase(constructor())
+	if err != nil {
+		return nil, err
+	}
+	return FindBetweenInDatabase(dbName, bucketName, from, to, constructor)
+}
+
+func FindBetweenInDatabase(dbName, bucketName string, from, to time.Time, constructor func() interface{}) ([]interface{}, error) {
+	if !to.IsZero() && to.Before(from) {
+		return nil, fmt.Errorf("range end %s is before start %s", to, from)
+	}
+
+	db, err := database.GetNamed(dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := db.CreatedBetween(bucketName, from, to, 0, false)
+	if err != nil {
+		return nil, err
+	}
+	return loadKeys(db, bucketName, ids, constructor)
+}
+
+// planTimeline answers a CreatedAt range criterion from the timeline instead
+// of a full scan. The candidates still go through every criterion.
+func planTimeline(db *database.DB, bucketName string, criteria map[string]interface{}) ([]string, string, bool, error) {
+	for _, field := range []string{"created_at", "CreatedAt"} {
+		lookup, ok := criteria[field].(reflection.TimeRangeLookup)
+		if !ok {
+			continue
+		}
+
+		from, to := lookup.TimeRange()
+		keys, covered, err := db.CreatedCovering(bucketName, from, to)
+		if err != nil || !covered {
+			return nil, "", false, err
+		}
+		return keys, field, true, nil
+	}
+	return nil, "", false, nil
+}
+
+func loadKeys(db *database.DB, bucketName string, ids []string, constructor func() interface{}) ([]interface{}, error) {
+	results := make([]interface{}, 0, len(ids))
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrBucketMissing
+		}
+
+		for _, id := range ids {
+			data := b.Get([]byte(id))
+			if len(data) == 0 {
+				continue
+			}
+
+			entity := constructor()
+			if err := db.Decode(bucketName, id, data, entity); err != nil {
+				if skipErr := db.SkipRecord(bucketName, id, err); skipErr != nil {
+					return skipErr
+				}
+				continue
+			}
+			reflection.SetRecordKey(entity, id)
+			markClean(entity)
+			results = append(results, entity)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
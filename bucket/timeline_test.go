@@ -0,0 +1,65 @@
+package bucket
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andr1ww/odin/database"
+	"github.com/andr1ww/odin/internal/logger"
+	"github.com/andr1ww/odin/internal/reflection"
+)
+
+type timelineOrder struct {
+	Bucket `bucket:"orders" database:"bucket-timeline"`
+	Item   string `json:"item"`
+}
+
+func TestCreatedAtRangeUsesTimeline(t *testing.T) {
+	logger.DisableLogging()
+	name := "bucket-timeline"
+
+	if err := database.Connect(name, filepath.Join(t.TempDir(), "main.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close(name)
+
+	db, err := database.GetNamed(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateBucket("orders"); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	for id, age := range map[string]int{"old": 30, "recent": 3, "today": 0} {
+		order := &timelineOrder{Item: id}
+		order.ID, order.CreatedAt = id, now.AddDate(0, 0, -age)
+		if err := order.Save(order); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	criteria := map[string]interface{}{"created_at": reflection.Since(now.AddDate(0, 0, -7))}
+	newOrder := func() interface{} { return &timelineOrder{} }
+
+	result, err := FindWhereResultInDatabase(name, "orders", criteria, newOrder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Strategy != PlanTimeline || result.Scanned != 2 || result.Matched != 2 {
+		t.Fatalf("expected a timeline plan reading 2 records, got %s scanning %d matching %d", result.Strategy, result.Scanned, result.Matched)
+	}
+
+	if err := db.Put("orders", "raw", map[string]interface{}{"id": "raw", "item": "raw", "created_at": now}); err != nil {
+		t.Fatal(err)
+	}
+	result, err = FindWhereResultInDatabase(name, "orders", criteria, newOrder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Strategy != PlanFullScan || result.Matched != 3 {
+		t.Fatalf("expected a full scan once a record is off the timeline, got %s matching %d", result.Strategy, result.Matched)
+	}
+}
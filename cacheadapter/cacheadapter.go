@@ -0,0 +1,108 @@
+package cacheadapter
+
+import (
+	err "errors"
+	"time"
+
+	"github.com/andr1ww/odin/database"
+	"github.com/andr1ww/odin/errors"
+	jsoniter "github.com/json-iterator/go"
+)
+
+var js = jsoniter.ConfigCompatibleWithStandardLibrary
+
+const NoExpiration time.Duration = 0
+
+type Store interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(key string) error
+}
+
+type entry struct {
+	Value   []byte    `json:"v"`
+	Expires time.Time `json:"e,omitempty"`
+}
+
+type Cache struct {
+	db     *database.DB
+	bucket string
+}
+
+func New(db *database.DB, bucketName string) (*Cache, error) {
+	if db == nil {
+		return nil, errors.ErrNilValue
+	}
+	if bucketName == "" {
+		return nil, err.New("cache bucket name cannot be empty")
+	}
+	if err := db.CreateBucket(bucketName); err != nil {
+		return nil, err
+	}
+	return &Cache{db: db, bucket: bucketName}, nil
+}
+
+func (c *Cache) Get(key string) ([]byte, bool) {
+	value, _, found := c.GetWithTTL(key)
+	return value, found
+}
+
+func (c *Cache) GetWithTTL(key string) ([]byte, time.Duration, bool) {
+	var e entry
+	if err := c.db.Get(c.bucket, key, &e); err != nil {
+		return nil, 0, false
+	}
+	if e.Expires.IsZero() {
+		return e.Value, NoExpiration, true
+	}
+
+	remaining := time.Until(e.Expires)
+	if remaining <= 0 {
+		return nil, 0, false
+	}
+	return e.Value, remaining, true
+}
+
+func (c *Cache) Set(key string, value []byte, ttl time.Duration) error {
+	e := entry{Value: value}
+	if ttl > 0 {
+		e.Expires = time.Now().Add(ttl)
+	}
+
+	if err := c.db.Put(c.bucket, key, e); err != nil {
+		return err
+	}
+	if e.Expires.IsZero() {
+		return c.db.ClearExpiry(c.bucket, key)
+	}
+	return c.db.SetExpiry(c.bucket, key, e.Expires)
+}
+
+func (c *Cache) Delete(key string) error {
+	return c.db.Delete(c.bucket, key)
+}
+
+func (c *Cache) GetValue(key string, target interface{}) (bool, error) {
+	data, found := c.Get(key)
+	if !found {
+		return false, nil
+	}
+	return true, js.Unmarshal(data, target)
+}
+
+func (c *Cache) SetValue(key string, value interface{}, ttl time.Duration) error {
+	data, err := js.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.Set(key, data, ttl)
+}
+
+func (c *Cache) ItemCount() int {
+	count, _ := c.db.Count(c.bucket)
+	return count
+}
+
+func (c *Cache) Flush() error {
+	return c.db.Clear(c.bucket)
+}
@@ -0,0 +1,61 @@
+package cacheadapter
+
+import (
+	"time"
+
+	"github.com/andr1ww/odin/database"
+)
+
+type Tiered struct {
+	L1 Store
+	L2 *Cache
+
+	release func()
+}
+
+func NewTiered(l1 Store, l2 *Cache) *Tiered {
+	t := &Tiered{L1: l1, L2: l2}
+	t.release = l2.db.OnCommit(database.CommitHook{
+		Stage: database.StageCache,
+		Buckets: func(bucketName string) bool {
+			return bucketName == l2.bucket
+		},
+		Run: func(changes []database.Change) {
+			for _, change := range changes {
+				_ = l1.Delete(change.Key)
+			}
+		},
+	})
+	return t
+}
+
+func (t *Tiered) Close() {
+	t.release()
+}
+
+func (t *Tiered) Get(key string) ([]byte, bool) {
+	if value, found := t.L1.Get(key); found {
+		return value, true
+	}
+
+	value, ttl, found := t.L2.GetWithTTL(key)
+	if !found {
+		return nil, false
+	}
+	_ = t.L1.Set(key, value, ttl)
+	return value, true
+}
+
+func (t *Tiered) Set(key string, value []byte, ttl time.Duration) error {
+	if err := t.L2.Set(key, value, ttl); err != nil {
+		return err
+	}
+	return t.L1.Set(key, value, ttl)
+}
+
+func (t *Tiered) Delete(key string) error {
+	if err := t.L2.Delete(key); err != nil {
+		return err
+	}
+	return t.L1.Delete(key)
+}
@@ -0,0 +1,208 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/andr1ww/odin/database"
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/logger"
+	"github.com/hashicorp/raft"
+	raftboltdb "github.com/hashicorp/raft-boltdb/v2"
+)
+
+type Peer struct {
+	ID   string
+	Addr string
+}
+
+type Config struct {
+	NodeID        string
+	BindAddr      string
+	AdvertiseAddr string
+	DataDir       string
+	Database      string
+	Bootstrap     bool
+	Peers         []Peer
+	ApplyTimeout  time.Duration
+	LogOutput     io.Writer
+}
+
+type Node struct {
+	config Config
+	raft   *raft.Raft
+	store  *raftboltdb.BoltStore
+}
+
+func Start(config Config) (*Node, error) {
+	if config.NodeID == "" || config.BindAddr == "" || config.DataDir == "" {
+		return nil, fmt.Errorf("cluster node needs an ID, a bind address and a data directory")
+	}
+	db, err := database.GetNamed(config.Database)
+	if err != nil {
+		return nil, err
+	}
+	if config.AdvertiseAddr == "" {
+		config.AdvertiseAddr = config.BindAddr
+	}
+	if config.ApplyTimeout <= 0 {
+		config.ApplyTimeout = 10 * time.Second
+	}
+	if config.LogOutput == nil {
+		config.LogOutput = os.Stderr
+	}
+
+	if err := os.MkdirAll(config.DataDir, 0700); err != nil {
+		return nil, err
+	}
+
+	raftConfig := raft.DefaultConfig()
+	raftConfig.LocalID = raft.ServerID(config.NodeID)
+	raftConfig.LogOutput = config.LogOutput
+
+	store, err := raftboltdb.New(raftboltdb.Options{Path: filepath.Join(config.DataDir, "raft.db")})
+	if err != nil {
+		return nil, fmt.Errorf("open raft log: %w", err)
+	}
+
+	snapshots, err := raft.NewFileSnapshotStore(config.DataDir, 2, config.LogOutput)
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("open snapshot store: %w", err)
+	}
+
+	advertise, err := net.ResolveTCPAddr("tcp", config.AdvertiseAddr)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	transport, err := raft.NewTCPTransport(config.BindAddr, advertise, 3, 10*time.Second, config.LogOutput)
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("open raft transport: %w", err)
+	}
+
+	r, err := raft.NewRaft(raftConfig, &fsm{name: config.Database, dir: config.DataDir}, store, store, snapshots, transport)
+	if err != nil {
+		transport.Close()
+		store.Close()
+		return nil, err
+	}
+
+	if config.Bootstrap {
+		servers := []raft.Server{{ID: raftConfig.LocalID, Address: transport.LocalAddr()}}
+		for _, peer := range config.Peers {
+			if peer.ID == config.NodeID {
+				continue
+			}
+			servers = append(servers, raft.Server{ID: raft.ServerID(peer.ID), Address: raft.ServerAddress(peer.Addr)})
+		}
+		if err := r.BootstrapCluster(raft.Configuration{Servers: servers}).Error(); err != nil && err != raft.ErrCantBootstrap {
+			r.Shutdown()
+			store.Close()
+			return nil, err
+		}
+	}
+
+	db.SetReplica(true)
+	logger.Success("cluster node '%s' started for database '%s' at %s", config.NodeID, config.Database, config.AdvertiseAddr)
+	return &Node{config: config, raft: r, store: store}, nil
+}
+
+func (n *Node) IsLeader() bool {
+	return n.raft.State() == raft.Leader
+}
+
+func (n *Node) Leader() (id, addr string) {
+	leaderAddr, leaderID := n.raft.LeaderWithID()
+	return string(leaderID), string(leaderAddr)
+}
+
+func (n *Node) Put(bucketName, key string, value interface{}) error {
+	if value == nil {
+		return errors.ErrNilValue
+	}
+	data, err := js.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("error marshaling data: %w", err)
+	}
+	return n.Write(Op{Bucket: bucketName, Key: key, Value: data})
+}
+
+func (n *Node) Delete(bucketName, key string) error {
+	return n.Write(Op{Bucket: bucketName, Key: key, Delete: true})
+}
+
+func (n *Node) Write(ops ...Op) error {
+	if len(ops) == 0 {
+		return nil
+	}
+	for _, op := range ops {
+		if op.Key == "" {
+			return fmt.Errorf("key cannot be empty")
+		}
+		if !op.Delete && !json.Valid(op.Value) {
+			return fmt.Errorf("%w: value of %s/%s is not JSON", errors.ErrInvalidData, op.Bucket, op.Key)
+		}
+	}
+	if !n.IsLeader() {
+		_, addr := n.Leader()
+		return fmt.Errorf("%w: leader is %q", errors.ErrNotLeader, addr)
+	}
+
+	data, err := js.Marshal(command{Ops: ops})
+	if err != nil {
+		return err
+	}
+
+	future := n.raft.Apply(data, n.config.ApplyTimeout)
+	if err := future.Error(); err != nil {
+		if err == raft.ErrNotLeader || err == raft.ErrLeadershipLost {
+			return fmt.Errorf("%w: %v", errors.ErrNotLeader, err)
+		}
+		return err
+	}
+	if applyErr, ok := future.Response().(error); ok {
+		return applyErr
+	}
+	return nil
+}
+
+func (n *Node) Get(bucketName, key string, target interface{}) error {
+	db, err := database.GetNamed(n.config.Database)
+	if err != nil {
+		return err
+	}
+	return db.Get(bucketName, key, target)
+}
+
+func (n *Node) Barrier() error {
+	return n.raft.Barrier(n.config.ApplyTimeout).Error()
+}
+
+func (n *Node) AddVoter(id, addr string) error {
+	return n.raft.AddVoter(raft.ServerID(id), raft.ServerAddress(addr), 0, n.config.ApplyTimeout).Error()
+}
+
+func (n *Node) RemoveServer(id string) error {
+	return n.raft.RemoveServer(raft.ServerID(id), 0, n.config.ApplyTimeout).Error()
+}
+
+func (n *Node) Shutdown() error {
+	shutdownErr := n.raft.Shutdown().Error()
+	if db, err := database.GetNamed(n.config.Database); err == nil {
+		db.SetReplica(false)
+	}
+	if closeErr := n.store.Close(); shutdownErr == nil {
+		shutdownErr = closeErr
+	}
+	if shutdownErr == nil {
+		logger.Success("cluster node '%s' stopped", n.config.NodeID)
+	}
+	return shutdownErr
+}
@@ -0,0 +1,113 @@
+package cluster
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/andr1ww/odin/database"
+	"github.com/hashicorp/raft"
+	jsoniter "github.com/json-iterator/go"
+)
+
+var js = jsoniter.ConfigCompatibleWithStandardLibrary
+
+type Op struct {
+	Bucket string              `json:"b"`
+	Key    string              `json:"k"`
+	Value  jsoniter.RawMessage `json:"v,omitempty"`
+	Delete bool                `json:"d,omitempty"`
+}
+
+type command struct {
+	Ops []Op `json:"ops"`
+}
+
+type fsm struct {
+	name string
+	dir  string
+}
+
+func (f *fsm) db() (*database.DB, error) {
+	return database.GetNamed(f.name)
+}
+
+func (f *fsm) Apply(entry *raft.Log) interface{} {
+	var cmd command
+	if err := js.Unmarshal(entry.Data, &cmd); err != nil {
+		return fmt.Errorf("cluster: decode log entry %d: %w", entry.Index, err)
+	}
+
+	db, err := f.db()
+	if err != nil {
+		return fmt.Errorf("cluster: apply log entry %d: %w", entry.Index, err)
+	}
+
+	applyErr := db.ApplyReplicated(entry.AppendedAt, func(tx *database.Tx) error {
+		for _, op := range cmd.Ops {
+			var err error
+			if op.Delete {
+				err = tx.Delete(op.Bucket, op.Key)
+			} else {
+				err = tx.Put(op.Bucket, op.Key, op.Value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if applyErr != nil {
+		return fmt.Errorf("cluster: apply log entry %d: %w", entry.Index, applyErr)
+	}
+	return nil
+}
+
+func (f *fsm) Snapshot() (raft.FSMSnapshot, error) {
+	db, err := f.db()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.CreateTemp(f.dir, "fsm-snapshot-*.db")
+	if err != nil {
+		return nil, err
+	}
+	s := &snapshot{file: file}
+	if _, err := db.BackupTo(file); err != nil {
+		s.Release()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (f *fsm) Restore(snapshot io.ReadCloser) error {
+	defer snapshot.Close()
+
+	db, err := f.db()
+	if err != nil {
+		return err
+	}
+	return db.LoadReplicated(snapshot)
+}
+
+type snapshot struct {
+	file *os.File
+}
+
+func (s *snapshot) Persist(sink raft.SnapshotSink) error {
+	_, err := s.file.Seek(0, io.SeekStart)
+	if err == nil {
+		_, err = io.Copy(sink, s.file)
+	}
+	if err != nil {
+		sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+func (s *snapshot) Release() {
+	s.file.Close()
+	os.Remove(s.file.Name())
+}
@@ -0,0 +1,123 @@
+package cluster
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andr1ww/odin/database"
+	"github.com/andr1ww/odin/internal/logger"
+	"github.com/hashicorp/raft"
+)
+
+type memorySink struct {
+	bytes.Buffer
+	cancelled bool
+}
+
+func (s *memorySink) ID() string    { return "test" }
+func (s *memorySink) Cancel() error { s.cancelled = true; return nil }
+func (s *memorySink) Close() error  { return nil }
+
+func openReplica(t *testing.T, name string) (*database.DB, *fsm) {
+	t.Helper()
+	logger.DisableLogging()
+	dir := t.TempDir()
+
+	if err := database.Connect(name, filepath.Join(dir, "main.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close(name) })
+
+	db, err := database.GetNamed(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetReplica(true)
+	return db, &fsm{name: name, dir: dir}
+}
+
+func logEntry(t *testing.T, index uint64, ops ...Op) *raft.Log {
+	t.Helper()
+	data, err := js.Marshal(command{Ops: ops})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &raft.Log{Index: index, Data: data, AppendedAt: time.Unix(1700000000, 0)}
+}
+
+func TestApplyReturnsErrorsInsteadOfPanicking(t *testing.T) {
+	_, f := openReplica(t, "fsm-errors")
+
+	if resp := f.Apply(&raft.Log{Index: 1, Data: []byte("{not json")}); resp == nil {
+		t.Fatal("expected an error response for an undecodable entry")
+	} else if _, ok := resp.(error); !ok {
+		t.Fatalf("expected an error response, got %T", resp)
+	}
+
+	missing := &fsm{name: "fsm-missing"}
+	if _, ok := missing.Apply(logEntry(t, 2, Op{Bucket: "items", Key: "a", Value: []byte(`1`)})).(error); !ok {
+		t.Fatal("expected an error response when the database is missing")
+	}
+}
+
+func TestApplyIgnoresLocalSettings(t *testing.T) {
+	db, f := openReplica(t, "fsm-deterministic")
+
+	if resp := f.Apply(logEntry(t, 1, Op{Bucket: "items", Key: "a", Value: []byte(`{"n":1}`)})); resp != nil {
+		t.Fatal(resp)
+	}
+	db.EnableRecycleBin(time.Hour)
+	db.SetQuota("items", database.Quota{MaxKeys: 1})
+
+	if resp := f.Apply(logEntry(t, 2, Op{Bucket: "items", Key: "b", Value: []byte(`{"n":2}`)})); resp != nil {
+		t.Fatalf("local quota rejected a replicated write: %v", resp)
+	}
+	if resp := f.Apply(logEntry(t, 3, Op{Bucket: "items", Key: "a", Delete: true}, Op{Bucket: "gone", Key: "x", Delete: true})); resp != nil {
+		t.Fatal(resp)
+	}
+
+	if exists, _ := db.Has("items", "a"); exists {
+		t.Fatal("replicated delete was not applied")
+	}
+	if trashed, _ := db.ListTrash("items"); len(trashed) != 0 {
+		t.Fatalf("replicated delete went through the local recycle bin: %v", trashed)
+	}
+}
+
+func TestSnapshotIsTakenWhenRequested(t *testing.T) {
+	db, f := openReplica(t, "fsm-snapshot")
+
+	if resp := f.Apply(logEntry(t, 1, Op{Bucket: "items", Key: "a", Value: []byte(`{"n":1}`)})); resp != nil {
+		t.Fatal(resp)
+	}
+	snap, err := f.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snap.Release()
+
+	if resp := f.Apply(logEntry(t, 2, Op{Bucket: "items", Key: "b", Value: []byte(`{"n":2}`)})); resp != nil {
+		t.Fatal(resp)
+	}
+	if err := db.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+
+	sink := &memorySink{}
+	if err := snap.Persist(sink); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Restore(io.NopCloser(&sink.Buffer)); err != nil {
+		t.Fatal(err)
+	}
+
+	if exists, _ := db.Has("items", "a"); !exists {
+		t.Fatal("snapshot lost a record applied before it was taken")
+	}
+	if exists, _ := db.Has("items", "b"); exists {
+		t.Fatal("snapshot contains a record applied after it was taken")
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/andr1ww/odin/bench"
+	"github.com/andr1ww/odin/database"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("odinbench: ")
+
+	var config bench.Config
+	path := flag.String("path", "odinbench.db", "database file to benchmark against")
+	keep := flag.Bool("keep", false, "keep the database file after the run")
+	noSync := flag.Bool("nosync", false, "open the database with NoSync")
+	compress := flag.Bool("compression", true, "compress stored values")
+	level := flag.Int("level", database.DefaultCompression, "compression level")
+	threshold := flag.Int("threshold", 0, "minimum value size to compress; 0 keeps the default")
+	pooled := flag.Bool("pooled-decoding", false, "decode values through pooled buffers")
+	flag.StringVar(&config.Bucket, "bucket", "bench", "bucket to use")
+	flag.IntVar(&config.Keys, "keys", 10000, "number of distinct keys, preloaded before the run")
+	flag.IntVar(&config.ValueSize, "value-size", 256, "value size in bytes")
+	flag.Float64Var(&config.ReadRatio, "reads", 0.9, "fraction of operations that are reads")
+	flag.Float64Var(&config.ScanRatio, "scans", 0, "fraction of operations that read the whole bucket")
+	flag.BoolVar(&config.Repetitive, "repetitive", false, "use values that compress well")
+	flag.IntVar(&config.Concurrency, "concurrency", 4, "number of concurrent workers")
+	flag.DurationVar(&config.Duration, "duration", 0, "how long to run; defaults to 10s unless -ops is set")
+	flag.IntVar(&config.Operations, "ops", 0, "stop after this many operations")
+	flag.Int64Var(&config.Seed, "seed", 1, "random seed for keys and values")
+	flag.Parse()
+
+	opts := []database.Option{
+		database.WithNoSync(*noSync),
+		database.WithCompression(*compress),
+		database.WithCompressionLevel(*level),
+		database.WithPooledDecoding(*pooled),
+	}
+	if *threshold > 0 {
+		opts = append(opts, database.WithCompressionThreshold(*threshold))
+	}
+
+	if err := database.Connect("odinbench", *path, opts...); err != nil {
+		log.Fatal(err)
+	}
+	db, err := database.GetNamed("odinbench")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	result, runErr := bench.Run(ctx, db, config)
+	stop()
+
+	database.Close("odinbench")
+	if !*keep {
+		os.Remove(*path)
+	}
+	if runErr != nil {
+		log.Fatal(runErr)
+	}
+	fmt.Print(result)
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/andr1ww/odin/internal/reflection"
+)
+
+type field struct {
+	goName   string
+	jsonName string
+	expr     string
+	typ      string
+}
+
+type model struct {
+	name      string
+	fields    []field
+	keyExpr   string
+	keyTagged bool
+	hasBucket bool
+}
+
+var bucketFields = []field{
+	{goName: "ID", jsonName: "id", expr: "Bucket.ID", typ: "string"},
+	{goName: "CreatedAt", jsonName: "created_at", expr: "Bucket.CreatedAt"},
+	{goName: "UpdatedAt", jsonName: "updated_at", expr: "Bucket.UpdatedAt"},
+	{goName: "DeletedAt", jsonName: "deleted_at", expr: "Bucket.DeletedAt"},
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("odingen: ")
+
+	typeNames := flag.String("type", "", "comma-separated list of model type names; defaults to every struct embedding odin.Bucket")
+	output := flag.String("output", "", "output file name; defaults to <package>_odin.go")
+	dir := flag.String("dir", ".", "package directory to scan")
+	flag.Parse()
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, *dir, func(info os.FileInfo) bool {
+		name := info.Name()
+		return !strings.HasSuffix(name, "_test.go") && !strings.HasSuffix(name, "_odin.go")
+	}, parser.ParseComments)
+	if err != nil {
+		log.Fatalf("parsing %s: %v", *dir, err)
+	}
+	if len(pkgs) != 1 {
+		log.Fatalf("expected exactly one package in %s, found %d", *dir, len(pkgs))
+	}
+
+	var pkg *ast.Package
+	for _, p := range pkgs {
+		pkg = p
+	}
+
+	wanted := make(map[string]bool)
+	for _, name := range strings.Split(*typeNames, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			wanted[name] = true
+		}
+	}
+
+	models := collectModels(pkg, wanted)
+	if len(models) == 0 {
+		log.Fatalf("no models found in %s", *dir)
+	}
+	for name := range wanted {
+		found := false
+		for _, m := range models {
+			found = found || m.name == name
+		}
+		if !found {
+			log.Fatalf("type %s not found", name)
+		}
+	}
+
+	src, err := generate(pkg.Name, models)
+	if err != nil {
+		log.Fatalf("generating code: %v", err)
+	}
+
+	outName := *output
+	if outName == "" {
+		outName = filepath.Join(*dir, strings.ToLower(pkg.Name)+"_odin.go")
+	}
+	if err := os.WriteFile(outName, src, 0644); err != nil {
+		log.Fatalf("writing %s: %v", outName, err)
+	}
+}
+
+func collectModels(pkg *ast.Package, wanted map[string]bool) []model {
+	var models []model
+
+	fileNames := make([]string, 0, len(pkg.Files))
+	for name := range pkg.Files {
+		fileNames = append(fileNames, name)
+	}
+	sort.Strings(fileNames)
+
+	for _, fileName := range fileNames {
+		for _, decl := range pkg.Files[fileName].Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range gen.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				structType, ok := typeSpec.Type.(*ast.StructType)
+				if !ok || typeSpec.TypeParams != nil {
+					continue
+				}
+
+				m := buildModel(typeSpec.Name.Name, structType)
+				if len(wanted) > 0 {
+					if wanted[m.name] {
+						models = append(models, m)
+					}
+				} else if m.hasBucket {
+					models = append(models, m)
+				}
+			}
+		}
+	}
+	return models
+}
+
+func buildModel(name string, structType *ast.StructType) model {
+	m := model{name: name}
+
+	for _, f := range structType.Fields.List {
+		tag := reflect.StructTag("")
+		if f.Tag != nil {
+			if unquoted, err := strconv.Unquote(f.Tag.Value); err == nil {
+				tag = reflect.StructTag(unquoted)
+			}
+		}
+
+		if len(f.Names) == 0 {
+			if typeName(f.Type) == "Bucket" {
+				m.hasBucket = true
+				m.fields = append(m.fields, bucketFields...)
+				m.keyExpr = "e.Bucket.ID"
+			}
+			continue
+		}
+
+		for _, ident := range f.Names {
+			if !ident.IsExported() {
+				continue
+			}
+
+			if reflection.IsKeyTag(tag) && !m.hasBucket {
+				m.keyTagged = true
+				m.keyExpr = ""
+				if exprString(f.Type) == "string" {
+					m.keyExpr = "e." + ident.Name
+				}
+			}
+
+			jsonName := ident.Name
+			if jsonTag := tag.Get("json"); jsonTag != "" {
+				if jsonTag == "-" {
+					continue
+				}
+				if n := strings.Split(jsonTag, ",")[0]; n != "" {
+					jsonName = n
+				}
+			}
+
+			fieldType := exprString(f.Type)
+			m.fields = append(m.fields, field{goName: ident.Name, jsonName: jsonName, expr: ident.Name, typ: fieldType})
+
+			if m.keyExpr == "" && !m.keyTagged && fieldType == "string" && ident.Name == "ID" {
+				m.keyExpr = "e." + ident.Name
+			}
+		}
+	}
+
+	if m.keyExpr == "" && !m.keyTagged {
+		for _, f := range m.fields {
+			if f.typ == "string" && strings.HasSuffix(f.goName, "ID") {
+				m.keyExpr = "e." + f.expr
+				break
+			}
+		}
+	}
+
+	return m
+}
+
+func typeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.StarExpr:
+		return typeName(t.X)
+	}
+	return ""
+}
+
+func exprString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	format.Node(&buf, token.NewFileSet(), expr)
+	return buf.String()
+}
+
+func generate(pkgName string, models []model) ([]byte, error) {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "// Code generated by odingen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n", pkgName)
+
+	for _, m := range models {
+		names := make([]string, 0, len(m.fields))
+		for _, f := range m.fields {
+			names = append(names, strconv.Quote(f.jsonName))
+		}
+
+		fmt.Fprintf(&buf, "\nvar odinFieldNames%s = []string{%s}\n", m.name, strings.Join(names, ", "))
+
+		fmt.Fprintf(&buf, "\nfunc (e *%s) OdinFieldNames() []string {\n", m.name)
+		fmt.Fprintf(&buf, "\treturn odinFieldNames%s\n}\n", m.name)
+
+		fmt.Fprintf(&buf, "\nfunc (e *%s) OdinField(name string) (interface{}, bool) {\n", m.name)
+		fmt.Fprintf(&buf, "\tswitch name {\n")
+		used := make(map[string]bool)
+		for _, f := range m.fields {
+			var labels []string
+			for _, label := range []string{f.jsonName, f.goName} {
+				if !used[label] {
+					used[label] = true
+					labels = append(labels, strconv.Quote(label))
+				}
+			}
+			if len(labels) > 0 {
+				fmt.Fprintf(&buf, "\tcase %s:\n\t\treturn e.%s, true\n", strings.Join(labels, ", "), f.expr)
+			}
+		}
+		fmt.Fprintf(&buf, "\t}\n\treturn nil, false\n}\n")
+
+		if m.keyExpr != "" && !m.hasBucket {
+			fmt.Fprintf(&buf, "\nfunc (e *%s) OdinKey() string {\n\treturn %s\n}\n", m.name, m.keyExpr)
+		}
+	}
+
+	return format.Source(buf.Bytes())
+}
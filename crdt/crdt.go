@@ -0,0 +1,174 @@
+package crdt
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type GCounter map[string]uint64
+
+func (c GCounter) Inc(replica string, n uint64) GCounter {
+	if c == nil {
+		c = make(GCounter)
+	}
+	c[replica] += n
+	return c
+}
+
+func (c GCounter) Value() uint64 {
+	var total uint64
+	for _, n := range c {
+		total += n
+	}
+	return total
+}
+
+func (c GCounter) Merge(other GCounter) GCounter {
+	merged := make(GCounter, max(len(c), len(other)))
+	for replica, n := range c {
+		merged[replica] = n
+	}
+	for replica, n := range other {
+		merged[replica] = max(merged[replica], n)
+	}
+	return merged
+}
+
+type LWWRegister[T any] struct {
+	Value   T         `json:"value"`
+	At      time.Time `json:"at"`
+	Replica string    `json:"replica"`
+}
+
+func (r LWWRegister[T]) Set(value T, replica string) LWWRegister[T] {
+	return LWWRegister[T]{Value: value, At: time.Now(), Replica: replica}
+}
+
+func (r LWWRegister[T]) Merge(other LWWRegister[T]) LWWRegister[T] {
+	switch {
+	case other.At.After(r.At):
+		return other
+	case r.At.After(other.At):
+		return r
+	case other.Replica > r.Replica:
+		return other
+	}
+	return r
+}
+
+type ORSet struct {
+	Adds    map[string][]string `json:"adds,omitempty"`
+	Removed map[string]bool     `json:"removed,omitempty"`
+}
+
+func (s ORSet) Add(element, replica string) ORSet {
+	next := s.clone()
+	tag := replica + ":" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	next.Adds[element] = append(next.Adds[element], tag)
+	return next
+}
+
+func (s ORSet) Remove(element string) ORSet {
+	next := s.clone()
+	for _, tag := range next.Adds[element] {
+		next.Removed[tag] = true
+	}
+	return next
+}
+
+func (s ORSet) Contains(element string) bool {
+	for _, tag := range s.Adds[element] {
+		if !s.Removed[tag] {
+			return true
+		}
+	}
+	return false
+}
+
+func (s ORSet) Values() []string {
+	var values []string
+	for element := range s.Adds {
+		if s.Contains(element) {
+			values = append(values, element)
+		}
+	}
+	sort.Strings(values)
+	return values
+}
+
+func (s ORSet) Merge(other ORSet) ORSet {
+	merged := s.clone()
+	for element, tags := range other.Adds {
+		for _, tag := range tags {
+			if !containsTag(merged.Adds[element], tag) {
+				merged.Adds[element] = append(merged.Adds[element], tag)
+			}
+		}
+		sort.Strings(merged.Adds[element])
+	}
+	for tag := range other.Removed {
+		merged.Removed[tag] = true
+	}
+	return merged
+}
+
+func (s ORSet) clone() ORSet {
+	next := ORSet{Adds: make(map[string][]string, len(s.Adds)), Removed: make(map[string]bool, len(s.Removed))}
+	for element, tags := range s.Adds {
+		next.Adds[element] = append([]string(nil), tags...)
+	}
+	for tag := range s.Removed {
+		next.Removed[tag] = true
+	}
+	return next
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func Merge(dst, other interface{}) error {
+	dv, ov := reflect.ValueOf(dst), reflect.ValueOf(other)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() || dv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("merge destination must be a pointer to a struct, got %T", dst)
+	}
+	for ov.Kind() == reflect.Ptr {
+		ov = ov.Elem()
+	}
+	if ov.Type() != dv.Elem().Type() {
+		return fmt.Errorf("cannot merge %s into %s", ov.Type(), dv.Elem().Type())
+	}
+
+	mergeFields(dv.Elem(), ov)
+	return nil
+}
+
+func mergeFields(dst, other reflect.Value) {
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		target := dst.Field(i)
+		if merge := target.MethodByName("Merge"); merge.IsValid() && isMergeFunc(merge.Type(), target.Type()) {
+			target.Set(merge.Call([]reflect.Value{other.Field(i)})[0])
+			continue
+		}
+		if field.Anonymous && target.Kind() == reflect.Struct {
+			mergeFields(target, other.Field(i))
+		}
+	}
+}
+
+func isMergeFunc(fn, typ reflect.Type) bool {
+	return fn.NumIn() == 1 && fn.In(0) == typ && fn.NumOut() == 1 && fn.Out(0) == typ
+}
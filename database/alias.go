@@ -0,0 +1,71 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/logger"
+)
+
+func Alias(alias, target string) error {
+	if alias == "" || target == "" {
+		return fmt.Errorf("alias and target cannot be empty")
+	}
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	if _, exists := manager.databases[alias]; exists {
+		return errors.ErrDatabaseExists
+	}
+	if _, declared := manager.declarations[alias]; declared {
+		return errors.ErrDatabaseExists
+	}
+	for name := target; name != ""; name = manager.aliases[name] {
+		if name == alias {
+			return errors.ErrAliasCycle
+		}
+	}
+
+	manager.aliases[alias] = target
+	logger.Success("database alias '%s' now points at '%s'", alias, target)
+	return nil
+}
+
+func Unalias(alias string) error {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	if _, exists := manager.aliases[alias]; !exists {
+		return errors.ErrDatabaseNotFound
+	}
+	delete(manager.aliases, alias)
+	return nil
+}
+
+func Aliases() map[string]string {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	aliases := make(map[string]string, len(manager.aliases))
+	for alias, target := range manager.aliases {
+		aliases[alias] = target
+	}
+	return aliases
+}
+
+func Resolve(name string) string {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+	return resolveAlias(name)
+}
+
+func resolveAlias(name string) string {
+	for {
+		target, aliased := manager.aliases[name]
+		if !aliased {
+			return name
+		}
+		name = target
+	}
+}
@@ -0,0 +1,30 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/andr1ww/odin/internal/logger"
+)
+
+func TestCloseResolvesAliases(t *testing.T) {
+	logger.DisableLogging()
+
+	if err := Connect("alias-target", filepath.Join(t.TempDir(), "main.db")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Alias("alias-name", "alias-target"); err != nil {
+		Close("alias-target")
+		t.Fatal(err)
+	}
+	defer Unalias("alias-name")
+
+	if err := Close("alias-name"); err != nil {
+		Close("alias-target")
+		t.Fatalf("closing through an alias failed: %v", err)
+	}
+	if _, open := GetAll()["alias-target"]; open {
+		Close("alias-target")
+		t.Fatal("closing through an alias left the target open")
+	}
+}
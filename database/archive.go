@@ -0,0 +1,298 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/klauspost/compress/zstd"
+	bolt "go.etcd.io/bbolt"
+)
+
+type ArchiveCompression string
+
+const (
+	ArchiveNone ArchiveCompression = ""
+	ArchiveGzip ArchiveCompression = "gzip"
+	ArchiveZstd ArchiveCompression = "zstd"
+)
+
+type ArchiveOptions struct {
+	Compression ArchiveCompression
+	Key         []byte
+}
+
+const archiveChunkSize = 64 * 1024
+
+var (
+	archiveMagic = []byte("ODINENC1")
+	gzipMagic    = []byte{0x1f, 0x8b}
+	zstdMagic    = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+func ArchiveCompressionFor(filename string) ArchiveCompression {
+	switch filepath.Ext(strings.TrimSuffix(filename, ".enc")) {
+	case ".gz":
+		return ArchiveGzip
+	case ".zst":
+		return ArchiveZstd
+	}
+	return ArchiveNone
+}
+
+func (db *DB) BackupArchive(filename string, opts ArchiveOptions) error {
+	if opts.Compression == ArchiveNone {
+		opts.Compression = ArchiveCompressionFor(filename)
+	}
+
+	file, openErr := db.options.fs().OpenFile(filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if openErr != nil {
+		return openErr
+	}
+
+	backupErr := db.BackupArchiveTo(file, opts)
+	if backupErr == nil {
+		backupErr = file.Sync()
+	}
+	if closeErr := file.Close(); backupErr == nil {
+		backupErr = closeErr
+	}
+	return backupErr
+}
+
+func (db *DB) BackupArchiveTo(w io.Writer, opts ArchiveOptions) error {
+	var closers []io.Closer
+	if opts.Key != nil {
+		encrypted, encErr := newArchiveWriter(w, opts.Key)
+		if encErr != nil {
+			return encErr
+		}
+		w = encrypted
+		closers = append(closers, encrypted)
+	}
+
+	switch opts.Compression {
+	case ArchiveNone:
+	case ArchiveGzip:
+		compressed := gzip.NewWriter(w)
+		w = compressed
+		closers = append(closers, compressed)
+	case ArchiveZstd:
+		compressed, zstdErr := zstd.NewWriter(w)
+		if zstdErr != nil {
+			return zstdErr
+		}
+		w = compressed
+		closers = append(closers, compressed)
+	default:
+		return fmt.Errorf("unknown archive compression %q", opts.Compression)
+	}
+
+	backupErr := db.View(func(tx *bolt.Tx) error {
+		_, writeErr := tx.WriteTo(w)
+		return writeErr
+	})
+	for i := len(closers) - 1; i >= 0; i-- {
+		if closeErr := closers[i].Close(); backupErr == nil {
+			backupErr = closeErr
+		}
+	}
+	return backupErr
+}
+
+func (db *DB) RestoreArchive(filename string, key []byte) error {
+	file, openErr := db.options.fs().OpenFile(filename, os.O_RDONLY, 0)
+	if openErr != nil {
+		return openErr
+	}
+	defer file.Close()
+	return db.LoadArchive(file, key)
+}
+
+func (db *DB) LoadArchive(r io.Reader, key []byte) error {
+	reader, closeFn, openErr := openArchive(r, key)
+	if openErr != nil {
+		return openErr
+	}
+	defer closeFn()
+	return db.LoadBackup(reader)
+}
+
+func openArchive(r io.Reader, key []byte) (io.Reader, func(), error) {
+	buffered := bufio.NewReader(r)
+	header, _ := buffered.Peek(len(archiveMagic))
+
+	if bytes.HasPrefix(header, archiveMagic) {
+		if key == nil {
+			return nil, nil, errors.ErrArchiveKeyRequired
+		}
+		buffered.Discard(len(archiveMagic))
+		decrypted, decErr := newArchiveReader(buffered, key)
+		if decErr != nil {
+			return nil, nil, decErr
+		}
+		return openArchive(decrypted, nil)
+	}
+
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		decompressed, gzipErr := gzip.NewReader(buffered)
+		if gzipErr != nil {
+			return nil, nil, gzipErr
+		}
+		return decompressed, func() { decompressed.Close() }, nil
+	case bytes.HasPrefix(header, zstdMagic):
+		decompressed, zstdErr := zstd.NewReader(buffered)
+		if zstdErr != nil {
+			return nil, nil, zstdErr
+		}
+		return decompressed, decompressed.Close, nil
+	}
+	return buffered, func() {}, nil
+}
+
+func newArchiveCipher(key []byte) (cipher.AEAD, error) {
+	block, keyErr := aes.NewCipher(key)
+	if keyErr != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrInvalidArchiveKey, keyErr)
+	}
+	return cipher.NewGCM(block)
+}
+
+type archiveWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	nonce   []byte
+	counter uint32
+	buf     []byte
+	err     error
+}
+
+func newArchiveWriter(w io.Writer, key []byte) (*archiveWriter, error) {
+	aead, cipherErr := newArchiveCipher(key)
+	if cipherErr != nil {
+		return nil, cipherErr
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, randErr := rand.Read(nonce[:len(nonce)-4]); randErr != nil {
+		return nil, randErr
+	}
+	if _, writeErr := w.Write(archiveMagic); writeErr != nil {
+		return nil, writeErr
+	}
+	if _, writeErr := w.Write(nonce[:len(nonce)-4]); writeErr != nil {
+		return nil, writeErr
+	}
+	return &archiveWriter{w: w, aead: aead, nonce: nonce, buf: make([]byte, 0, archiveChunkSize)}, nil
+}
+
+func (a *archiveWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 && a.err == nil {
+		n := min(len(p), archiveChunkSize-len(a.buf))
+		a.buf = append(a.buf, p[:n]...)
+		p, written = p[n:], written+n
+		if len(a.buf) == archiveChunkSize {
+			a.err = a.flush(false)
+		}
+	}
+	return written, a.err
+}
+
+func (a *archiveWriter) Close() error {
+	if a.err != nil {
+		return a.err
+	}
+	a.err = a.flush(true)
+	return a.err
+}
+
+func (a *archiveWriter) flush(final bool) error {
+	header := make([]byte, 5)
+	if final {
+		header[0] = 1
+	}
+	binary.BigEndian.PutUint32(header[1:], uint32(len(a.buf)))
+	binary.BigEndian.PutUint32(a.nonce[len(a.nonce)-4:], a.counter)
+	a.counter++
+
+	sealed := a.aead.Seal(nil, a.nonce, a.buf, header)
+	a.buf = a.buf[:0]
+	if _, writeErr := a.w.Write(header); writeErr != nil {
+		return writeErr
+	}
+	_, writeErr := a.w.Write(sealed)
+	return writeErr
+}
+
+type archiveReader struct {
+	r       io.Reader
+	aead    cipher.AEAD
+	nonce   []byte
+	counter uint32
+	buf     []byte
+	done    bool
+}
+
+func newArchiveReader(r io.Reader, key []byte) (*archiveReader, error) {
+	aead, cipherErr := newArchiveCipher(key)
+	if cipherErr != nil {
+		return nil, cipherErr
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, readErr := io.ReadFull(r, nonce[:len(nonce)-4]); readErr != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrArchiveCorrupt, readErr)
+	}
+	return &archiveReader{r: r, aead: aead, nonce: nonce}, nil
+}
+
+func (a *archiveReader) Read(p []byte) (int, error) {
+	for len(a.buf) == 0 {
+		if a.done {
+			return 0, io.EOF
+		}
+		if readErr := a.next(); readErr != nil {
+			return 0, readErr
+		}
+	}
+	n := copy(p, a.buf)
+	a.buf = a.buf[n:]
+	return n, nil
+}
+
+func (a *archiveReader) next() error {
+	header := make([]byte, 5)
+	if _, readErr := io.ReadFull(a.r, header); readErr != nil {
+		return fmt.Errorf("%w: %v", errors.ErrArchiveCorrupt, readErr)
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > archiveChunkSize {
+		return errors.ErrArchiveCorrupt
+	}
+
+	sealed := make([]byte, int(size)+a.aead.Overhead())
+	if _, readErr := io.ReadFull(a.r, sealed); readErr != nil {
+		return fmt.Errorf("%w: %v", errors.ErrArchiveCorrupt, readErr)
+	}
+	binary.BigEndian.PutUint32(a.nonce[len(a.nonce)-4:], a.counter)
+	a.counter++
+
+	plain, openErr := a.aead.Open(sealed[:0], a.nonce, sealed, header)
+	if openErr != nil {
+		return errors.ErrInvalidArchiveKey
+	}
+	a.buf, a.done = plain, header[0] == 1
+	return nil
+}
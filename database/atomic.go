@@ -0,0 +1,176 @@
+package database
+
+import (
+	err "errors"
+	"fmt"
+	"time"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/compression"
+	bolt "go.etcd.io/bbolt"
+)
+
+type Savepoint int
+
+type Tx struct {
+	db         *DB
+	ops        []txOp
+	replicated bool
+	at         time.Time
+}
+
+type txOp struct {
+	bucket string
+	key    string
+	data   []byte
+}
+
+func (db *DB) Atomic(fn func(tx *Tx) error) error {
+	return db.Intercept(OpInfo{Op: OpAtomic}, func() error {
+		tx := &Tx{db: db}
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return tx.commit()
+	})
+}
+
+// ApplyReplicated commits fn the same way on every node of a cluster: local
+// quotas, caps and the recycle bin are ignored and at stamps the change log.
+func (db *DB) ApplyReplicated(at time.Time, fn func(tx *Tx) error) error {
+	tx := &Tx{db: db, replicated: true, at: at}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.commit()
+}
+
+func (tx *Tx) Put(bucketName, key string, value interface{}) error {
+	if key == "" {
+		return err.New("key cannot be empty")
+	}
+	if value == nil {
+		return errors.ErrNilValue
+	}
+
+	data, marshalErr := js.Marshal(value)
+	if marshalErr != nil {
+		return fmt.Errorf("error marshaling data: %w", marshalErr)
+	}
+	tx.ops = append(tx.ops, txOp{bucket: bucketName, key: key, data: data})
+	return nil
+}
+
+func (tx *Tx) Delete(bucketName, key string) error {
+	if key == "" {
+		return err.New("key cannot be empty")
+	}
+	tx.ops = append(tx.ops, txOp{bucket: bucketName, key: key})
+	return nil
+}
+
+func (tx *Tx) Get(bucketName, key string, target interface{}) error {
+	for i := len(tx.ops) - 1; i >= 0; i-- {
+		op := tx.ops[i]
+		if op.bucket != bucketName || op.key != key {
+			continue
+		}
+		if op.data == nil {
+			return errors.ErrNotFound
+		}
+		return js.Unmarshal(op.data, target)
+	}
+	return tx.db.Get(bucketName, key, target)
+}
+
+func (tx *Tx) Savepoint() Savepoint {
+	return Savepoint(len(tx.ops))
+}
+
+func (tx *Tx) RollbackTo(sp Savepoint) error {
+	if sp < 0 || int(sp) > len(tx.ops) {
+		return fmt.Errorf("savepoint %d is no longer valid", sp)
+	}
+	tx.ops = tx.ops[:sp]
+	return nil
+}
+
+func (tx *Tx) Pending() int {
+	return len(tx.ops)
+}
+
+func (tx *Tx) commit() error {
+	if len(tx.ops) == 0 {
+		return nil
+	}
+
+	db := tx.db
+	at := tx.at
+	if at.IsZero() {
+		at = time.Now()
+	}
+	update := db.limitedUpdate
+	if tx.replicated {
+		update = func(fn func(*bolt.Tx) error) error {
+			return db.commitUpdate(fn, nil)
+		}
+	}
+	updateErr := update(func(btx *bolt.Tx) error {
+		for _, op := range tx.ops {
+			b := btx.Bucket([]byte(op.bucket))
+			if b == nil && tx.replicated && op.data != nil {
+				var createErr error
+				if b, createErr = btx.CreateBucket([]byte(op.bucket)); createErr != nil {
+					return fmt.Errorf("create bucket '%s': %w", op.bucket, createErr)
+				}
+			}
+			if b == nil && tx.replicated {
+				continue
+			}
+			if b == nil {
+				return fmt.Errorf("bucket '%s': %w", op.bucket, errors.ErrBucketMissing)
+			}
+
+			observed := db.hasObservers(op.bucket)
+			if op.data == nil {
+				recycle := !tx.replicated && db.recycleBinEnabled() && !isTrashBucket(op.bucket)
+				stored, removeErr := db.removeKey(btx, b, op.bucket, op.key, recycle)
+				if removeErr != nil {
+					return removeErr
+				}
+				if observed && stored != nil {
+					if err := db.stageChange(btx, op.bucket, op.key, compression.DecompressData(stored), nil, ChangeDelete); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
+			existing := b.Get([]byte(op.key))
+			value := db.encode(op.bucket, op.data)
+			if err := db.account(btx, op.bucket, op.key, existing, value, !tx.replicated); err != nil {
+				return err
+			}
+			if err := bumpVersion(btx, op.bucket, op.key); err != nil {
+				return err
+			}
+			if err := logChange(btx, op.bucket, op.key, at); err != nil {
+				return err
+			}
+			if err := b.Put([]byte(op.key), value); err != nil {
+				return err
+			}
+			if observed {
+				var old []byte
+				if existing != nil {
+					old = compression.DecompressData(db.ColdValue(op.bucket, op.key, existing))
+				}
+				if err := db.stageChange(btx, op.bucket, op.key, old, op.data, changeTypeOf(old, op.data)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	return updateErr
+}
@@ -0,0 +1,248 @@
+package database
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/bloom"
+	"github.com/andr1ww/odin/internal/logger"
+	bolt "go.etcd.io/bbolt"
+)
+
+var bloomBucket = []byte("__bloom")
+
+type BloomStats struct {
+	Bits              uint64
+	Hashes            uint64
+	ExpectedKeys      int
+	FalsePositiveRate float64
+	Skipped           uint64
+	Stale             bool
+}
+
+type bloomState struct {
+	filter   *bloom.Filter
+	expected int
+	rate     float64
+	skipped  atomic.Uint64
+	stale    atomic.Bool
+}
+
+type bloomRecord struct {
+	Expected int     `json:"expected"`
+	Rate     float64 `json:"rate"`
+	TxID     int     `json:"txid"`
+	Filter   []byte  `json:"filter"`
+}
+
+func (db *DB) EnableBloomFilter(bucketName string, expectedKeys int, falsePositiveRate float64) error {
+	if expectedKeys < 1 {
+		return fmt.Errorf("expected key count must be positive")
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		return fmt.Errorf("false positive rate must be between 0 and 1")
+	}
+	return db.buildBloom(bucketName, expectedKeys, falsePositiveRate)
+}
+
+func (db *DB) DisableBloomFilter(bucketName string) error {
+	db.bloomMutex.Lock()
+	delete(db.blooms, bucketName)
+	db.bloomMutex.Unlock()
+
+	if db.rejectsWrites() {
+		return nil
+	}
+	return db.rawUpdate(func(tx *bolt.Tx) error {
+		return dropBloomRecord(tx, bucketName)
+	})
+}
+
+func (db *DB) RebuildBloomFilter(bucketName string) error {
+	db.bloomMutex.RLock()
+	state, exists := db.blooms[bucketName]
+	db.bloomMutex.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("no bloom filter enabled for bucket '%s'", bucketName)
+	}
+	return db.buildBloom(bucketName, state.expected, state.rate)
+}
+
+func (db *DB) BloomFilterStats(bucketName string) (BloomStats, error) {
+	db.bloomMutex.RLock()
+	defer db.bloomMutex.RUnlock()
+
+	state, exists := db.blooms[bucketName]
+	if !exists {
+		return BloomStats{}, fmt.Errorf("no bloom filter enabled for bucket '%s'", bucketName)
+	}
+	return BloomStats{
+		Bits:              state.filter.Bits(),
+		Hashes:            state.filter.Hashes(),
+		ExpectedKeys:      state.expected,
+		FalsePositiveRate: state.rate,
+		Skipped:           state.skipped.Load(),
+		Stale:             state.stale.Load(),
+	}, nil
+}
+
+func (db *DB) buildBloom(bucketName string, expected int, rate float64) error {
+	build := func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrBucketMissing
+		}
+
+		count := 0
+		b.ForEach(func(_, _ []byte) error {
+			count++
+			return nil
+		})
+
+		filter := bloom.New(max(expected, count), rate)
+		b.ForEach(func(k, _ []byte) error {
+			filter.Add(k)
+			return nil
+		})
+
+		state := &bloomState{filter: filter, expected: expected, rate: rate}
+		db.bloomMutex.Lock()
+		db.blooms[bucketName] = state
+		db.bloomMutex.Unlock()
+
+		if !tx.Writable() {
+			return nil
+		}
+		return saveBloomRecord(tx, bucketName, state)
+	}
+
+	if db.rejectsWrites() {
+		return db.View(build)
+	}
+	return db.rawUpdate(build)
+}
+
+func (db *DB) bloomAdd(bucketName, key string) {
+	db.bloomMutex.RLock()
+	state, exists := db.blooms[bucketName]
+	db.bloomMutex.RUnlock()
+
+	if exists {
+		state.filter.Add([]byte(key))
+	}
+}
+
+func (db *DB) bloomMiss(bucketName, key string) bool {
+	db.bloomMutex.RLock()
+	state, exists := db.blooms[bucketName]
+	db.bloomMutex.RUnlock()
+
+	if !exists || state.stale.Load() || state.filter.Test([]byte(key)) {
+		return false
+	}
+	state.skipped.Add(1)
+	return true
+}
+
+func (db *DB) invalidateBloomFilters() {
+	db.bloomMutex.RLock()
+	defer db.bloomMutex.RUnlock()
+
+	for _, state := range db.blooms {
+		state.stale.Store(true)
+	}
+}
+
+func (db *DB) rebuildBloomFilters() {
+	db.bloomMutex.RLock()
+	states := make(map[string]*bloomState, len(db.blooms))
+	for bucketName, state := range db.blooms {
+		states[bucketName] = state
+	}
+	db.bloomMutex.RUnlock()
+
+	for bucketName, state := range states {
+		if err := db.buildBloom(bucketName, state.expected, state.rate); err != nil {
+			logger.Warning("dropping bloom filter of bucket '%s' in database '%s': %v", bucketName, db.name, err)
+			db.bloomMutex.Lock()
+			delete(db.blooms, bucketName)
+			db.bloomMutex.Unlock()
+		}
+	}
+}
+
+func (db *DB) loadBloomFilters() {
+	stale := make(map[string]bloomRecord)
+	db.View(func(tx *bolt.Tx) error {
+		records := tx.Bucket(bloomBucket)
+		if records == nil {
+			return nil
+		}
+
+		return records.ForEach(func(k, v []byte) error {
+			var record bloomRecord
+			if err := js.Unmarshal(v, &record); err != nil {
+				logger.Warning("ignoring unreadable bloom filter of bucket '%s' in database '%s': %v", k, db.name, err)
+				return nil
+			}
+
+			if record.TxID == tx.ID() {
+				if filter, err := bloom.Unmarshal(record.Filter); err == nil {
+					db.blooms[string(k)] = &bloomState{filter: filter, expected: record.Expected, rate: record.Rate}
+					return nil
+				}
+			}
+			stale[string(k)] = record
+			return nil
+		})
+	})
+
+	for bucketName, record := range stale {
+		if err := db.buildBloom(bucketName, record.Expected, record.Rate); err != nil {
+			logger.Warning("failed to rebuild bloom filter of bucket '%s' in database '%s': %v", bucketName, db.name, err)
+		}
+	}
+}
+
+func (db *DB) saveBloomFilters() error {
+	db.bloomMutex.RLock()
+	defer db.bloomMutex.RUnlock()
+
+	if len(db.blooms) == 0 || db.rejectsWrites() {
+		return nil
+	}
+	return db.rawUpdate(func(tx *bolt.Tx) error {
+		for bucketName, state := range db.blooms {
+			if state.stale.Load() {
+				continue
+			}
+			if err := saveBloomRecord(tx, bucketName, state); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func saveBloomRecord(tx *bolt.Tx, bucketName string, state *bloomState) error {
+	records, err := tx.CreateBucketIfNotExists(bloomBucket)
+	if err != nil {
+		return err
+	}
+
+	data, err := js.Marshal(bloomRecord{Expected: state.expected, Rate: state.rate, TxID: tx.ID(), Filter: state.filter.MarshalBinary()})
+	if err != nil {
+		return err
+	}
+	return records.Put([]byte(bucketName), data)
+}
+
+func dropBloomRecord(tx *bolt.Tx, bucketName string) error {
+	records := tx.Bucket(bloomBucket)
+	if records == nil {
+		return nil
+	}
+	return records.Delete([]byte(bucketName))
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/andr1ww/odin/internal/logger"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestRawWritesDoNotHideKeysBehindBloomFilter(t *testing.T) {
+	logger.DisableLogging()
+	name := "bloom-raw"
+
+	if err := Connect(name, filepath.Join(t.TempDir(), "main.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer Close(name)
+
+	db, err := GetNamed(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateBucket("users"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.EnableBloomFilter("users", 1000, 0.01); err != nil {
+		t.Fatal(err)
+	}
+
+	writes := map[string]func(fn func(*bolt.Tx) error) error{
+		"batch": db.Batch,
+		"transaction": func(fn func(*bolt.Tx) error) error {
+			return db.Transaction(true, fn)
+		},
+	}
+	for key, write := range writes {
+		if err := db.Put("users", "a", map[string]string{"name": "a"}); err != nil {
+			t.Fatal(err)
+		}
+		if stats, _ := db.BloomFilterStats("users"); stats.Stale {
+			t.Fatal("a write through the database API marked the filter stale")
+		}
+
+		if err := write(func(tx *bolt.Tx) error {
+			return tx.Bucket([]byte("users")).Put([]byte(key), []byte(`{"name":"raw"}`))
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if exists, err := db.Has("users", key); err != nil || !exists {
+			t.Fatalf("%s write hidden by the bloom filter: %v (%v)", key, exists, err)
+		}
+		var record map[string]string
+		if err := db.Get("users", key, &record); err != nil || record["name"] != "raw" {
+			t.Fatalf("get after %s write = %v, %v", key, record, err)
+		}
+
+		if err := db.RebuildBloomFilter("users"); err != nil {
+			t.Fatal(err)
+		}
+		stats, _ := db.BloomFilterStats("users")
+		if stats.Stale {
+			t.Fatal("rebuilding did not clear the stale mark")
+		}
+		if exists, _ := db.Has("users", key); !exists {
+			t.Fatalf("rebuilt filter is missing the %s key", key)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package database
+
+import (
+	err "errors"
+	"time"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/keys"
+	"github.com/andr1ww/odin/internal/logger"
+	bolt "go.etcd.io/bbolt"
+)
+
+type Cap struct {
+	MaxRecords int
+	MaxBytes   int64
+}
+
+func (db *DB) SetCap(bucketName string, limits Cap) error {
+	if limits.MaxRecords < 0 || limits.MaxBytes < 0 {
+		return err.New("cap limits cannot be negative")
+	}
+	if limits == (Cap{}) {
+		return err.New("cap needs MaxRecords or MaxBytes")
+	}
+
+	if err := db.backfillTimeline(bucketName); err != nil {
+		return err
+	}
+
+	db.configured.Store(true)
+	db.quotaMutex.Lock()
+	db.limitState(bucketName).cap = limits
+	db.quotaMutex.Unlock()
+
+	return db.enforceCap(bucketName)
+}
+
+func (db *DB) RemoveCap(bucketName string) {
+	db.quotaMutex.Lock()
+	defer db.quotaMutex.Unlock()
+	if state, exists := db.quotas[bucketName]; exists {
+		state.cap = Cap{}
+		db.pruneLimits(bucketName, state)
+	}
+}
+
+func (db *DB) GetCap(bucketName string) (Cap, bool) {
+	db.quotaMutex.Lock()
+	defer db.quotaMutex.Unlock()
+	state, exists := db.quotas[bucketName]
+	if !exists || state.cap == (Cap{}) {
+		return Cap{}, false
+	}
+	return state.cap, true
+}
+
+func (db *DB) backfillTimeline(bucketName string) error {
+	return db.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrBucketMissing
+		}
+
+		now := time.Now()
+		return b.ForEach(func(k, v []byte) error {
+			if v == nil {
+				return nil
+			}
+
+			var record struct {
+				CreatedAt time.Time `json:"created_at"`
+			}
+			at := now
+			if db.Decode(bucketName, string(k), v, &record) == nil && !record.CreatedAt.IsZero() {
+				at = record.CreatedAt
+			}
+			return stampCreated(tx, bucketName, string(k), at)
+		})
+	})
+}
+
+func (db *DB) enforceCap(bucketName string) error {
+	db.capMutex.Lock()
+	defer db.capMutex.Unlock()
+
+	db.quotaMutex.Lock()
+	state, exists := db.quotas[bucketName]
+	if !exists || state.cap == (Cap{}) {
+		db.quotaMutex.Unlock()
+		return nil
+	}
+	limits, loaded, keyCount, byteCount := state.cap, state.loaded, state.keys, state.bytes
+	db.quotaMutex.Unlock()
+
+	if !loaded {
+		count, size, usageErr := db.QuotaUsage(bucketName)
+		if usageErr != nil {
+			return usageErr
+		}
+		keyCount, byteCount = int64(count), size
+	}
+
+	over := func() bool {
+		return (limits.MaxRecords > 0 && keyCount > int64(limits.MaxRecords)) || (limits.MaxBytes > 0 && byteCount > limits.MaxBytes)
+	}
+	if !over() {
+		return nil
+	}
+
+	var victims []string
+	viewErr := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		root := tx.Bucket(timelineBucketName(bucketName))
+		if b == nil || root == nil {
+			return nil
+		}
+
+		c := root.Bucket(timelineOrder).Cursor()
+		for k, _ := c.First(); k != nil && over(); k, _ = c.Next() {
+			_, key := keys.SplitTimeKey(k)
+			value := b.Get(key)
+			if value == nil {
+				continue
+			}
+			victims = append(victims, string(key))
+			keyCount--
+			byteCount -= int64(len(key) + len(value))
+		}
+		return nil
+	})
+	if viewErr != nil {
+		return viewErr
+	}
+
+	for _, key := range victims {
+		if deleteErr := db.delete(bucketName, key, ChangeEvict, nil, db.Update); deleteErr != nil {
+			logger.Error("failed to evict '%s' from capped bucket '%s': %v", key, bucketName, deleteErr)
+			return deleteErr
+		}
+	}
+	return nil
+}
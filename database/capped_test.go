@@ -0,0 +1,29 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCapEvictsOldestAfterCommit(t *testing.T) {
+	db := openLimited(t, "cap-evict")
+	if err := db.SetCap("items", Cap{MaxRecords: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if err := db.Put("items", fmt.Sprintf("k%d", i), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < 5; i++ {
+		exists, err := db.Has("items", fmt.Sprintf("k%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := i >= 2; exists != want {
+			t.Fatalf("k%d present = %v, want %v", i, exists, want)
+		}
+	}
+}
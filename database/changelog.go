@@ -0,0 +1,156 @@
+package database
+
+import (
+	"time"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/compression"
+	"github.com/andr1ww/odin/internal/keys"
+	bolt "go.etcd.io/bbolt"
+)
+
+const changeLogPrefix = "__changes_"
+
+var (
+	changeLogSeq  = []byte("seq")
+	changeLogKeys = []byte("keys")
+)
+
+type LoggedChange struct {
+	Seq     uint64
+	Key     string
+	Value   []byte
+	Deleted bool
+	At      time.Time
+}
+
+func changeLogBucketName(bucketName string) []byte {
+	return []byte(changeLogPrefix + bucketName)
+}
+
+func (db *DB) EnableChangeLog(bucketName string) error {
+	return db.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrBucketMissing
+		}
+
+		root, err := tx.CreateBucketIfNotExists(changeLogBucketName(bucketName))
+		if err != nil {
+			return err
+		}
+		if _, err := root.CreateBucketIfNotExists(changeLogSeq); err != nil {
+			return err
+		}
+		byKey, err := root.CreateBucketIfNotExists(changeLogKeys)
+		if err != nil {
+			return err
+		}
+		if byKey.Stats().KeyN > 0 {
+			return nil
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			if v == nil {
+				return nil
+			}
+			return logChange(tx, bucketName, string(k), time.Now())
+		})
+	})
+}
+
+func (db *DB) DisableChangeLog(bucketName string) error {
+	return db.update(func(tx *bolt.Tx) error {
+		if tx.Bucket(changeLogBucketName(bucketName)) == nil {
+			return nil
+		}
+		return tx.DeleteBucket(changeLogBucketName(bucketName))
+	})
+}
+
+func logChange(tx *bolt.Tx, bucketName, key string, at time.Time) error {
+	root := tx.Bucket(changeLogBucketName(bucketName))
+	if root == nil {
+		return nil
+	}
+	order, byKey := root.Bucket(changeLogSeq), root.Bucket(changeLogKeys)
+
+	if previous := byKey.Get([]byte(key)); previous != nil {
+		if err := order.Delete(previous[:8]); err != nil {
+			return err
+		}
+	}
+
+	seq, err := root.NextSequence()
+	if err != nil {
+		return err
+	}
+	entry := append(keys.EncodeUint64(seq), keys.EncodeTime(at)...)
+	if err := order.Put(entry[:8], []byte(key)); err != nil {
+		return err
+	}
+	return byKey.Put([]byte(key), entry)
+}
+
+func (db *DB) ChangeLogSeq(bucketName string) (uint64, error) {
+	var seq uint64
+	err := db.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket(changeLogBucketName(bucketName))
+		if root == nil {
+			return errors.ErrBucketMissing
+		}
+		seq = root.Sequence()
+		return nil
+	})
+	return seq, err
+}
+
+func (db *DB) KeyChange(bucketName, key string) (uint64, time.Time, bool, error) {
+	var seq uint64
+	var at time.Time
+	var found bool
+	err := db.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket(changeLogBucketName(bucketName))
+		if root == nil {
+			return errors.ErrBucketMissing
+		}
+		if entry := root.Bucket(changeLogKeys).Get([]byte(key)); entry != nil {
+			seq, at, found = keys.DecodeUint64(entry[:8]), keys.DecodeTime(entry[8:]), true
+		}
+		return nil
+	})
+	return seq, at, found, err
+}
+
+func (db *DB) ChangesSince(bucketName string, since uint64, limit int) ([]LoggedChange, error) {
+	var changes []LoggedChange
+	err := db.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket(changeLogBucketName(bucketName))
+		if root == nil {
+			return errors.ErrBucketMissing
+		}
+		b := tx.Bucket([]byte(bucketName))
+		byKey := root.Bucket(changeLogKeys)
+
+		c := root.Bucket(changeLogSeq).Cursor()
+		for k, key := c.Seek(keys.EncodeUint64(since + 1)); k != nil; k, key = c.Next() {
+			if limit > 0 && len(changes) == limit {
+				break
+			}
+
+			change := LoggedChange{Seq: keys.DecodeUint64(k), Key: string(key), Deleted: true}
+			if entry := byKey.Get(key); entry != nil {
+				change.At = keys.DecodeTime(entry[8:])
+			}
+			if b != nil {
+				if value := b.Get(key); value != nil {
+					change.Value = compression.DecompressData(db.ColdValue(bucketName, string(key), value))
+					change.Deleted = false
+				}
+			}
+			changes = append(changes, change)
+		}
+		return nil
+	})
+	return changes, err
+}
@@ -0,0 +1,260 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+type CommitStage int
+
+const (
+	StageIndex CommitStage = iota
+	StageCache
+	StageWatch
+	StageCDC
+)
+
+type CommitHook struct {
+	Stage   CommitStage
+	Buckets func(bucketName string) bool
+	Run     func(changes []Change)
+	RunInTx func(tx *bolt.Tx, change Change) error
+	Discard func(changes []Change)
+}
+
+type registeredHook struct {
+	id uint64
+	CommitHook
+}
+
+type commitPipeline struct {
+	mutex      sync.Mutex
+	staged     map[*bolt.Tx]*commitBatch
+	assigned   uint64
+	next       uint64
+	ready      map[uint64][]Change
+	delivering bool
+	followUps  map[*bolt.Tx][]func()
+
+	hookMutex sync.RWMutex
+	hooks     []registeredHook
+	nextHook  uint64
+}
+
+type commitBatch struct {
+	seq     uint64
+	changes []Change
+}
+
+func (db *DB) OnCommit(hook CommitHook) func() {
+	p := &db.commits
+	p.hookMutex.Lock()
+	defer p.hookMutex.Unlock()
+
+	p.nextHook++
+	id := p.nextHook
+	p.hooks = append(p.hooks, registeredHook{id: id, CommitHook: hook})
+	sort.SliceStable(p.hooks, func(i, j int) bool {
+		return p.hooks[i].Stage < p.hooks[j].Stage
+	})
+
+	return func() {
+		p.hookMutex.Lock()
+		defer p.hookMutex.Unlock()
+		for i, registered := range p.hooks {
+			if registered.id == id {
+				p.hooks = append(p.hooks[:i:i], p.hooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (db *DB) hasCommitHooks(bucketName string) bool {
+	p := &db.commits
+	p.hookMutex.RLock()
+	defer p.hookMutex.RUnlock()
+
+	for _, hook := range p.hooks {
+		if hook.Buckets == nil || hook.Buckets(bucketName) {
+			return true
+		}
+	}
+	return false
+}
+
+func (db *DB) stageChange(tx *bolt.Tx, bucketName, key string, old, data []byte, changeType ChangeType) error {
+	change := Change{Bucket: bucketName, Key: key, Type: changeType, Old: old, Value: data, Time: time.Now()}
+
+	p := &db.commits
+	p.mutex.Lock()
+	batch, exists := p.staged[tx]
+	if !exists {
+		if p.staged == nil {
+			p.staged = make(map[*bolt.Tx]*commitBatch)
+		}
+		batch = &commitBatch{seq: p.assigned}
+		p.assigned++
+		p.staged[tx] = batch
+	}
+	batch.changes = append(batch.changes, change)
+	p.mutex.Unlock()
+
+	p.hookMutex.RLock()
+	hooks := append([]registeredHook(nil), p.hooks...)
+	p.hookMutex.RUnlock()
+
+	for _, hook := range hooks {
+		if hook.RunInTx == nil || (hook.Buckets != nil && !hook.Buckets(bucketName)) {
+			continue
+		}
+		if err := hook.RunInTx(tx, change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *DB) finishCommit(tx *bolt.Tx, committed bool) {
+	if tx == nil {
+		return
+	}
+
+	p := &db.commits
+	p.mutex.Lock()
+	batch, exists := p.staged[tx]
+	if !exists {
+		p.mutex.Unlock()
+		return
+	}
+	delete(p.staged, tx)
+
+	if p.ready == nil {
+		p.ready = make(map[uint64][]Change)
+	}
+	if committed {
+		p.ready[batch.seq] = batch.changes
+	} else {
+		p.ready[batch.seq] = nil
+		defer db.discardCommit(batch.changes)
+	}
+	if p.delivering {
+		p.mutex.Unlock()
+		return
+	}
+	p.delivering = true
+
+	for {
+		changes, ready := p.ready[p.next]
+		if !ready {
+			p.delivering = false
+			p.mutex.Unlock()
+			return
+		}
+		delete(p.ready, p.next)
+		p.next++
+		p.mutex.Unlock()
+
+		if len(changes) > 0 {
+			db.deliverCommit(changes)
+		}
+		p.mutex.Lock()
+	}
+}
+
+// afterCommit queues fn to run once tx has committed and the handle guard is
+// released, so it may open transactions of its own.
+func (db *DB) afterCommit(tx *bolt.Tx, fn func()) {
+	p := &db.commits
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.followUps == nil {
+		p.followUps = make(map[*bolt.Tx][]func())
+	}
+	p.followUps[tx] = append(p.followUps[tx], fn)
+}
+
+func (db *DB) runFollowUps(tx *bolt.Tx, committed bool) {
+	if tx == nil {
+		return
+	}
+
+	p := &db.commits
+	p.mutex.Lock()
+	followUps := p.followUps[tx]
+	delete(p.followUps, tx)
+	p.mutex.Unlock()
+
+	if !committed {
+		return
+	}
+	for _, fn := range followUps {
+		fn()
+	}
+}
+
+func (db *DB) deliverCommit(changes []Change) {
+	db.sequenceChanges(changes)
+
+	p := &db.commits
+	p.hookMutex.RLock()
+	hooks := append([]registeredHook(nil), p.hooks...)
+	p.hookMutex.RUnlock()
+
+	runHooks := func(stage CommitStage) {
+		for _, hook := range hooks {
+			if hook.Stage != stage {
+				continue
+			}
+			if hook.Run == nil {
+				continue
+			}
+			if selected := selectChanges(changes, hook.Buckets); len(selected) > 0 {
+				hook.Run(selected)
+			}
+		}
+	}
+
+	runHooks(StageIndex)
+	runHooks(StageCache)
+	for _, change := range changes {
+		db.fireTriggers(change.Bucket, change.Key, change.Old, change.Value)
+		db.deliverWatchers(change)
+	}
+	runHooks(StageWatch)
+	db.publishChanges(changes)
+	runHooks(StageCDC)
+}
+
+func (db *DB) discardCommit(changes []Change) {
+	p := &db.commits
+	p.hookMutex.RLock()
+	hooks := append([]registeredHook(nil), p.hooks...)
+	p.hookMutex.RUnlock()
+
+	for _, hook := range hooks {
+		if hook.Discard == nil {
+			continue
+		}
+		if selected := selectChanges(changes, hook.Buckets); len(selected) > 0 {
+			hook.Discard(selected)
+		}
+	}
+}
+
+func selectChanges(changes []Change, buckets func(string) bool) []Change {
+	if buckets == nil {
+		return changes
+	}
+	var selected []Change
+	for _, change := range changes {
+		if buckets(change.Bucket) {
+			selected = append(selected, change)
+		}
+	}
+	return selected
+}
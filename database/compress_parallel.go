@@ -0,0 +1,130 @@
+package database
+
+import (
+	err "errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andr1ww/odin/internal/logger"
+)
+
+type BucketCompressionReport struct {
+	Bucket     string        `json:"bucket"`
+	Processed  int           `json:"processed"`
+	Rewritten  int           `json:"rewritten"`
+	BytesSaved int64         `json:"bytes_saved"`
+	Skipped    bool          `json:"skipped"`
+	Error      string        `json:"error,omitempty"`
+	Duration   time.Duration `json:"duration"`
+}
+
+type CompressionReport struct {
+	Database   string                    `json:"database"`
+	Workers    int                       `json:"workers"`
+	Buckets    []BucketCompressionReport `json:"buckets"`
+	Processed  int                       `json:"processed"`
+	Rewritten  int                       `json:"rewritten"`
+	BytesSaved int64                     `json:"bytes_saved"`
+	Failed     int                       `json:"failed"`
+	Duration   time.Duration             `json:"duration"`
+}
+
+func (db *DB) CompressAllBucketsParallel(workers int) (*CompressionReport, error) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	all, listErr := db.ListBuckets()
+	if listErr != nil {
+		return nil, fmt.Errorf("failed to list buckets: %w", listErr)
+	}
+
+	var buckets []string
+	for _, name := range all {
+		if !strings.HasPrefix(name, "__") {
+			buckets = append(buckets, name)
+		}
+	}
+
+	report := &CompressionReport{Database: db.name, Workers: min(workers, len(buckets)), Buckets: make([]BucketCompressionReport, len(buckets))}
+	start := time.Now()
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < report.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				report.Buckets[index] = db.compressBucketBatched(buckets[index])
+			}
+		}()
+	}
+	for index := range buckets {
+		jobs <- index
+	}
+	close(jobs)
+	wg.Wait()
+
+	var errs []error
+	for _, bucket := range report.Buckets {
+		report.Processed += bucket.Processed
+		report.Rewritten += bucket.Rewritten
+		report.BytesSaved += bucket.BytesSaved
+		if bucket.Error != "" {
+			report.Failed++
+			errs = append(errs, fmt.Errorf("bucket '%s': %s", bucket.Bucket, bucket.Error))
+		}
+	}
+	report.Duration = time.Since(start)
+
+	if len(errs) > 0 {
+		logger.Error("compression of database '%s' finished with %d failed buckets", db.name, report.Failed)
+		return report, err.Join(errs...)
+	}
+	logger.Success("compressed %d buckets in database '%s' with %d workers: %d records processed, %d rewritten, %d bytes saved in %s",
+		len(buckets), db.name, report.Workers, report.Processed, report.Rewritten, report.BytesSaved, report.Duration)
+	return report, nil
+}
+
+func (db *DB) compressBucketBatched(bucketName string) BucketCompressionReport {
+	report := BucketCompressionReport{Bucket: bucketName}
+	if !db.CompressionEnabled(bucketName) {
+		report.Skipped = true
+		return report
+	}
+
+	total, _ := db.Count(bucketName)
+	tracker := db.trackProgress(OpCompress, bucketName, total)
+	defer tracker.finish()
+
+	start := time.Now()
+	var after []byte
+	for {
+		batch, err := db.readRaw(bucketName, after, defaultRecompressBatch)
+		if err != nil {
+			report.Error = err.Error()
+			break
+		}
+		if len(batch) == 0 {
+			break
+		}
+		after = []byte(batch[len(batch)-1].key)
+
+		rewritten, saved, err := db.recompressBatch(bucketName, batch, false)
+		if err != nil {
+			report.Error = err.Error()
+			tracker.step(0, true)
+			break
+		}
+		tracker.step(len(batch), false)
+		report.Processed += len(batch)
+		report.Rewritten += rewritten
+		report.BytesSaved += saved
+	}
+	report.Duration = time.Since(start)
+	return report
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/andr1ww/odin/internal/compression"
+)
+
+const (
+	HuffmanOnly        = compression.HuffmanOnly
+	DefaultCompression = compression.DefaultCompression
+	BestSpeed          = compression.BestSpeed
+	BestCompression    = compression.BestCompression
+)
+
+type ValueFormat = compression.Format
+
+var compressionDisabled atomic.Bool
+
+func RegisterValueFormat(format ValueFormat) error {
+	return compression.RegisterFormat(format)
+}
+
+func ValueFormats() []ValueFormat {
+	return compression.Formats()
+}
+
+type compressionOverride struct {
+	enabled   *bool
+	level     *int
+	threshold *int
+}
+
+func SetCompression(enabled bool) {
+	compressionDisabled.Store(!enabled)
+}
+
+func (db *DB) SetBucketCompression(bucketName string, enabled bool) {
+	db.overrideCompression(bucketName, func(o *compressionOverride) {
+		o.enabled = &enabled
+	})
+}
+
+func (db *DB) SetBucketCompressionLevel(bucketName string, level int) error {
+	if !compression.ValidLevel(level) {
+		return fmt.Errorf("invalid compression level %d", level)
+	}
+	db.overrideCompression(bucketName, func(o *compressionOverride) {
+		o.level = &level
+	})
+	return nil
+}
+
+func (db *DB) SetBucketCompressionThreshold(bucketName string, threshold int) error {
+	if threshold < 0 {
+		return fmt.Errorf("invalid compression threshold %d", threshold)
+	}
+	db.overrideCompression(bucketName, func(o *compressionOverride) {
+		o.threshold = &threshold
+	})
+	return nil
+}
+
+func (db *DB) ClearBucketCompression(bucketName string) {
+	db.compressionMutex.Lock()
+	defer db.compressionMutex.Unlock()
+	delete(db.compression, bucketName)
+}
+
+func (db *DB) overrideCompression(bucketName string, fn func(o *compressionOverride)) {
+	db.configured.Store(true)
+	db.compressionMutex.Lock()
+	defer db.compressionMutex.Unlock()
+
+	override := db.compression[bucketName]
+	fn(&override)
+	db.compression[bucketName] = override
+}
+
+func (db *DB) CompressionEnabled(bucketName string) bool {
+	enabled, _ := db.compressionSettings(bucketName)
+	return enabled
+}
+
+func (db *DB) compressionSettings(bucketName string) (bool, compression.Settings) {
+	enabled := db.options.Compression
+	settings := compression.Settings{Level: db.options.CompressionLevel, Threshold: db.options.CompressionThreshold}
+
+	db.compressionMutex.RLock()
+	defer db.compressionMutex.RUnlock()
+
+	override := db.compression[bucketName]
+	if override.enabled != nil {
+		enabled = *override.enabled
+	}
+	if override.level != nil {
+		settings.Level = *override.level
+	}
+	if override.threshold != nil {
+		settings.Threshold = *override.threshold
+	}
+	return enabled, settings
+}
+
+func (db *DB) encode(bucketName string, data []byte) []byte {
+	enabled, settings := db.compressionSettings(bucketName)
+	if !enabled {
+		return data
+	}
+	return compression.CompressWith(data, settings)
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	err "errors"
+	"fmt"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/compression"
+	"github.com/andr1ww/odin/internal/keys"
+	bolt "go.etcd.io/bbolt"
+)
+
+const versionsPrefix = "__versions_"
+
+type Condition struct {
+	AbsentOnly   bool
+	MatchVersion uint64
+	MatchHash    string
+	MatchValue   []byte
+}
+
+func versionBucketName(bucketName string) []byte {
+	return []byte(versionsPrefix + bucketName)
+}
+
+func (db *DB) EnableVersioning(bucketName string) error {
+	return db.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrBucketMissing
+		}
+
+		versions, err := tx.CreateBucketIfNotExists(versionBucketName(bucketName))
+		if err != nil {
+			return fmt.Errorf("create version bucket for %s: %w", bucketName, err)
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			if v == nil || versions.Get(k) != nil {
+				return nil
+			}
+			return versions.Put(k, keys.EncodeUint64(1))
+		})
+	})
+}
+
+func bumpVersion(tx *bolt.Tx, bucketName, key string) error {
+	versions := tx.Bucket(versionBucketName(bucketName))
+	if versions == nil {
+		return nil
+	}
+
+	next := keys.DecodeUint64(versions.Get([]byte(key))) + 1
+	return versions.Put([]byte(key), keys.EncodeUint64(next))
+}
+
+func dropVersion(tx *bolt.Tx, bucketName, key string) error {
+	versions := tx.Bucket(versionBucketName(bucketName))
+	if versions == nil {
+		return nil
+	}
+	return versions.Delete([]byte(key))
+}
+
+func (db *DB) Version(bucketName string, key string) (uint64, error) {
+	var version uint64
+
+	err := db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(bucketName)) == nil {
+			return errors.ErrBucketMissing
+		}
+
+		versions := tx.Bucket(versionBucketName(bucketName))
+		if versions == nil {
+			return errors.ErrNotVersioned
+		}
+
+		version = keys.DecodeUint64(versions.Get([]byte(key)))
+		return nil
+	})
+	return version, err
+}
+
+func (db *DB) Hash(bucketName string, key string) (string, error) {
+	var hash string
+
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrBucketMissing
+		}
+
+		data := b.Get([]byte(key))
+		if data == nil {
+			return errors.ErrNotFound
+		}
+
+		hash = hashValue(compression.DecompressData(db.ColdValue(bucketName, key, data)))
+		return nil
+	})
+	return hash, err
+}
+
+func hashValue(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (db *DB) PutIf(bucketName string, key string, value interface{}, cond Condition) error {
+	return db.Intercept(OpInfo{Op: OpPutIf, Bucket: bucketName, Key: key, Keys: 1}, func() error {
+		return db.putIf(bucketName, key, value, cond)
+	})
+}
+
+func (db *DB) putIf(bucketName string, key string, value interface{}, cond Condition) error {
+	if key == "" {
+		return err.New("key cannot be empty")
+	}
+	if value == nil {
+		return errors.ErrNilValue
+	}
+
+	data, err := js.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("error marshaling data: %w", err)
+	}
+
+	return db.modify(bucketName, key, func([]byte) ([]byte, error) {
+		return data, nil
+	}, func(tx *bolt.Tx, current []byte) error {
+		return checkCondition(tx, bucketName, key, current, cond)
+	})
+}
+
+func checkCondition(tx *bolt.Tx, bucketName, key string, current []byte, cond Condition) error {
+	if cond.AbsentOnly && current != nil {
+		return fmt.Errorf("%w: key '%s' already exists", errors.ErrConditionFailed, key)
+	}
+
+	if cond.MatchVersion > 0 {
+		versions := tx.Bucket(versionBucketName(bucketName))
+		if versions == nil {
+			return errors.ErrNotVersioned
+		}
+
+		version := keys.DecodeUint64(versions.Get([]byte(key)))
+		if version != cond.MatchVersion {
+			return fmt.Errorf("%w: key '%s' is at version %d, expected %d", errors.ErrConditionFailed, key, version, cond.MatchVersion)
+		}
+	}
+
+	if cond.MatchHash != "" {
+		if current == nil || hashValue(current) != cond.MatchHash {
+			return fmt.Errorf("%w: hash of key '%s' does not match", errors.ErrConditionFailed, key)
+		}
+	}
+
+	if cond.MatchValue != nil {
+		if current == nil || !bytes.Equal(current, cond.MatchValue) {
+			return fmt.Errorf("%w: key '%s' changed", errors.ErrConditionFailed, key)
+		}
+	}
+	return nil
+}
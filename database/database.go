@@ -1,11 +1,14 @@
 package database
 
 import (
+	"context"
 	err "errors"
 	"fmt"
 	"os"
 	"reflect"
-	"strings"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/andr1ww/odin/errors"
@@ -20,65 +23,270 @@ var js = jsoniter.ConfigCompatibleWithStandardLibrary
 
 type DB struct {
 	*bolt.DB
-	name string
+	name    string
+	options Options
+
+	lazy       bool
+	configured atomic.Bool
+	lastUsed   atomic.Int64
+	standby    atomic.Bool
+	replica    atomic.Bool
+	readOnly   atomic.Bool
+	fileInfo   atomic.Pointer[os.FileInfo]
+
+	reopenMutex sync.Mutex
+	handleMutex sync.RWMutex
+	writeMutex  sync.RWMutex
+
+	done    chan struct{}
+	bgMutex sync.Mutex
+	stopped bool
+	wg      sync.WaitGroup
+
+	retentionMutex sync.Mutex
+	retention      map[string]RetentionPolicy
+	retentionStop  chan struct{}
+
+	recycleMutex     sync.Mutex
+	recycleRetention time.Duration
+	recycleStop      chan struct{}
+
+	triggerMutex sync.RWMutex
+	triggers     map[string][]Trigger
+
+	watchMutex  sync.RWMutex
+	watchers    map[int]*watcher
+	nextWatcher int
+
+	pubsubMutex    sync.Mutex
+	subscribers    map[int]*Subscription
+	nextSubscriber int
+	changeSeq      uint64
+	publishedSeq   uint64
+	history        []Change
+	historySize    int
+
+	historyConfigured bool
+
+	expiryMutex sync.Mutex
+	expiryWake  chan struct{}
+
+	quotaMutex sync.Mutex
+	quotas     map[string]*quotaState
+	capMutex   sync.Mutex
+
+	tierMutex sync.Mutex
+	tiering   map[string]*tierState
+
+	bloomMutex sync.RWMutex
+	blooms     map[string]*bloomState
+
+	slowThreshold atomic.Int64
+	slowMutex     sync.Mutex
+	slowOps       map[string]*SlowOpStats
+
+	interceptMutex sync.RWMutex
+	interceptors   []Interceptor
+
+	compressionMutex sync.RWMutex
+	compression      map[string]compressionOverride
+
+	progressMutex sync.RWMutex
+
+	migrationPolicy atomic.Int32
+	migrations      migrator
+	quarantine      quarantine
+	commits         commitPipeline
+	scanner         scanExecutor
+	scheduler       scheduler
+	limits          limiters
 }
 
-func openDatabase(name, dbPath string) (*DB, error) {
-	boltDB, err := bolt.Open(dbPath, 0600, &bolt.Options{
-		Timeout:         10 * time.Second,
-		InitialMmapSize: 10 * 1024 * 1024,
+func boltOptions(options Options) *bolt.Options {
+	return &bolt.Options{
+		Timeout:         options.Timeout,
+		InitialMmapSize: options.MmapSize,
 		PageSize:        8096,
-		NoSync:          false,
+		NoSync:          options.NoSync,
 		NoFreelistSync:  false,
 		FreelistType:    bolt.FreelistMapType,
 		NoGrowSync:      true,
 		MmapFlags:       0,
-	})
+		ReadOnly:        options.ReadOnly,
+	}
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to open database %s: %w", name, err)
+func openDatabase(name, dbPath string, options Options) (*DB, error) {
+	if options.Naming != nil {
+		reflection.SetNamingStrategy(name, options.Naming)
+	}
+	if options.ReadOnly {
+		if _, statErr := os.Stat(dbPath); statErr != nil {
+			return nil, fmt.Errorf("failed to open database %s read-only: %w", name, statErr)
+		}
+	} else if err := recoverMaintenanceFiles(options, name, dbPath); err != nil {
+		return nil, fmt.Errorf("failed to recover database %s: %w", name, err)
 	}
 
-	err = boltDB.Update(func(tx *bolt.Tx) error {
-		return nil
-	})
+	boltDB, err := bolt.Open(dbPath, 0600, boltOptions(options))
+
 	if err != nil {
-		boltDB.Close()
-		return nil, err
+		if err == bolt.ErrTimeout && options.ReadOnly {
+			return nil, fmt.Errorf("failed to open database %s read-only: %w: %s is held by a writer", name, errors.ErrDatabaseLocked, dbPath)
+		}
+		if err == bolt.ErrTimeout {
+			return nil, fmt.Errorf("failed to open database %s: %w: %s", name, errors.ErrDatabaseLocked, dbPath)
+		}
+		return nil, fmt.Errorf("failed to open database %s: %w", name, err)
 	}
 
-	if err := reflection.FindAndInitBuckets(boltDB, name); err != nil {
-		boltDB.Close()
-		return nil, err
+	if !options.ReadOnly {
+		err = boltDB.Update(func(tx *bolt.Tx) error {
+			return nil
+		})
+		if err != nil {
+			boltDB.Close()
+			return nil, err
+		}
+
+		if err := reflection.FindAndInitBuckets(boltDB, name); err != nil {
+			boltDB.Close()
+			return nil, err
+		}
 	}
 
-	return &DB{DB: boltDB, name: name}, nil
+	db := &DB{
+		DB:        boltDB,
+		name:      name,
+		options:   options,
+		done:      make(chan struct{}),
+		retention: make(map[string]RetentionPolicy),
+		triggers:  make(map[string][]Trigger),
+		watchers:  make(map[int]*watcher),
+
+		subscribers: make(map[int]*Subscription),
+		compression: make(map[string]compressionOverride),
+		quotas:      make(map[string]*quotaState),
+		tiering:     make(map[string]*tierState),
+		blooms:      make(map[string]*bloomState),
+		limits:      newLimiters(options),
+	}
+	db.standby.Store(options.standby)
+	db.readOnly.Store(boltDB.IsReadOnly())
+	db.slowThreshold.Store(int64(options.SlowThreshold))
+	db.use(options.Interceptors...)
+	db.migrationPolicy.Store(int32(options.MigrationPolicy))
+	db.loadBloomFilters()
+	db.recordFileIdentity()
+	db.resumeExpiry()
+	return db, nil
 }
 
 func (db *DB) GetName() string {
 	return db.name
 }
 
-func (db *DB) CreateBucket(bucketName string) error {
-	return db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte(bucketName))
-		if err != nil {
-			return fmt.Errorf("create bucket %s: %w", bucketName, err)
-		}
+func (db *DB) signalStop() {
+	db.bgMutex.Lock()
+	defer db.bgMutex.Unlock()
+
+	if !db.stopped {
+		db.stopped = true
+		close(db.done)
+	}
+}
+
+func (db *DB) stopBackground() {
+	db.signalStop()
+	db.wg.Wait()
+}
+
+func (db *DB) waitBackground(ctx context.Context) error {
+	finished := make(chan struct{})
+	go func() {
+		db.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
 		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for background work of database '%s': %w", db.name, ctx.Err())
+	}
+}
+
+func (db *DB) goBackground(fn func()) bool {
+	db.bgMutex.Lock()
+	defer db.bgMutex.Unlock()
+
+	if db.stopped {
+		return false
+	}
+
+	db.wg.Add(1)
+	go func() {
+		defer db.wg.Done()
+		fn()
+	}()
+	return true
+}
+
+func (db *DB) CreateBucket(bucketName string) error {
+	return db.Intercept(OpInfo{Op: OpCreateBucket, Bucket: bucketName}, func() error {
+		return db.update(func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists([]byte(bucketName))
+			if err != nil {
+				return fmt.Errorf("create bucket %s: %w", bucketName, err)
+			}
+			return nil
+		})
 	})
 }
 
 func (db *DB) DeleteBucket(bucketName string) error {
-	return db.Update(func(tx *bolt.Tx) error {
+	return db.Intercept(OpInfo{Op: OpDeleteBucket, Bucket: bucketName}, func() error {
+		return db.deleteBucket(bucketName)
+	})
+}
+
+func (db *DB) deleteBucket(bucketName string) error {
+	return db.update(func(tx *bolt.Tx) error {
 		err := tx.DeleteBucket([]byte(bucketName))
 		if err != nil {
 			return fmt.Errorf("delete bucket %s: %w", bucketName, err)
 		}
+		if err := dropCompanions(tx, bucketName); err != nil {
+			return err
+		}
+		if err := dropBloomRecord(tx, bucketName); err != nil {
+			return err
+		}
+		tx.OnCommit(func() {
+			db.bloomMutex.Lock()
+			delete(db.blooms, bucketName)
+			db.bloomMutex.Unlock()
+		})
+		db.resetQuotaUsage(tx, bucketName)
+		if tx.Bucket(versionBucketName(bucketName)) != nil {
+			return tx.DeleteBucket(versionBucketName(bucketName))
+		}
 		return nil
 	})
 }
 
+func dropCompanions(tx *bolt.Tx, bucketName string) error {
+	for _, name := range [][]byte{expiryBucketName(bucketName), geoBucketName(bucketName), timelineBucketName(bucketName), accessBucketName(bucketName), []byte(QuarantineBucketName(bucketName))} {
+		if tx.Bucket(name) == nil {
+			continue
+		}
+		if err := tx.DeleteBucket(name); err != nil {
+			return fmt.Errorf("delete %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
 func (db *DB) ListBuckets() ([]string, error) {
 	var buckets []string
 	err := db.View(func(tx *bolt.Tx) error {
@@ -91,39 +299,196 @@ func (db *DB) ListBuckets() ([]string, error) {
 }
 
 func (db *DB) Put(bucketName string, key string, value interface{}) error {
+	return db.Intercept(OpInfo{Op: OpPut, Bucket: bucketName, Key: key, Keys: 1}, func() error {
+		return db.put(bucketName, key, value)
+	})
+}
+
+func (db *DB) put(bucketName string, key string, value interface{}) error {
 	if key == "" {
 		return err.New("key cannot be empty")
 	}
 	if value == nil {
 		return errors.ErrNilValue
 	}
+	defer db.ObserveOp(OpPut, bucketName, "", time.Now(), 1)
 
 	data, err := js.Marshal(value)
 	if err != nil {
 		return fmt.Errorf("error marshaling data: %w", err)
 	}
 
-	compressedData := compression.CompressData(data)
+	compressedData := db.encode(bucketName, data)
+	observed := db.hasObservers(bucketName)
 
-	return db.Update(func(tx *bolt.Tx) error {
+	var old []byte
+	err = db.limitedUpdate(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(bucketName))
 		if b == nil {
 			return errors.ErrBucketMissing
 		}
+
+		existing := b.Get([]byte(key))
+		if observed && existing != nil {
+			old = compression.DecompressData(db.ColdValue(bucketName, key, existing))
+		}
+		if err := db.admit(tx, bucketName, key, existing, compressedData); err != nil {
+			return err
+		}
+		if err := bumpVersion(tx, bucketName, key); err != nil {
+			return err
+		}
+		if err := logChange(tx, bucketName, key, time.Now()); err != nil {
+			return err
+		}
+		if observed {
+			if err := db.stageChange(tx, bucketName, key, old, data, changeTypeOf(old, data)); err != nil {
+				return err
+			}
+		}
 		return b.Put([]byte(key), compressedData)
 	})
+	return err
+}
+
+func (db *DB) PutMany(bucketName string, values map[string]interface{}) error {
+	return db.Intercept(OpInfo{Op: OpPutMany, Bucket: bucketName, Keys: len(values)}, func() error {
+		return db.putMany(bucketName, values)
+	})
+}
+
+func (db *DB) putMany(bucketName string, values map[string]interface{}) error {
+	defer db.ObserveOp(OpPutMany, bucketName, "", time.Now(), len(values))
+
+	ordered := make([]string, 0, len(values))
+	encoded := make(map[string][]byte, len(values))
+	for key, value := range values {
+		if key == "" {
+			return err.New("key cannot be empty")
+		}
+		if value == nil {
+			return errors.ErrNilValue
+		}
+
+		data, err := js.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("error marshaling data for key %s: %w", key, err)
+		}
+		ordered = append(ordered, key)
+		encoded[key] = data
+	}
+	sort.Strings(ordered)
+
+	observed := db.hasObservers(bucketName)
+	olds := make(map[string][]byte)
+	err := db.limitedUpdate(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrBucketMissing
+		}
+
+		for _, key := range ordered {
+			existing := b.Get([]byte(key))
+			if observed && existing != nil {
+				olds[key] = compression.DecompressData(db.ColdValue(bucketName, key, existing))
+			}
+			value := db.encode(bucketName, encoded[key])
+			if err := db.admit(tx, bucketName, key, existing, value); err != nil {
+				return err
+			}
+			if err := bumpVersion(tx, bucketName, key); err != nil {
+				return err
+			}
+			if err := logChange(tx, bucketName, key, time.Now()); err != nil {
+				return err
+			}
+			if err := b.Put([]byte(key), value); err != nil {
+				return err
+			}
+			if observed {
+				if err := db.stageChange(tx, bucketName, key, olds[key], encoded[key], changeTypeOf(olds[key], encoded[key])); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+func (db *DB) modify(bucketName string, key string, fn func(current []byte) ([]byte, error), checks ...func(tx *bolt.Tx, current []byte) error) error {
+	if key == "" {
+		return err.New("key cannot be empty")
+	}
+
+	observed := db.hasObservers(bucketName)
+
+	var old, data []byte
+	err := db.limitedUpdate(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrBucketMissing
+		}
+
+		existing := b.Get([]byte(key))
+		if existing != nil {
+			old = compression.DecompressData(db.ColdValue(bucketName, key, existing))
+		}
+
+		for _, check := range checks {
+			if err := check(tx, old); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		data, err = fn(old)
+		if err != nil {
+			return err
+		}
+		if data == nil {
+			return nil
+		}
+		value := db.encode(bucketName, data)
+		if err := db.admit(tx, bucketName, key, existing, value); err != nil {
+			return err
+		}
+		if err := bumpVersion(tx, bucketName, key); err != nil {
+			return err
+		}
+		if err := logChange(tx, bucketName, key, time.Now()); err != nil {
+			return err
+		}
+		if observed {
+			if err := db.stageChange(tx, bucketName, key, old, data, changeTypeOf(old, data)); err != nil {
+				return err
+			}
+		}
+		return b.Put([]byte(key), value)
+	})
+	return err
 }
 
 func (db *DB) Get(bucketName string, key string, target interface{}) error {
+	return db.Intercept(OpInfo{Op: OpGet, Bucket: bucketName, Key: key, Keys: 1}, func() error {
+		return db.get(bucketName, key, target)
+	})
+}
+
+func (db *DB) get(bucketName string, key string, target interface{}) error {
 	if key == "" {
 		return err.New("key cannot be empty")
 	}
 	if target == nil {
 		return errors.ErrNilValue
 	}
+	defer db.ObserveOp(OpGet, bucketName, "", time.Now(), 1)
+	if db.bloomMiss(bucketName, key) {
+		return errors.ErrNotFound
+	}
 
 	var needsMigration bool
-	var rawData []byte
+	var rawData, stub []byte
 
 	err := db.View(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(bucketName))
@@ -140,57 +505,139 @@ func (db *DB) Get(bucketName string, key string, target interface{}) error {
 			return errors.ErrInvalidData
 		}
 
-		rawData = make([]byte, len(data))
-		copy(rawData, data)
+		if isColdStub(data) {
+			stub = append([]byte(nil), data...)
+			return nil
+		}
 
-		actualData := compression.DecompressData(data)
+		actualData, buf, decompressErr := db.decompressPooled(bucketName, key, data)
+		if decompressErr != nil {
+			return decompressErr
+		}
+		defer releaseDecodeBuffer(buf)
 
-		if len(data) > 0 && (data[0] == 0 || data[0] == 1) && len(actualData) > 50 {
-			needsMigration = true
+		if needsMigration = needsFormatMigration(data, actualData); needsMigration {
+			rawData = append([]byte(nil), data...)
 		}
 
-		return js.Unmarshal(actualData, target)
+		if err := js.Unmarshal(actualData, target); err != nil {
+			return &DecodeError{Database: db.name, Bucket: bucketName, Key: key, Err: err}
+		}
+		reflection.SetRecordKey(target, key)
+		return nil
 	})
 
 	if err != nil {
 		return err
 	}
+	db.touch(bucketName, key)
+
+	if stub != nil {
+		raw, thawErr := db.thaw(bucketName, key, stub)
+		if thawErr != nil {
+			return thawErr
+		}
+		if err := db.Decode(bucketName, key, raw, target); err != nil {
+			return err
+		}
+		reflection.SetRecordKey(target, key)
+		return nil
+	}
 
 	if needsMigration {
-		go func() {
-			db.Put(bucketName, key, target)
-		}()
+		if migrateErr := db.migrateFormat(bucketName, key, rawData); migrateErr != nil {
+			logger.Warning("database '%s': %v", db.name, migrateErr)
+		}
 	}
 
 	return nil
 }
 
 func (db *DB) Delete(bucketName string, key string) error {
+	return db.Intercept(OpInfo{Op: OpDelete, Bucket: bucketName, Key: key, Keys: 1}, func() error {
+		defer db.ObserveOp(OpDelete, bucketName, "", time.Now(), 1)
+		return db.delete(bucketName, key, ChangeDelete, nil, db.limitedUpdate)
+	})
+}
+
+func (db *DB) delete(bucketName string, key string, changeType ChangeType, check func(tx *bolt.Tx) bool, update func(func(*bolt.Tx) error) error) error {
 	if key == "" {
 		return err.New("key cannot be empty")
 	}
 
-	return db.Update(func(tx *bolt.Tx) error {
+	recycle := db.recycleBinEnabled() && !isTrashBucket(bucketName)
+	observed := db.hasObservers(bucketName)
+
+	err := update(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(bucketName))
 		if b == nil {
 			return errors.ErrBucketMissing
 		}
-		return b.Delete([]byte(key))
+		if check != nil && !check(tx) {
+			return nil
+		}
+
+		stored, err := db.removeKey(tx, b, bucketName, key, recycle)
+		if err != nil {
+			return err
+		}
+		if observed && stored != nil {
+			return db.stageChange(tx, bucketName, key, compression.DecompressData(stored), nil, changeType)
+		}
+		return nil
 	})
+	return err
+}
+
+func (db *DB) removeKey(tx *bolt.Tx, b *bolt.Bucket, bucketName, key string, recycle bool) ([]byte, error) {
+	existing := b.Get([]byte(key))
+	stored := db.ColdValue(bucketName, key, existing)
+
+	if recycle {
+		if err := moveToTrash(tx, bucketName, []byte(key), stored); err != nil {
+			return stored, err
+		}
+	}
+	if err := dropVersion(tx, bucketName, key); err != nil {
+		return stored, err
+	}
+	if err := dropExpiry(tx, bucketName, key); err != nil {
+		return stored, err
+	}
+	if err := dropLocation(tx, bucketName, key); err != nil {
+		return stored, err
+	}
+	if err := dropCreated(tx, bucketName, key); err != nil {
+		return stored, err
+	}
+	if err := dropAccess(tx, bucketName, key); err != nil {
+		return stored, err
+	}
+	if existing != nil {
+		if err := db.admit(tx, bucketName, key, existing, nil); err != nil {
+			return stored, err
+		}
+		if err := logChange(tx, bucketName, key, time.Now()); err != nil {
+			return stored, err
+		}
+	}
+	return stored, b.Delete([]byte(key))
 }
 
 func (db *DB) List(bucketName string) ([]string, error) {
 	var keys []string
 
-	err := db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(bucketName))
-		if b == nil {
-			return errors.ErrBucketMissing
-		}
+	err := db.Intercept(OpInfo{Op: OpList, Bucket: bucketName}, func() error {
+		return db.View(func(tx *bolt.Tx) error {
+			b := tx.Bucket([]byte(bucketName))
+			if b == nil {
+				return errors.ErrBucketMissing
+			}
 
-		return b.ForEach(func(k, _ []byte) error {
-			keys = append(keys, string(k))
-			return nil
+			return b.ForEach(func(k, _ []byte) error {
+				keys = append(keys, string(k))
+				return nil
+			})
 		})
 	})
 
@@ -198,29 +645,68 @@ func (db *DB) List(bucketName string) ([]string, error) {
 }
 
 func (db *DB) ForEach(bucketName string, fn func(k, v []byte) error) error {
-	return db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(bucketName))
-		if b == nil {
-			return errors.ErrBucketMissing
-		}
-		return b.ForEach(func(k, v []byte) error {
-			actualData := compression.DecompressData(v)
-			return fn(k, actualData)
+	return db.Intercept(OpInfo{Op: OpForEach, Bucket: bucketName}, func() error {
+		return db.View(func(tx *bolt.Tx) error {
+			b := tx.Bucket([]byte(bucketName))
+			if b == nil {
+				return errors.ErrBucketMissing
+			}
+			return b.ForEach(func(k, v []byte) error {
+				actualData, decompressErr := db.Decompress(bucketName, string(k), db.ColdValue(bucketName, string(k), v))
+				if decompressErr != nil {
+					return decompressErr
+				}
+				return fn(k, actualData)
+			})
 		})
 	})
 }
 
-func (db *DB) Count(bucketName string) (int, error) {
-	var count int
+func (db *DB) Has(bucketName string, key string) (bool, error) {
+	var found bool
+	interceptErr := db.Intercept(OpInfo{Op: OpHas, Bucket: bucketName, Key: key, Keys: 1}, func() error {
+		var hasErr error
+		found, hasErr = db.has(bucketName, key)
+		return hasErr
+	})
+	return found, interceptErr
+}
+
+func (db *DB) has(bucketName string, key string) (bool, error) {
+	if key == "" {
+		return false, err.New("key cannot be empty")
+	}
+	if db.bloomMiss(bucketName, key) {
+		return false, nil
+	}
+
+	var found bool
 	err := db.View(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(bucketName))
 		if b == nil {
 			return errors.ErrBucketMissing
 		}
 
-		count = b.Stats().KeyN
+		k, v := b.Cursor().Seek([]byte(key))
+		found = k != nil && v != nil && string(k) == key
 		return nil
 	})
+	return found, err
+}
+
+func (db *DB) Count(bucketName string) (int, error) {
+	var count int
+	err := db.Intercept(OpInfo{Op: OpCount, Bucket: bucketName}, func() error {
+		return db.View(func(tx *bolt.Tx) error {
+			b := tx.Bucket([]byte(bucketName))
+			if b == nil {
+				return errors.ErrBucketMissing
+			}
+
+			count = b.Stats().KeyN
+			return nil
+		})
+	})
 	return count, err
 }
 
@@ -229,32 +715,68 @@ func (db *DB) Batch(fn func(tx *bolt.Tx) error) error {
 }
 
 func (db *DB) GetAll(bucketName string, constructor func() interface{}) ([]interface{}, error) {
-	count, _ := db.Count(bucketName)
-	items := make([]interface{}, 0, count)
+	_, items, _, getErr := db.GetAllCounted(bucketName, constructor)
+	return items, getErr
+}
 
-	err := db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(bucketName))
-		if b == nil {
-			return errors.ErrBucketMissing
-		}
+func (db *DB) GetAllCounted(bucketName string, constructor func() interface{}) ([]string, []interface{}, ScanCounts, error) {
+	var keys []string
+	var items []interface{}
+	var counts ScanCounts
+	interceptErr := db.Intercept(OpInfo{Op: OpGetAll, Bucket: bucketName}, func() error {
+		var getErr error
+		keys, items, counts, getErr = db.getAll(bucketName, constructor)
+		return getErr
+	})
+	return keys, items, counts, interceptErr
+}
 
-		return b.ForEach(func(_, v []byte) error {
-			if v == nil {
-				return nil
-			}
+func (db *DB) getAll(bucketName string, constructor func() interface{}) ([]string, []interface{}, ScanCounts, error) {
+	count, _ := db.Count(bucketName)
+	defer db.ObserveOp(OpGetAll, bucketName, "", time.Now(), count)
 
-			actualData := compression.DecompressData(v)
+	type decoded struct {
+		seq  int
+		key  string
+		item interface{}
+	}
 
-			item := constructor()
-			if err := js.Unmarshal(actualData, item); err != nil {
-				return nil
+	var mutex sync.Mutex
+	var counts ScanCounts
+	records := make([]decoded, 0, count)
+	scanErr := db.scanParallel(bucketName, func(seq int, k, v []byte) error {
+		key := string(k)
+		item := constructor()
+		decodeErr := db.Decode(bucketName, key, v, item)
+		if decodeErr != nil {
+			if skipErr := db.SkipRecord(bucketName, key, decodeErr); skipErr != nil {
+				return skipErr
 			}
-			items = append(items, item)
+		} else {
+			reflection.SetRecordKey(item, key)
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		counts.Scanned++
+		if decodeErr != nil {
+			counts.skip(decodeErr)
 			return nil
-		})
+		}
+		records = append(records, decoded{seq: seq, key: key, item: item})
+		return nil
 	})
+	if scanErr != nil {
+		return nil, nil, counts, scanErr
+	}
 
-	return items, err
+	sort.Slice(records, func(i, j int) bool { return records[i].seq < records[j].seq })
+	keys := make([]string, len(records))
+	items := make([]interface{}, len(records))
+	for i, record := range records {
+		keys[i], items[i] = record.key, record.item
+	}
+	return keys, items, counts, nil
 }
 
 func (db *DB) GetAllTyped(bucketName string, itemType reflect.Type) (interface{}, error) {
@@ -267,17 +789,16 @@ func (db *DB) GetAllTyped(bucketName string, itemType reflect.Type) (interface{}
 			return errors.ErrBucketMissing
 		}
 
-		return b.ForEach(func(_, v []byte) error {
+		return b.ForEach(func(k, v []byte) error {
 			if v == nil {
 				return nil
 			}
 
-			actualData := compression.DecompressData(v)
-
 			item := reflect.New(itemType).Interface()
-			if err := js.Unmarshal(actualData, item); err != nil {
-				return nil
+			if err := db.Decode(bucketName, string(k), v, item); err != nil {
+				return db.SkipRecord(bucketName, string(k), err)
 			}
+			reflection.SetRecordKey(item, string(k))
 
 			elem := reflect.ValueOf(item).Elem()
 			result = reflect.Append(result, elem)
@@ -289,25 +810,53 @@ func (db *DB) GetAllTyped(bucketName string, itemType reflect.Type) (interface{}
 }
 
 func (db *DB) Clear(bucketName string) error {
-	return db.Update(func(tx *bolt.Tx) error {
+	return db.Intercept(OpInfo{Op: OpClear, Bucket: bucketName}, func() error {
+		return db.clear(bucketName)
+	})
+}
+
+func (db *DB) clear(bucketName string) error {
+	return db.update(func(tx *bolt.Tx) error {
 		if err := tx.DeleteBucket([]byte(bucketName)); err != nil {
 			return fmt.Errorf("delete bucket: %w", err)
 		}
 		if _, err := tx.CreateBucket([]byte(bucketName)); err != nil {
 			return fmt.Errorf("recreate bucket: %w", err)
 		}
-		return nil
+		if tx.Bucket(versionBucketName(bucketName)) != nil {
+			if err := tx.DeleteBucket(versionBucketName(bucketName)); err != nil {
+				return fmt.Errorf("delete versions: %w", err)
+			}
+			if _, err := tx.CreateBucket(versionBucketName(bucketName)); err != nil {
+				return fmt.Errorf("recreate versions: %w", err)
+			}
+		}
+		db.resetQuotaUsage(tx, bucketName)
+		return dropCompanions(tx, bucketName)
 	})
 }
 
 func (db *DB) Backup(filename string) error {
-	return db.View(func(tx *bolt.Tx) error {
-		return tx.CopyFile(filename, 0600)
+	file, openErr := db.options.fs().OpenFile(filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if openErr != nil {
+		return openErr
+	}
+
+	backupErr := db.View(func(tx *bolt.Tx) error {
+		_, writeErr := tx.WriteTo(file)
+		return writeErr
 	})
+	if backupErr == nil {
+		backupErr = file.Sync()
+	}
+	if closeErr := file.Close(); backupErr == nil {
+		backupErr = closeErr
+	}
+	return backupErr
 }
 
 func (db *DB) Stats() bolt.Stats {
-	return db.DB.Stats()
+	return db.handle().Stats()
 }
 
 func (db *DB) Transaction(writable bool, fn func(tx *bolt.Tx) error) error {
@@ -318,13 +867,16 @@ func (db *DB) Transaction(writable bool, fn func(tx *bolt.Tx) error) error {
 }
 
 func (db *DB) Health() error {
+	if identityErr := db.checkFileIdentity(); identityErr != nil {
+		return identityErr
+	}
 	return db.View(func(tx *bolt.Tx) error {
 		return nil
 	})
 }
 
 func (db *DB) GetDiskUsage() (int64, error) {
-	info, err := os.Stat(db.DB.Path())
+	info, err := os.Stat(db.Path())
 	if err != nil {
 		return 0, err
 	}
@@ -332,51 +884,58 @@ func (db *DB) GetDiskUsage() (int64, error) {
 }
 
 func (db *DB) RefreshBuckets() error {
+	db.handleMutex.RLock()
+	defer db.handleMutex.RUnlock()
 	return reflection.FindAndInitBuckets(db.DB, db.name)
 }
 
 func (db *DB) CompressBucket(bucketName string) error {
+	return db.CompressBucketContext(context.Background(), bucketName)
+}
+
+func (db *DB) CompressBucketContext(ctx context.Context, bucketName string) error {
 	if bucketName == "" {
 		return err.New("bucket name cannot be empty")
 	}
 
-	var processed int
-	var compressionErrors []string
+	if !db.CompressionEnabled(bucketName) {
+		return nil
+	}
 
-	err := db.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte(bucketName))
-		if bucket == nil {
-			return errors.ErrBucketMissing
+	total, countErr := db.Count(bucketName)
+	if countErr != nil {
+		return fmt.Errorf("failed to compress bucket '%s': %w", bucketName, countErr)
+	}
+	tracker := db.trackProgress(OpCompress, bucketName, total)
+	defer tracker.finish()
+
+	var processed, rewritten int
+	var after []byte
+	for {
+		if ctx.Err() != nil {
+			logger.Warning("Compression of bucket '%s' cancelled after %d records", bucketName, processed)
+			return fmt.Errorf("compression of bucket '%s' cancelled: %w", bucketName, ctx.Err())
 		}
 
-		return bucket.ForEach(func(k, v []byte) error {
-			if len(v) == 0 {
-				return nil
-			}
-
-			decompressed := compression.DecompressData(v)
-			recompressed := compression.CompressData(decompressed)
-
-			if len(recompressed) < len(v) {
-				if err := bucket.Put(k, recompressed); err != nil {
-					compressionErrors = append(compressionErrors, fmt.Sprintf("key '%s': %v", string(k), err))
-					return nil
-				}
-			}
-
-			processed++
-			return nil
-		})
-	})
-
-	if err != nil {
-		return fmt.Errorf("failed to compress bucket '%s': %w", bucketName, err)
-	}
+		batch, readErr := db.readRaw(bucketName, after, defaultRecompressBatch)
+		if readErr != nil {
+			return fmt.Errorf("failed to compress bucket '%s': %w", bucketName, readErr)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		after = []byte(batch[len(batch)-1].key)
 
-	if len(compressionErrors) > 0 {
-		return fmt.Errorf("compression completed with %d errors: %s", len(compressionErrors), strings.Join(compressionErrors, "; "))
+		n, _, writeErr := db.recompressBatch(bucketName, batch, false)
+		if writeErr != nil {
+			tracker.step(0, true)
+			return fmt.Errorf("failed to compress bucket '%s': %w", bucketName, writeErr)
+		}
+		processed += len(batch)
+		rewritten += n
+		tracker.step(len(batch), false)
 	}
 
-	logger.Success("Compressed bucket '%s': %d records processed", bucketName, processed)
+	logger.Success("Compressed bucket '%s': %d records processed, %d rewritten", bucketName, processed, rewritten)
 	return nil
 }
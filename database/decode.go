@@ -0,0 +1,142 @@
+package database
+
+import (
+	"bytes"
+	err "errors"
+	"fmt"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/compression"
+	"github.com/andr1ww/odin/internal/pool"
+)
+
+var decodeBufferPool = pool.New("database.decode", func() *bytes.Buffer {
+	return &bytes.Buffer{}
+}, func(buf *bytes.Buffer) int {
+	return buf.Cap()
+})
+
+type DecodeError struct {
+	Database string
+	Bucket   string
+	Key      string
+	Corrupt  bool
+	Err      error
+}
+
+func (e *DecodeError) Error() string {
+	kind := "decode failed"
+	if e.Corrupt {
+		kind = "corrupt value"
+	}
+	return fmt.Sprintf("%s for key '%s' of bucket '%s': %v", kind, e.Key, e.Bucket, e.Err)
+}
+
+func (e *DecodeError) Unwrap() []error {
+	if e.Corrupt {
+		return []error{errors.ErrCorruptValue, e.Err}
+	}
+	return []error{errors.ErrDecodeFailed, e.Err}
+}
+
+func WithStrictDecoding(strict bool) Option {
+	return func(o *Options) {
+		o.StrictDecoding = strict
+	}
+}
+
+func WithSkipHandler(handler func(*DecodeError)) Option {
+	return func(o *Options) {
+		o.OnSkip = handler
+	}
+}
+
+func (db *DB) StrictDecoding() bool {
+	return db.options.StrictDecoding
+}
+
+func (db *DB) Decompress(bucketName, key string, raw []byte) ([]byte, error) {
+	if !db.options.StrictDecoding {
+		return compression.DecompressData(raw), nil
+	}
+	data, decompressErr := compression.Decompress(raw)
+	if decompressErr != nil {
+		return nil, &DecodeError{Database: db.name, Bucket: bucketName, Key: key, Corrupt: true, Err: decompressErr}
+	}
+	return data, nil
+}
+
+func WithPooledDecoding(pooled bool) Option {
+	return func(o *Options) {
+		o.PooledDecoding = pooled
+	}
+}
+
+func (db *DB) decompressPooled(bucketName, key string, raw []byte) ([]byte, *bytes.Buffer, error) {
+	if !db.options.PooledDecoding {
+		data, decompressErr := db.Decompress(bucketName, key, raw)
+		return data, nil, decompressErr
+	}
+
+	buf := decodeBufferPool.Get()
+	data, decompressErr := compression.DecompressInto(buf, raw)
+	if decompressErr == nil {
+		return data, buf, nil
+	}
+
+	releaseDecodeBuffer(buf)
+	data, decompressErr = db.Decompress(bucketName, key, raw)
+	return data, nil, decompressErr
+}
+
+func releaseDecodeBuffer(buf *bytes.Buffer) {
+	if buf != nil {
+		buf.Reset()
+		decodeBufferPool.Put(buf)
+	}
+}
+
+func (db *DB) Decode(bucketName, key string, raw []byte, target interface{}) error {
+	raw = db.ColdValue(bucketName, key, raw)
+	data, buf, decompressErr := db.decompressPooled(bucketName, key, raw)
+	if decompressErr != nil {
+		return decompressErr
+	}
+	defer releaseDecodeBuffer(buf)
+	if unmarshalErr := js.Unmarshal(data, target); unmarshalErr != nil {
+		if _, corruptErr := compression.Decompress(raw); corruptErr != nil {
+			return &DecodeError{Database: db.name, Bucket: bucketName, Key: key, Corrupt: true, Err: corruptErr}
+		}
+		return &DecodeError{Database: db.name, Bucket: bucketName, Key: key, Err: unmarshalErr}
+	}
+	return nil
+}
+
+func (db *DB) SkipRecord(bucketName, key string, cause error) error {
+	var decodeErr *DecodeError
+	if !err.As(cause, &decodeErr) {
+		decodeErr = &DecodeError{Database: db.name, Bucket: bucketName, Key: key, Err: cause}
+	}
+	if db.options.StrictDecoding {
+		return decodeErr
+	}
+	db.quarantineRecord(decodeErr)
+	if db.options.OnSkip != nil {
+		db.options.OnSkip(decodeErr)
+	}
+	return nil
+}
+
+type ScanCounts struct {
+	Scanned int
+	Skipped int
+	Corrupt int
+}
+
+func (c *ScanCounts) skip(cause error) {
+	c.Skipped++
+	var decodeErr *DecodeError
+	if err.As(cause, &decodeErr) && decodeErr.Corrupt {
+		c.Corrupt++
+	}
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	err "errors"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/reflection"
+	bolt "go.etcd.io/bbolt"
+)
+
+type Document = map[string]interface{}
+
+func (db *DB) PutDoc(bucketName string, key string, doc Document) error {
+	if doc == nil {
+		return errors.ErrNilValue
+	}
+	return db.Put(bucketName, key, doc)
+}
+
+func (db *DB) GetDoc(bucketName string, key string) (Document, error) {
+	if key == "" {
+		return nil, err.New("key cannot be empty")
+	}
+
+	var doc Document
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrBucketMissing
+		}
+
+		data := b.Get([]byte(key))
+		if data == nil {
+			return errors.ErrNotFound
+		}
+		if len(data) == 0 {
+			return errors.ErrInvalidData
+		}
+
+		return db.Decode(bucketName, key, data, &doc)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if doc == nil {
+		return nil, errors.ErrInvalidData
+	}
+	return doc, nil
+}
+
+func (db *DB) GetAllDocs(bucketName string) ([]Document, error) {
+	return db.FindDocs(bucketName, nil)
+}
+
+func (db *DB) FindDocs(bucketName string, criteria map[string]interface{}) ([]Document, error) {
+	criteria, err := reflection.CompileFilter(criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []Document
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrBucketMissing
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			if len(v) == 0 {
+				return nil
+			}
+
+			var doc Document
+			if err := db.Decode(bucketName, string(k), v, &doc); err != nil {
+				return db.SkipRecord(bucketName, string(k), err)
+			}
+			if doc == nil {
+				return nil
+			}
+
+			if reflection.MatchesDocument(doc, criteria) {
+				docs = append(docs, doc)
+			}
+			return nil
+		})
+	})
+	return docs, err
+}
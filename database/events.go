@@ -0,0 +1,119 @@
+package database
+
+import (
+	"sync"
+
+	"github.com/andr1ww/odin/internal/reflection"
+)
+
+type managerEvents struct {
+	mutex          sync.RWMutex
+	connect        []func(name string, db *DB)
+	restore        []func(name string, db *DB)
+	reopen         []func(name string, db *DB)
+	unhealthy      []func(name string, err error)
+	close          []func(name string)
+	defaultChanged []func(previous, current string)
+}
+
+func OnConnect(hook func(name string, db *DB)) {
+	manager.events.mutex.Lock()
+	manager.events.connect = append(manager.events.connect, hook)
+	manager.events.mutex.Unlock()
+
+	for name, db := range GetAll() {
+		hook(name, db)
+	}
+}
+
+func OnRestore(hook func(name string, db *DB)) {
+	manager.events.mutex.Lock()
+	defer manager.events.mutex.Unlock()
+	manager.events.restore = append(manager.events.restore, hook)
+}
+
+func OnReopen(hook func(name string, db *DB)) {
+	manager.events.mutex.Lock()
+	defer manager.events.mutex.Unlock()
+	manager.events.reopen = append(manager.events.reopen, hook)
+}
+
+func OnUnhealthy(hook func(name string, err error)) {
+	manager.events.mutex.Lock()
+	defer manager.events.mutex.Unlock()
+	manager.events.unhealthy = append(manager.events.unhealthy, hook)
+}
+
+func OnClose(hook func(name string)) {
+	manager.events.mutex.Lock()
+	defer manager.events.mutex.Unlock()
+	manager.events.close = append(manager.events.close, hook)
+}
+
+func OnDefaultChanged(hook func(previous, current string)) {
+	manager.events.mutex.Lock()
+	defer manager.events.mutex.Unlock()
+	manager.events.defaultChanged = append(manager.events.defaultChanged, hook)
+}
+
+func emitConnect(name string, db *DB) {
+	manager.events.mutex.RLock()
+	hooks := append(([]func(string, *DB))(nil), manager.events.connect...)
+	manager.events.mutex.RUnlock()
+
+	for _, hook := range hooks {
+		hook(name, db)
+	}
+}
+
+func emitRestore(name string, db *DB) {
+	manager.events.mutex.RLock()
+	hooks := append(([]func(string, *DB))(nil), manager.events.restore...)
+	manager.events.mutex.RUnlock()
+
+	for _, hook := range hooks {
+		hook(name, db)
+	}
+}
+
+func emitReopen(name string, db *DB) {
+	manager.events.mutex.RLock()
+	hooks := append(([]func(string, *DB))(nil), manager.events.reopen...)
+	manager.events.mutex.RUnlock()
+
+	for _, hook := range hooks {
+		hook(name, db)
+	}
+}
+
+func emitUnhealthy(name string, unhealthyErr error) {
+	manager.events.mutex.RLock()
+	hooks := append(([]func(string, error))(nil), manager.events.unhealthy...)
+	manager.events.mutex.RUnlock()
+
+	for _, hook := range hooks {
+		hook(name, unhealthyErr)
+	}
+}
+
+func emitClose(name string) {
+	manager.events.mutex.RLock()
+	hooks := append(([]func(string))(nil), manager.events.close...)
+	manager.events.mutex.RUnlock()
+
+	for _, hook := range hooks {
+		hook(name)
+	}
+}
+
+func emitDefaultChanged(previous, current string) {
+	reflection.SetDefaultDatabase(current)
+
+	manager.events.mutex.RLock()
+	hooks := append(([]func(string, string))(nil), manager.events.defaultChanged...)
+	manager.events.mutex.RUnlock()
+
+	for _, hook := range hooks {
+		hook(previous, current)
+	}
+}
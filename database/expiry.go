@@ -0,0 +1,241 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"time"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/keys"
+	"github.com/andr1ww/odin/internal/logger"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	expiryPrefix     = "__expire_"
+	maxExpiryWait    = time.Minute
+	expirySweepBatch = 256
+)
+
+var (
+	expiryQueue = []byte("queue")
+	expiryKeys  = []byte("keys")
+)
+
+func expiryBucketName(bucketName string) []byte {
+	return []byte(expiryPrefix + bucketName)
+}
+
+func (db *DB) SetExpiry(bucketName, key string, at time.Time) error {
+	if at.IsZero() {
+		return db.ClearExpiry(bucketName, key)
+	}
+
+	err := db.update(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(bucketName)) == nil {
+			return errors.ErrBucketMissing
+		}
+
+		root, err := tx.CreateBucketIfNotExists(expiryBucketName(bucketName))
+		if err != nil {
+			return err
+		}
+		queue, err := root.CreateBucketIfNotExists(expiryQueue)
+		if err != nil {
+			return err
+		}
+		byKey, err := root.CreateBucketIfNotExists(expiryKeys)
+		if err != nil {
+			return err
+		}
+
+		if previous := byKey.Get([]byte(key)); previous != nil {
+			if err := queue.Delete(keys.TimeKey(keys.DecodeTime(previous), []byte(key))); err != nil {
+				return err
+			}
+		}
+		if err := queue.Put(keys.TimeKey(at, []byte(key)), nil); err != nil {
+			return err
+		}
+		return byKey.Put([]byte(key), keys.EncodeTime(at))
+	})
+	if err != nil {
+		return err
+	}
+
+	db.ensureExpirySweeper()
+	return nil
+}
+
+func (db *DB) ClearExpiry(bucketName, key string) error {
+	return db.update(func(tx *bolt.Tx) error {
+		return dropExpiry(tx, bucketName, key)
+	})
+}
+
+func (db *DB) Expiry(bucketName, key string) (time.Time, bool, error) {
+	var at time.Time
+	found := false
+
+	err := db.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket(expiryBucketName(bucketName))
+		if root == nil {
+			return nil
+		}
+		if data := root.Bucket(expiryKeys).Get([]byte(key)); data != nil {
+			at, found = keys.DecodeTime(data), true
+		}
+		return nil
+	})
+	return at, found, err
+}
+
+func dropExpiry(tx *bolt.Tx, bucketName, key string) error {
+	root := tx.Bucket(expiryBucketName(bucketName))
+	if root == nil {
+		return nil
+	}
+
+	byKey := root.Bucket(expiryKeys)
+	previous := byKey.Get([]byte(key))
+	if previous == nil {
+		return nil
+	}
+
+	if err := root.Bucket(expiryQueue).Delete(keys.TimeKey(keys.DecodeTime(previous), []byte(key))); err != nil {
+		return err
+	}
+	return byKey.Delete([]byte(key))
+}
+
+func (db *DB) resumeExpiry() {
+	if db.rejectsWrites() {
+		return
+	}
+
+	pending := false
+	db.View(func(tx *bolt.Tx) error {
+		c := tx.Cursor()
+		name, _ := c.Seek([]byte(expiryPrefix))
+		pending = name != nil && bytes.HasPrefix(name, []byte(expiryPrefix))
+		return nil
+	})
+	if pending {
+		db.ensureExpirySweeper()
+	}
+}
+
+func (db *DB) ensureExpirySweeper() {
+	db.expiryMutex.Lock()
+	defer db.expiryMutex.Unlock()
+
+	if db.expiryWake != nil {
+		select {
+		case db.expiryWake <- struct{}{}:
+		default:
+		}
+		return
+	}
+
+	wake := make(chan struct{}, 1)
+	if db.goBackground(func() { db.runExpirySweeper(wake) }) {
+		db.expiryWake = wake
+	}
+}
+
+func (db *DB) runExpirySweeper(wake chan struct{}) {
+	for {
+		if db.YieldToForeground(context.Background(), JobExpiry) != nil {
+			return
+		}
+		next, err := db.SweepExpired()
+		if err != nil {
+			logger.Error("expiry sweep on database '%s' failed: %v", db.name, err)
+		}
+
+		wait := maxExpiryWait
+		if !next.IsZero() {
+			if until := time.Until(next); until < wait {
+				wait = until
+			}
+		}
+		if wait < 0 {
+			wait = 0
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-wake:
+			timer.Stop()
+		case <-db.done:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+type expiredEntry struct {
+	bucket string
+	key    string
+	at     []byte
+}
+
+func (db *DB) SweepExpired() (time.Time, error) {
+	now := time.Now()
+
+	var due []expiredEntry
+	var next time.Time
+
+	err := db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, root *bolt.Bucket) error {
+			if !bytes.HasPrefix(name, []byte(expiryPrefix)) {
+				return nil
+			}
+			bucketName := strings.TrimPrefix(string(name), expiryPrefix)
+
+			queue := root.Bucket(expiryQueue)
+			if queue == nil {
+				return nil
+			}
+
+			c := queue.Cursor()
+			for k, _ := c.First(); k != nil; k, _ = c.Next() {
+				at, key := keys.SplitTimeKey(k)
+				if at.After(now) {
+					if next.IsZero() || at.Before(next) {
+						next = at
+					}
+					break
+				}
+				if len(due) >= expirySweepBatch {
+					next = now
+					break
+				}
+				due = append(due, expiredEntry{bucket: bucketName, key: string(key), at: keys.EncodeTime(at)})
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	for _, entry := range due {
+		entry := entry
+		err := db.delete(entry.bucket, entry.key, ChangeExpire, func(tx *bolt.Tx) bool {
+			root := tx.Bucket(expiryBucketName(entry.bucket))
+			return root != nil && bytes.Equal(root.Bucket(expiryKeys).Get([]byte(entry.key)), entry.at)
+		}, db.Update)
+		if err != nil && err != errors.ErrBucketMissing {
+			return next, err
+		}
+		if err == errors.ErrBucketMissing {
+			db.update(func(tx *bolt.Tx) error {
+				return dropExpiry(tx, entry.bucket, entry.key)
+			})
+		}
+	}
+	return next, nil
+}
@@ -0,0 +1,162 @@
+package database
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/geo"
+	bolt "go.etcd.io/bbolt"
+)
+
+const geoPrefix = "__geo_"
+
+var (
+	geoCells = []byte("cells")
+	geoKeys  = []byte("keys")
+)
+
+type GeoHit struct {
+	Key      string
+	Lat      float64
+	Lng      float64
+	Distance float64
+}
+
+func geoBucketName(bucketName string) []byte {
+	return []byte(geoPrefix + bucketName)
+}
+
+func encodePoint(lat, lng float64) []byte {
+	data := make([]byte, 16)
+	binary.BigEndian.PutUint64(data[:8], math.Float64bits(lat))
+	binary.BigEndian.PutUint64(data[8:], math.Float64bits(lng))
+	return data
+}
+
+func decodePoint(data []byte) (float64, float64) {
+	return math.Float64frombits(binary.BigEndian.Uint64(data[:8])),
+		math.Float64frombits(binary.BigEndian.Uint64(data[8:16]))
+}
+
+func geoCellKey(hash, key string) []byte {
+	cell := make([]byte, 0, len(hash)+len(key)+1)
+	cell = append(cell, hash...)
+	cell = append(cell, 0)
+	return append(cell, key...)
+}
+
+func (db *DB) SetLocation(bucketName, key string, lat, lng float64) error {
+	if !geo.Valid(lat, lng) {
+		return fmt.Errorf("invalid coordinates %f,%f", lat, lng)
+	}
+
+	return db.update(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(bucketName)) == nil {
+			return errors.ErrBucketMissing
+		}
+
+		root, err := tx.CreateBucketIfNotExists(geoBucketName(bucketName))
+		if err != nil {
+			return err
+		}
+		cells, err := root.CreateBucketIfNotExists(geoCells)
+		if err != nil {
+			return err
+		}
+		if _, err := root.CreateBucketIfNotExists(geoKeys); err != nil {
+			return err
+		}
+
+		if err := dropLocation(tx, bucketName, key); err != nil {
+			return err
+		}
+
+		hash := geo.Encode(lat, lng, geo.MaxPrecision)
+		point := encodePoint(lat, lng)
+		if err := cells.Put(geoCellKey(hash, key), point); err != nil {
+			return err
+		}
+		return root.Bucket(geoKeys).Put([]byte(key), append([]byte(hash), point...))
+	})
+}
+
+func (db *DB) ClearLocation(bucketName, key string) error {
+	return db.update(func(tx *bolt.Tx) error {
+		return dropLocation(tx, bucketName, key)
+	})
+}
+
+func dropLocation(tx *bolt.Tx, bucketName, key string) error {
+	root := tx.Bucket(geoBucketName(bucketName))
+	if root == nil {
+		return nil
+	}
+
+	byKey := root.Bucket(geoKeys)
+	previous := byKey.Get([]byte(key))
+	if previous == nil {
+		return nil
+	}
+
+	hash := string(previous[:len(previous)-16])
+	if err := root.Bucket(geoCells).Delete(geoCellKey(hash, key)); err != nil {
+		return err
+	}
+	return byKey.Delete([]byte(key))
+}
+
+func (db *DB) Near(bucketName string, lat, lng, radius float64) ([]GeoHit, error) {
+	if !geo.Valid(lat, lng) {
+		return nil, fmt.Errorf("invalid coordinates %f,%f", lat, lng)
+	}
+	if radius <= 0 {
+		return nil, fmt.Errorf("radius must be positive")
+	}
+
+	var hits []GeoHit
+	err := db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(bucketName)) == nil {
+			return errors.ErrBucketMissing
+		}
+
+		root := tx.Bucket(geoBucketName(bucketName))
+		if root == nil {
+			return nil
+		}
+
+		seen := make(map[string]bool)
+		c := root.Bucket(geoCells).Cursor()
+		for _, prefix := range geo.Cover(lat, lng, radius) {
+			p := []byte(prefix)
+			for k, v := c.Seek(p); k != nil && bytes.HasPrefix(k, p); k, v = c.Next() {
+				sep := bytes.IndexByte(k, 0)
+				if sep < 0 {
+					continue
+				}
+				key := string(k[sep+1:])
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+
+				pLat, pLng := decodePoint(v)
+				if distance := geo.Distance(lat, lng, pLat, pLng); distance <= radius {
+					hits = append(hits, GeoHit{Key: key, Lat: pLat, Lng: pLng, Distance: distance})
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		return hits[i].Distance < hits[j].Distance
+	})
+	return hits, nil
+}
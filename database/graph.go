@@ -0,0 +1,321 @@
+package database
+
+import (
+	"bytes"
+	err "errors"
+	"strings"
+	"time"
+
+	"github.com/andr1ww/odin/internal/keys"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	graphPrefix = "__graph_"
+
+	defaultTraversalDepth = 3
+	defaultTraversalLimit = 1000
+)
+
+var (
+	graphOut = []byte("o")
+	graphIn  = []byte("i")
+)
+
+type Direction int
+
+const (
+	DirectionOut Direction = iota
+	DirectionIn
+	DirectionBoth
+)
+
+type TraversalOrder int
+
+const (
+	BreadthFirst TraversalOrder = iota
+	DepthFirst
+)
+
+type Edge struct {
+	From    string    `json:"from"`
+	Rel     string    `json:"rel"`
+	To      string    `json:"to"`
+	Created time.Time `json:"created"`
+}
+
+type Visit struct {
+	Node   string `json:"node"`
+	Depth  int    `json:"depth"`
+	Parent string `json:"parent"`
+	Rel    string `json:"rel"`
+}
+
+type TraverseOptions struct {
+	Rel       string
+	Direction Direction
+	Order     TraversalOrder
+	MaxDepth  int
+	Limit     int
+}
+
+type Graph struct {
+	db     *DB
+	name   string
+	bucket []byte
+}
+
+func (db *DB) Graph(name string) (*Graph, error) {
+	if name == "" {
+		return nil, err.New("graph name cannot be empty")
+	}
+
+	bucketName := graphPrefix + name
+	if err := db.CreateBucket(bucketName); err != nil {
+		return nil, err
+	}
+
+	return &Graph{db: db, name: name, bucket: []byte(bucketName)}, nil
+}
+
+func (g *Graph) Name() string {
+	return g.name
+}
+
+func edgeKey(side []byte, node, rel, other string) []byte {
+	key := make([]byte, 0, len(side)+len(node)+len(rel)+len(other)+3)
+	key = append(key, side...)
+	key = append(key, 0)
+	key = append(key, node...)
+	key = append(key, 0)
+	key = append(key, rel...)
+	key = append(key, 0)
+	return append(key, other...)
+}
+
+func edgePrefix(side []byte, node, rel string) []byte {
+	key := make([]byte, 0, len(side)+len(node)+len(rel)+3)
+	key = append(key, side...)
+	key = append(key, 0)
+	key = append(key, node...)
+	key = append(key, 0)
+	if rel == "" {
+		return key
+	}
+	key = append(key, rel...)
+	return append(key, 0)
+}
+
+func validEdge(from, rel, to string) error {
+	if from == "" || rel == "" || to == "" {
+		return err.New("edge node and relation cannot be empty")
+	}
+	if strings.IndexByte(from, 0) >= 0 || strings.IndexByte(rel, 0) >= 0 || strings.IndexByte(to, 0) >= 0 {
+		return err.New("edge node and relation cannot contain NUL bytes")
+	}
+	return nil
+}
+
+func (g *Graph) AddEdge(from, rel, to string) error {
+	if err := validEdge(from, rel, to); err != nil {
+		return err
+	}
+
+	stamp := keys.EncodeTime(time.Now())
+	return g.db.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(g.bucket)
+		if err := b.Put(edgeKey(graphOut, from, rel, to), stamp); err != nil {
+			return err
+		}
+		return b.Put(edgeKey(graphIn, to, rel, from), stamp)
+	})
+}
+
+func (g *Graph) RemoveEdge(from, rel, to string) error {
+	return g.db.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(g.bucket)
+		if err := b.Delete(edgeKey(graphOut, from, rel, to)); err != nil {
+			return err
+		}
+		return b.Delete(edgeKey(graphIn, to, rel, from))
+	})
+}
+
+func (g *Graph) HasEdge(from, rel, to string) (bool, error) {
+	found := false
+	err := g.db.View(func(tx *bolt.Tx) error {
+		found = tx.Bucket(g.bucket).Get(edgeKey(graphOut, from, rel, to)) != nil
+		return nil
+	})
+	return found, err
+}
+
+func (g *Graph) RemoveNode(node string) error {
+	return g.db.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(g.bucket)
+		for _, edge := range scanEdges(b, graphOut, node, "") {
+			if err := b.Delete(edgeKey(graphOut, edge.From, edge.Rel, edge.To)); err != nil {
+				return err
+			}
+			if err := b.Delete(edgeKey(graphIn, edge.To, edge.Rel, edge.From)); err != nil {
+				return err
+			}
+		}
+		for _, edge := range scanEdges(b, graphIn, node, "") {
+			if err := b.Delete(edgeKey(graphIn, edge.To, edge.Rel, edge.From)); err != nil {
+				return err
+			}
+			if err := b.Delete(edgeKey(graphOut, edge.From, edge.Rel, edge.To)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (g *Graph) Edges(node string, direction Direction) ([]Edge, error) {
+	var edges []Edge
+	err := g.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(g.bucket)
+		if direction != DirectionIn {
+			edges = append(edges, scanEdges(b, graphOut, node, "")...)
+		}
+		if direction != DirectionOut {
+			edges = append(edges, scanEdges(b, graphIn, node, "")...)
+		}
+		return nil
+	})
+	return edges, err
+}
+
+func (g *Graph) Neighbors(node, rel string) ([]string, error) {
+	return g.neighbors(node, rel, DirectionOut)
+}
+
+func (g *Graph) InNeighbors(node, rel string) ([]string, error) {
+	return g.neighbors(node, rel, DirectionIn)
+}
+
+func (g *Graph) neighbors(node, rel string, direction Direction) ([]string, error) {
+	var nodes []string
+	err := g.db.View(func(tx *bolt.Tx) error {
+		for _, step := range adjacent(tx.Bucket(g.bucket), node, rel, direction) {
+			nodes = append(nodes, step.node)
+		}
+		return nil
+	})
+	return nodes, err
+}
+
+func (g *Graph) Traverse(start string, opts TraverseOptions) ([]Visit, error) {
+	if start == "" {
+		return nil, err.New("start node cannot be empty")
+	}
+
+	maxDepth := opts.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = defaultTraversalDepth
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultTraversalLimit
+	}
+
+	var visits []Visit
+	err := g.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(g.bucket)
+		seen := map[string]bool{start: true}
+		pending := []Visit{{Node: start}}
+
+		for len(pending) > 0 && len(visits) < limit {
+			var current Visit
+			if opts.Order == DepthFirst {
+				current, pending = pending[len(pending)-1], pending[:len(pending)-1]
+			} else {
+				current, pending = pending[0], pending[1:]
+			}
+
+			if current.Node != start {
+				if seen[current.Node] {
+					continue
+				}
+				seen[current.Node] = true
+				visits = append(visits, current)
+			}
+			if current.Depth >= maxDepth {
+				continue
+			}
+
+			next := adjacent(b, current.Node, opts.Rel, opts.Direction)
+			if opts.Order == DepthFirst {
+				for i := len(next) - 1; i >= 0; i-- {
+					if !seen[next[i].node] {
+						pending = append(pending, Visit{Node: next[i].node, Depth: current.Depth + 1, Parent: current.Node, Rel: next[i].rel})
+					}
+				}
+				continue
+			}
+			for _, step := range next {
+				if !seen[step.node] {
+					pending = append(pending, Visit{Node: step.node, Depth: current.Depth + 1, Parent: current.Node, Rel: step.rel})
+				}
+			}
+		}
+		return nil
+	})
+	return visits, err
+}
+
+type graphStep struct {
+	node string
+	rel  string
+}
+
+func adjacent(b *bolt.Bucket, node, rel string, direction Direction) []graphStep {
+	var steps []graphStep
+	added := make(map[string]bool)
+	collect := func(side []byte) {
+		for _, edge := range scanEdges(b, side, node, rel) {
+			other := edge.To
+			if bytes.Equal(side, graphIn) {
+				other = edge.From
+			}
+			if !added[other] {
+				added[other] = true
+				steps = append(steps, graphStep{node: other, rel: edge.Rel})
+			}
+		}
+	}
+
+	if direction != DirectionIn {
+		collect(graphOut)
+	}
+	if direction != DirectionOut {
+		collect(graphIn)
+	}
+	return steps
+}
+
+func scanEdges(b *bolt.Bucket, side []byte, node, rel string) []Edge {
+	prefix := edgePrefix(side, node, rel)
+	base := len(side) + len(node) + 2
+
+	var edges []Edge
+	c := b.Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		rest := k[base:]
+		sep := bytes.IndexByte(rest, 0)
+		if sep < 0 {
+			continue
+		}
+
+		edgeRel, other := string(rest[:sep]), string(rest[sep+1:])
+		created := keys.DecodeTime(v)
+		if bytes.Equal(side, graphOut) {
+			edges = append(edges, Edge{From: node, Rel: edgeRel, To: other, Created: created})
+		} else {
+			edges = append(edges, Edge{From: other, Rel: edgeRel, To: node, Created: created})
+		}
+	}
+	return edges
+}
@@ -0,0 +1,26 @@
+package database
+
+import (
+	"github.com/andr1ww/odin/internal/indexing"
+	"github.com/andr1ww/odin/internal/logger"
+)
+
+func (db *DB) EnableDiskIndex(bucketName string, cacheEntries int) error {
+	return indexing.EnableDiskIndex(db.name, db.handle, bucketName, cacheEntries)
+}
+
+func (db *DB) DisableDiskIndex(bucketName string) {
+	indexing.DisableDiskIndex(bucketName)
+}
+
+func (db *DB) closeHandle() error {
+	indexing.DisableDiskIndexes(db.name)
+	db.closeWatchers()
+	db.closeSubscriptions()
+	if err := db.saveBloomFilters(); err != nil {
+		logger.Warning("failed to persist bloom filters of database '%s': %v", db.name, err)
+	}
+	db.handleMutex.Lock()
+	defer db.handleMutex.Unlock()
+	return db.DB.Close()
+}
@@ -0,0 +1,82 @@
+package database
+
+const (
+	OpGet          = "get"
+	OpHas          = "has"
+	OpPut          = "put"
+	OpPutMany      = "put_many"
+	OpDelete       = "delete"
+	OpGetAll       = "get_all"
+	OpFindWhere    = "find_where"
+	OpAtomic       = "atomic"
+	OpUpdate       = "update"
+	OpMerge        = "merge"
+	OpPatch        = "patch"
+	OpPutIf        = "put_if"
+	OpForEach      = "for_each"
+	OpList         = "list"
+	OpCount        = "count"
+	OpClear        = "clear"
+	OpCreateBucket = "create_bucket"
+	OpDeleteBucket = "delete_bucket"
+)
+
+type OpInfo struct {
+	Database string
+	Op       string
+	Bucket   string
+	Key      string
+	Keys     int
+	Criteria map[string]interface{}
+}
+
+type Interceptor func(op OpInfo, next func() error) error
+
+func WithInterceptor(interceptor Interceptor) Option {
+	return func(o *Options) {
+		o.Interceptors = append(o.Interceptors, interceptor)
+	}
+}
+
+func (db *DB) Use(interceptors ...Interceptor) {
+	db.configured.Store(true)
+	db.use(interceptors...)
+}
+
+func (db *DB) use(interceptors ...Interceptor) {
+	db.interceptMutex.Lock()
+	defer db.interceptMutex.Unlock()
+
+	chain := make([]Interceptor, 0, len(db.interceptors)+len(interceptors))
+	chain = append(chain, db.interceptors...)
+	for _, interceptor := range interceptors {
+		if interceptor != nil {
+			chain = append(chain, interceptor)
+		}
+	}
+	db.interceptors = chain
+}
+
+func (db *DB) ClearInterceptors() {
+	db.interceptMutex.Lock()
+	defer db.interceptMutex.Unlock()
+	db.interceptors = nil
+}
+
+func (db *DB) Intercept(op OpInfo, fn func() error) error {
+	db.interceptMutex.RLock()
+	chain := db.interceptors
+	db.interceptMutex.RUnlock()
+
+	if len(chain) == 0 {
+		return fn()
+	}
+
+	op.Database = db.name
+	next := fn
+	for i := len(chain) - 1; i >= 0; i-- {
+		interceptor, inner := chain[i], next
+		next = func() error { return interceptor(op, inner) }
+	}
+	return next()
+}
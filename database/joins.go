@@ -0,0 +1,197 @@
+package database
+
+import (
+	"bytes"
+	err "errors"
+	"time"
+
+	"github.com/andr1ww/odin/internal/keys"
+	bolt "go.etcd.io/bbolt"
+)
+
+const joinPrefix = "__join_"
+
+var (
+	joinLeft  = []byte("l")
+	joinRight = []byte("r")
+)
+
+type JoinTable struct {
+	db     *DB
+	name   string
+	bucket []byte
+}
+
+func (db *DB) JoinTable(name string) (*JoinTable, error) {
+	if name == "" {
+		return nil, err.New("join table name cannot be empty")
+	}
+
+	bucketName := joinPrefix + name
+	if err := db.CreateBucket(bucketName); err != nil {
+		return nil, err
+	}
+
+	return &JoinTable{db: db, name: name, bucket: []byte(bucketName)}, nil
+}
+
+func (jt *JoinTable) Name() string {
+	return jt.name
+}
+
+func joinKey(side []byte, from, to string) []byte {
+	key := make([]byte, 0, len(side)+len(from)+len(to)+2)
+	key = append(key, side...)
+	key = append(key, 0)
+	key = append(key, from...)
+	key = append(key, 0)
+	return append(key, to...)
+}
+
+func joinPrefixKey(side []byte, from string) []byte {
+	key := make([]byte, 0, len(side)+len(from)+2)
+	key = append(key, side...)
+	key = append(key, 0)
+	key = append(key, from...)
+	return append(key, 0)
+}
+
+func (jt *JoinTable) Attach(leftID string, rightIDs ...string) error {
+	if leftID == "" {
+		return err.New("key cannot be empty")
+	}
+
+	stamp := keys.EncodeTime(time.Now())
+	return jt.db.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(jt.bucket)
+		for _, rightID := range rightIDs {
+			if rightID == "" {
+				return err.New("key cannot be empty")
+			}
+			if err := b.Put(joinKey(joinLeft, leftID, rightID), stamp); err != nil {
+				return err
+			}
+			if err := b.Put(joinKey(joinRight, rightID, leftID), stamp); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (jt *JoinTable) Detach(leftID string, rightIDs ...string) error {
+	return jt.db.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(jt.bucket)
+		for _, rightID := range rightIDs {
+			if err := b.Delete(joinKey(joinLeft, leftID, rightID)); err != nil {
+				return err
+			}
+			if err := b.Delete(joinKey(joinRight, rightID, leftID)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (jt *JoinTable) Sync(leftID string, rightIDs []string) error {
+	if leftID == "" {
+		return err.New("key cannot be empty")
+	}
+
+	want := make(map[string]bool, len(rightIDs))
+	for _, rightID := range rightIDs {
+		if rightID == "" {
+			return err.New("key cannot be empty")
+		}
+		want[rightID] = true
+	}
+
+	stamp := keys.EncodeTime(time.Now())
+	return jt.db.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(jt.bucket)
+
+		for _, rightID := range scanJoin(b, joinLeft, leftID) {
+			if want[rightID] {
+				delete(want, rightID)
+				continue
+			}
+			if err := b.Delete(joinKey(joinLeft, leftID, rightID)); err != nil {
+				return err
+			}
+			if err := b.Delete(joinKey(joinRight, rightID, leftID)); err != nil {
+				return err
+			}
+		}
+
+		for rightID := range want {
+			if err := b.Put(joinKey(joinLeft, leftID, rightID), stamp); err != nil {
+				return err
+			}
+			if err := b.Put(joinKey(joinRight, rightID, leftID), stamp); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (jt *JoinTable) DetachLeft(leftID string) error {
+	return jt.detachAll(joinLeft, joinRight, leftID)
+}
+
+func (jt *JoinTable) DetachRight(rightID string) error {
+	return jt.detachAll(joinRight, joinLeft, rightID)
+}
+
+func (jt *JoinTable) detachAll(side, other []byte, id string) error {
+	return jt.db.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(jt.bucket)
+		for _, related := range scanJoin(b, side, id) {
+			if err := b.Delete(joinKey(side, id, related)); err != nil {
+				return err
+			}
+			if err := b.Delete(joinKey(other, related, id)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (jt *JoinTable) RightIDs(leftID string) ([]string, error) {
+	return jt.related(joinLeft, leftID)
+}
+
+func (jt *JoinTable) LeftIDs(rightID string) ([]string, error) {
+	return jt.related(joinRight, rightID)
+}
+
+func (jt *JoinTable) Attached(leftID, rightID string) (bool, error) {
+	attached := false
+	err := jt.db.View(func(tx *bolt.Tx) error {
+		attached = tx.Bucket(jt.bucket).Get(joinKey(joinLeft, leftID, rightID)) != nil
+		return nil
+	})
+	return attached, err
+}
+
+func (jt *JoinTable) related(side []byte, id string) ([]string, error) {
+	var ids []string
+	err := jt.db.View(func(tx *bolt.Tx) error {
+		ids = scanJoin(tx.Bucket(jt.bucket), side, id)
+		return nil
+	})
+	return ids, err
+}
+
+func scanJoin(b *bolt.Bucket, side []byte, id string) []string {
+	prefix := joinPrefixKey(side, id)
+
+	var ids []string
+	c := b.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		ids = append(ids, string(k[len(prefix):]))
+	}
+	return ids
+}
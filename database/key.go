@@ -0,0 +1,68 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/keys"
+	bolt "go.etcd.io/bbolt"
+)
+
+type Key interface {
+	~string | ~[]byte | ~uint64 | ~uint32 | ~uint | ~int64 | ~int32 | ~int | time.Time
+}
+
+func EncodeKey[K Key](key K) string {
+	encoded, _ := keys.Encode(key)
+	return encoded
+}
+
+func DecodeKey[K Key](encoded string) (K, error) {
+	var key K
+	if !keys.DecodeValue(reflect.ValueOf(&key).Elem(), encoded) {
+		return key, fmt.Errorf("%w: %q is not a %T key", errors.ErrInvalidKey, encoded, key)
+	}
+	return key, nil
+}
+
+func PutKey[K Key](db *DB, bucketName string, key K, value interface{}) error {
+	return db.Put(bucketName, EncodeKey(key), value)
+}
+
+func GetKey[K Key](db *DB, bucketName string, key K, target interface{}) error {
+	return db.Get(bucketName, EncodeKey(key), target)
+}
+
+func HasKey[K Key](db *DB, bucketName string, key K) (bool, error) {
+	return db.Has(bucketName, EncodeKey(key))
+}
+
+func DeleteKey[K Key](db *DB, bucketName string, key K) error {
+	return db.Delete(bucketName, EncodeKey(key))
+}
+
+func KeysBetween[K Key](db *DB, bucketName string, from, to K) ([]K, error) {
+	start, end := []byte(EncodeKey(from)), []byte(EncodeKey(to))
+
+	var result []K
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrBucketMissing
+		}
+
+		c := b.Cursor()
+		for k, _ := c.Seek(start); k != nil && bytes.Compare(k, end) <= 0; k, _ = c.Next() {
+			key, decodeErr := DecodeKey[K](string(k))
+			if decodeErr != nil {
+				continue
+			}
+			result = append(result, key)
+		}
+		return nil
+	})
+	return result, err
+}
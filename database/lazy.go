@@ -0,0 +1,152 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/indexing"
+	"github.com/andr1ww/odin/internal/logger"
+)
+
+type declaration struct {
+	path    string
+	options Options
+}
+
+func Declare(name, dbPath string, opts ...Option) error {
+	if name == "" {
+		return fmt.Errorf("database name cannot be empty")
+	}
+	if dbPath == "" {
+		dbPath = fmt.Sprintf("%s.db", name)
+	}
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	if _, exists := manager.databases[name]; exists {
+		return errors.ErrDatabaseExists
+	}
+	if _, declared := manager.declarations[name]; declared {
+		return errors.ErrDatabaseExists
+	}
+	if _, aliased := manager.aliases[name]; aliased {
+		return errors.ErrDatabaseExists
+	}
+	if owner, inUse := pathOwner(normalizePath(dbPath), name); inUse {
+		return pathInUseError(dbPath, owner)
+	}
+
+	manager.declarations[name] = declaration{path: dbPath, options: buildOptions(opts)}
+	return nil
+}
+
+func Undeclare(name string) error {
+	manager.mutex.Lock()
+	_, declared := manager.declarations[name]
+	delete(manager.declarations, name)
+	_, open := manager.databases[name]
+	manager.mutex.Unlock()
+
+	if !declared {
+		return errors.ErrDatabaseNotFound
+	}
+	if open {
+		return Close(name)
+	}
+	return nil
+}
+
+func ListDeclared() []string {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	names := make([]string, 0, len(manager.declarations))
+	for name := range manager.declarations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func SetIdleTimeout(timeout time.Duration) {
+	manager.mutex.Lock()
+	if manager.idleStop != nil {
+		close(manager.idleStop)
+		manager.idleStop = nil
+	}
+
+	if timeout <= 0 {
+		manager.mutex.Unlock()
+		return
+	}
+
+	stop := make(chan struct{})
+	manager.idleStop = stop
+	manager.mutex.Unlock()
+
+	interval := timeout / 2
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				closeIdle(timeout)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func closeIdle(timeout time.Duration) {
+	cutoff := time.Now().Add(-timeout).UnixNano()
+
+	manager.mutex.RLock()
+	var idle []string
+	for name, db := range manager.databases {
+		if db.lazy && db.lastUsed.Load() < cutoff && !db.configuredAtRuntime() {
+			idle = append(idle, name)
+		}
+	}
+	manager.mutex.RUnlock()
+
+	for _, name := range idle {
+		if err := Close(name); err != nil {
+			logger.Error("closing idle database '%s' failed: %v", name, err)
+			continue
+		}
+		logger.Success("closed idle database '%s'", name)
+	}
+}
+
+// configuredAtRuntime reports whether db holds settings or listeners added
+// after it opened. Closing it would drop them, because the next access
+// reconnects a fresh *DB from the declaration.
+func (db *DB) configuredAtRuntime() bool {
+	if db.configured.Load() || db.IsReplica() || len(indexing.DiskIndexes(db.name)) > 0 {
+		return true
+	}
+
+	db.watchMutex.RLock()
+	watched := len(db.watchers) > 0
+	db.watchMutex.RUnlock()
+
+	db.pubsubMutex.Lock()
+	subscribed := len(db.subscribers) > 0
+	db.pubsubMutex.Unlock()
+
+	db.commits.hookMutex.RLock()
+	hooked := len(db.commits.hooks) > 0
+	db.commits.hookMutex.RUnlock()
+
+	return watched || subscribed || hooked
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andr1ww/odin/internal/logger"
+)
+
+func TestCloseIdleKeepsConfiguredDatabases(t *testing.T) {
+	logger.DisableLogging()
+	dir := t.TempDir()
+
+	for _, name := range []string{"idle-plain", "idle-configured"} {
+		if err := Declare(name, filepath.Join(dir, name+".db")); err != nil {
+			t.Fatal(err)
+		}
+		defer Undeclare(name)
+	}
+
+	plain, err := GetNamed("idle-plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	configured, err := GetNamed("idle-configured")
+	if err != nil {
+		t.Fatal(err)
+	}
+	configured.SetQuota("items", Quota{MaxKeys: 1})
+
+	closeIdle(-time.Hour)
+
+	open := GetAll()
+	if _, exists := open["idle-plain"]; exists {
+		t.Fatal("idle database without runtime configuration was not closed")
+	}
+	if open["idle-configured"] != configured {
+		t.Fatal("idle database with a quota was closed")
+	}
+
+	reopened, err := GetNamed("idle-plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened == plain {
+		t.Fatal("expected a fresh connection after the idle close")
+	}
+}
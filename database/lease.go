@@ -0,0 +1,256 @@
+package database
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	err "errors"
+	"sync"
+	"time"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/logger"
+	bolt "go.etcd.io/bbolt"
+)
+
+const leaseBucket = "__leases"
+
+type LeaseInfo struct {
+	Name     string    `json:"name"`
+	Owner    string    `json:"owner"`
+	Token    uint64    `json:"token"`
+	Acquired time.Time `json:"acquired"`
+	Expires  time.Time `json:"expires"`
+}
+
+type Lease struct {
+	db    *DB
+	name  string
+	owner string
+	ttl   time.Duration
+
+	mutex    sync.Mutex
+	info     LeaseInfo
+	lost     bool
+	released bool
+	timer    *time.Timer
+	stop     chan struct{}
+	onExpire []func(name string)
+}
+
+func (db *DB) Lease(name string, ttl time.Duration) (*Lease, error) {
+	if name == "" {
+		return nil, err.New("lease name cannot be empty")
+	}
+	if ttl <= 0 {
+		return nil, err.New("lease ttl must be positive")
+	}
+
+	owner, err := leaseOwner()
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Lease{db: db, name: name, owner: owner, ttl: ttl, stop: make(chan struct{})}
+	err = db.update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(leaseBucket))
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if current, ok := readLease(b, name); ok && current.Expires.After(now) {
+			return errors.ErrLeaseHeld
+		}
+
+		token, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		l.info = LeaseInfo{Name: name, Owner: owner, Token: token, Acquired: now, Expires: now.Add(ttl)}
+		return writeLease(b, l.info)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l.timer = time.AfterFunc(ttl, l.checkExpired)
+	return l, nil
+}
+
+func (db *DB) LeaseHolder(name string) (LeaseInfo, bool, error) {
+	var info LeaseInfo
+	held := false
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(leaseBucket))
+		if b == nil {
+			return nil
+		}
+		if current, ok := readLease(b, name); ok && current.Expires.After(time.Now()) {
+			info, held = current, true
+		}
+		return nil
+	})
+	return info, held, err
+}
+
+func leaseOwner() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func readLease(b *bolt.Bucket, name string) (LeaseInfo, bool) {
+	var info LeaseInfo
+	data := b.Get([]byte(name))
+	if data == nil || js.Unmarshal(data, &info) != nil {
+		return info, false
+	}
+	return info, true
+}
+
+func writeLease(b *bolt.Bucket, info LeaseInfo) error {
+	data, err := js.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(info.Name), data)
+}
+
+func (l *Lease) Info() LeaseInfo {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.info
+}
+
+func (l *Lease) OnExpire(fn func(name string)) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.onExpire = append(l.onExpire, fn)
+}
+
+func (l *Lease) KeepAlive() error {
+	l.mutex.Lock()
+	if l.lost || l.released {
+		l.mutex.Unlock()
+		return errors.ErrLeaseLost
+	}
+	token := l.info.Token
+	l.mutex.Unlock()
+
+	var renewed LeaseInfo
+	err := l.db.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(leaseBucket))
+		if b == nil {
+			return errors.ErrLeaseLost
+		}
+
+		current, ok := readLease(b, l.name)
+		now := time.Now()
+		if !ok || current.Owner != l.owner || current.Token != token || !current.Expires.After(now) {
+			return errors.ErrLeaseLost
+		}
+
+		current.Expires = now.Add(l.ttl)
+		renewed = current
+		return writeLease(b, current)
+	})
+	if err == errors.ErrLeaseLost {
+		l.expire()
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.lost || l.released {
+		return errors.ErrLeaseLost
+	}
+	l.info = renewed
+	l.timer.Reset(time.Until(renewed.Expires))
+	return nil
+}
+
+func (l *Lease) AutoRenew() {
+	interval := l.ttl / 3
+	if interval <= 0 {
+		interval = l.ttl
+	}
+	l.db.goBackground(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := l.KeepAlive(); err != nil {
+					if err != errors.ErrLeaseLost {
+						logger.Error("failed to renew lease '%s': %v", l.name, err)
+						continue
+					}
+					return
+				}
+			case <-l.stop:
+				return
+			case <-l.db.done:
+				return
+			}
+		}
+	})
+}
+
+func (l *Lease) Release() error {
+	l.mutex.Lock()
+	if l.released {
+		l.mutex.Unlock()
+		return nil
+	}
+	l.released = true
+	l.timer.Stop()
+	close(l.stop)
+	token := l.info.Token
+	l.mutex.Unlock()
+
+	return l.db.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(leaseBucket))
+		if b == nil {
+			return nil
+		}
+		if current, ok := readLease(b, l.name); ok && current.Owner == l.owner && current.Token == token {
+			return b.Delete([]byte(l.name))
+		}
+		return nil
+	})
+}
+
+func (l *Lease) checkExpired() {
+	l.mutex.Lock()
+	remaining := time.Until(l.info.Expires)
+	if remaining > 0 && !l.lost && !l.released {
+		l.timer.Reset(remaining)
+		l.mutex.Unlock()
+		return
+	}
+	l.mutex.Unlock()
+	l.expire()
+}
+
+func (l *Lease) expire() {
+	l.mutex.Lock()
+	if l.lost || l.released {
+		l.mutex.Unlock()
+		return
+	}
+	l.lost = true
+	l.timer.Stop()
+	callbacks := append(([]func(string))(nil), l.onExpire...)
+	l.mutex.Unlock()
+
+	logger.Warning("lease '%s' expired", l.name)
+	for _, fn := range callbacks {
+		fn(l.name)
+	}
+}
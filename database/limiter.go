@@ -0,0 +1,139 @@
+package database
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/andr1ww/odin/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	LimitWrites = "writes"
+	LimitScans  = "scans"
+)
+
+type OverloadError struct {
+	Database string
+	Resource string
+	Limit    int
+	Queued   int
+	Waited   time.Duration
+}
+
+func (e *OverloadError) Error() string {
+	if e.Waited > 0 {
+		return fmt.Sprintf("database '%s' overloaded: no %s slot free after %s (limit %d, %d queued)", e.Database, e.Resource, e.Waited.Round(time.Millisecond), e.Limit, e.Queued)
+	}
+	return fmt.Sprintf("database '%s' overloaded: %s queue is full (limit %d, %d queued)", e.Database, e.Resource, e.Limit, e.Queued)
+}
+
+func (e *OverloadError) Unwrap() error {
+	return errors.ErrOverloaded
+}
+
+type LimitStats struct {
+	Limit    int
+	Active   int
+	Queued   int
+	Rejected int64
+}
+
+type limiter struct {
+	resource string
+	slots    chan struct{}
+	queued   atomic.Int64
+	rejected atomic.Int64
+}
+
+type limiters struct {
+	writes *limiter
+	scans  *limiter
+}
+
+func WithWriteLimit(concurrent int) Option {
+	return func(o *Options) {
+		o.MaxConcurrentWrites = concurrent
+	}
+}
+
+func WithScanLimit(concurrent int) Option {
+	return func(o *Options) {
+		o.MaxConcurrentScans = concurrent
+	}
+}
+
+func WithQueueLimit(depth int, timeout time.Duration) Option {
+	return func(o *Options) {
+		o.MaxQueued = depth
+		o.QueueTimeout = timeout
+	}
+}
+
+func newLimiter(resource string, concurrent int) *limiter {
+	if concurrent <= 0 {
+		return nil
+	}
+	return &limiter{resource: resource, slots: make(chan struct{}, concurrent)}
+}
+
+func newLimiters(options Options) limiters {
+	return limiters{
+		writes: newLimiter(LimitWrites, options.MaxConcurrentWrites),
+		scans:  newLimiter(LimitScans, options.MaxConcurrentScans),
+	}
+}
+
+func (db *DB) acquire(l *limiter) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	release := func() { <-l.slots }
+
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	queued := int(l.queued.Add(1))
+	defer l.queued.Add(-1)
+	if db.options.MaxQueued > 0 && queued > db.options.MaxQueued {
+		l.rejected.Add(1)
+		return nil, &OverloadError{Database: db.name, Resource: l.resource, Limit: cap(l.slots), Queued: queued - 1}
+	}
+
+	var timeout <-chan time.Time
+	if db.options.QueueTimeout > 0 {
+		timer := time.NewTimer(db.options.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	case <-timeout:
+		l.rejected.Add(1)
+		return nil, &OverloadError{Database: db.name, Resource: l.resource, Limit: cap(l.slots), Queued: int(l.queued.Load()) - 1, Waited: db.options.QueueTimeout}
+	case <-db.done:
+		return nil, bolt.ErrDatabaseNotOpen
+	}
+}
+
+func (db *DB) LimitStats() map[string]LimitStats {
+	stats := make(map[string]LimitStats)
+	for _, l := range []*limiter{db.limits.writes, db.limits.scans} {
+		if l == nil {
+			continue
+		}
+		stats[l.resource] = LimitStats{
+			Limit:    cap(l.slots),
+			Active:   len(l.slots),
+			Queued:   int(l.queued.Load()),
+			Rejected: l.rejected.Load(),
+		}
+	}
+	return stats
+}
@@ -1,18 +1,27 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/andr1ww/odin/errors"
 	"github.com/andr1ww/odin/internal/logger"
 )
 
 type DatabaseManager struct {
-	databases map[string]*DB
-	mutex     sync.RWMutex
-	defaultDB string
+	databases    map[string]*DB
+	mutex        sync.RWMutex
+	defaultDB    string
+	declarations map[string]declaration
+	aliases      map[string]string
+	idleStop     chan struct{}
+	healthStop   chan struct{}
+	closeHooks   []func(ctx context.Context) error
+	dependencies map[string]map[string]bool
+	events       managerEvents
 }
 
 var (
@@ -23,12 +32,15 @@ var (
 func init() {
 	once.Do(func() {
 		manager = &DatabaseManager{
-			databases: make(map[string]*DB),
+			databases:    make(map[string]*DB),
+			declarations: make(map[string]declaration),
+			aliases:      make(map[string]string),
+			dependencies: make(map[string]map[string]bool),
 		}
 	})
 }
 
-func Connect(name, dbPath string) error {
+func Connect(name, dbPath string, opts ...Option) error {
 	if name == "" {
 		name = "main"
 	}
@@ -36,26 +48,57 @@ func Connect(name, dbPath string) error {
 		dbPath = fmt.Sprintf("%s.db", name)
 	}
 
+	_, err := connect(name, dbPath, buildOptions(opts), false)
+	return err
+}
+
+func connect(name, dbPath string, options Options, lazy bool) (*DB, error) {
 	manager.mutex.Lock()
-	defer manager.mutex.Unlock()
 
-	if _, exists := manager.databases[name]; exists {
-		return errors.ErrDatabaseExists
+	if db, exists := manager.databases[name]; exists {
+		manager.mutex.Unlock()
+		if lazy {
+			return db, nil
+		}
+		return nil, errors.ErrDatabaseExists
+	}
+	if _, declared := manager.declarations[name]; declared && !lazy {
+		manager.mutex.Unlock()
+		return nil, errors.ErrDatabaseExists
+	}
+	if _, aliased := manager.aliases[name]; aliased {
+		manager.mutex.Unlock()
+		return nil, errors.ErrDatabaseExists
+	}
+	if owner, inUse := pathOwner(normalizePath(dbPath), name); inUse {
+		manager.mutex.Unlock()
+		return nil, pathInUseError(dbPath, owner)
 	}
 
-	db, err := openDatabase(name, dbPath)
+	db, err := openDatabase(name, dbPath, options)
 	if err != nil {
-		return err
+		manager.mutex.Unlock()
+		return nil, err
 	}
 
+	db.lazy = lazy
+	db.lastUsed.Store(time.Now().UnixNano())
 	manager.databases[name] = db
 
-	if manager.defaultDB == "" {
+	defaultChanged := false
+	if manager.defaultDB == "" && !lazy {
 		manager.defaultDB = name
+		defaultChanged = true
 	}
+	manager.mutex.Unlock()
 
 	logger.Success("database '%s' connected successfully at %s", name, dbPath)
-	return nil
+
+	emitConnect(name, db)
+	if defaultChanged {
+		emitDefaultChanged("", name)
+	}
+	return db, nil
 }
 
 func ConnectDefault(dbPath string) error {
@@ -64,14 +107,22 @@ func ConnectDefault(dbPath string) error {
 
 func SetDefault(name string) error {
 	manager.mutex.Lock()
-	defer manager.mutex.Unlock()
+	name = resolveAlias(name)
 
 	if _, exists := manager.databases[name]; !exists {
+		manager.mutex.Unlock()
 		return errors.ErrDatabaseNotFound
 	}
 
+	previous := manager.defaultDB
 	manager.defaultDB = name
+	manager.mutex.Unlock()
+
 	logger.Success("default database set to '%s'", name)
+
+	if previous != name {
+		emitDefaultChanged(previous, name)
+	}
 	return nil
 }
 
@@ -81,21 +132,30 @@ func Get() (*DB, error) {
 
 func GetNamed(name string) (*DB, error) {
 	manager.mutex.RLock()
-	defer manager.mutex.RUnlock()
 
 	if name == "" {
 		name = manager.defaultDB
 		if name == "" {
+			manager.mutex.RUnlock()
 			return nil, errors.ErrNoDefaultDatabase
 		}
 	}
+	name = resolveAlias(name)
 
 	db, exists := manager.databases[name]
-	if !exists {
-		return nil, fmt.Errorf("database '%s' not found", name)
+	declaration, declared := manager.declarations[name]
+	manager.mutex.RUnlock()
+
+	if exists {
+		db.lastUsed.Store(time.Now().UnixNano())
+		return db, nil
 	}
 
-	return db, nil
+	if declared {
+		return connect(name, declaration.path, declaration.options, true)
+	}
+
+	return nil, fmt.Errorf("database '%s' not found", name)
 }
 
 func GetAll() map[string]*DB {
@@ -122,27 +182,25 @@ func ListDatabases() []string {
 
 func Close(name string) error {
 	manager.mutex.Lock()
-	defer manager.mutex.Unlock()
 
 	if name == "" {
 		name = manager.defaultDB
 		if name == "" {
+			manager.mutex.Unlock()
 			return errors.ErrNoDefaultDatabase
 		}
 	}
+	name = resolveAlias(name)
 
 	db, exists := manager.databases[name]
 	if !exists {
+		manager.mutex.Unlock()
 		return fmt.Errorf("database '%s' not found", name)
 	}
 
-	err := db.DB.Close()
-	if err != nil {
-		return fmt.Errorf("error closing database '%s': %w", name, err)
-	}
-
 	delete(manager.databases, name)
 
+	previousDefault := manager.defaultDB
 	if manager.defaultDB == name {
 		manager.defaultDB = ""
 		for dbName := range manager.databases {
@@ -150,6 +208,20 @@ func Close(name string) error {
 			break
 		}
 	}
+	currentDefault := manager.defaultDB
+	manager.mutex.Unlock()
+
+	db.stopBackground()
+	closeErr := db.closeHandle()
+
+	emitClose(name)
+	if previousDefault != currentDefault {
+		emitDefaultChanged(previousDefault, currentDefault)
+	}
+
+	if closeErr != nil {
+		return fmt.Errorf("error closing database '%s': %w", name, closeErr)
+	}
 
 	logger.Success("Database '%s' connection closed successfully", name)
 	return nil
@@ -157,17 +229,24 @@ func Close(name string) error {
 
 func CloseAll() error {
 	manager.mutex.Lock()
-	defer manager.mutex.Unlock()
+	databases := manager.databases
+	previousDefault := manager.defaultDB
+	manager.databases = make(map[string]*DB)
+	manager.defaultDB = ""
+	manager.mutex.Unlock()
 
 	var errors []string
-	for name, db := range manager.databases {
-		if err := db.DB.Close(); err != nil {
+	for name, db := range databases {
+		db.stopBackground()
+		if err := db.closeHandle(); err != nil {
 			errors = append(errors, fmt.Sprintf("error closing database '%s': %v", name, err))
 		}
+		emitClose(name)
 	}
 
-	manager.databases = make(map[string]*DB)
-	manager.defaultDB = ""
+	if previousDefault != "" {
+		emitDefaultChanged(previousDefault, "")
+	}
 
 	if len(errors) > 0 {
 		return fmt.Errorf("errors closing databases: %s", strings.Join(errors, "; "))
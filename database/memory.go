@@ -0,0 +1,42 @@
+package database
+
+import (
+	"github.com/andr1ww/odin/internal/indexing"
+	"github.com/andr1ww/odin/internal/pool"
+)
+
+type PoolStats = pool.Stats
+
+type MemoryUsage struct {
+	Pools      []PoolStats
+	IndexBytes int64
+	CacheBytes int64
+	BloomBytes int64
+}
+
+func SetMaxPooledItemBytes(bytes int) {
+	pool.SetMaxItemBytes(bytes)
+}
+
+func MemoryStats() MemoryUsage {
+	usage := MemoryUsage{
+		Pools:      pool.All(),
+		IndexBytes: indexing.MemoryBytes(),
+		CacheBytes: indexing.CacheBytes(),
+	}
+	for _, db := range GetAll() {
+		usage.BloomBytes += db.bloomBytes()
+	}
+	return usage
+}
+
+func (db *DB) bloomBytes() int64 {
+	db.bloomMutex.RLock()
+	defer db.bloomMutex.RUnlock()
+
+	var total int64
+	for _, state := range db.blooms {
+		total += int64(state.filter.Bits() / 8)
+	}
+	return total
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -13,6 +14,10 @@ import (
 )
 
 func (db *DB) MigrateBucket(bucketName, targetDBName string, deleteSource bool) error {
+	return db.MigrateBucketContext(context.Background(), bucketName, targetDBName, deleteSource)
+}
+
+func (db *DB) MigrateBucketContext(ctx context.Context, bucketName, targetDBName string, deleteSource bool) error {
 	if bucketName == "" {
 		return fmt.Errorf("bucket name cannot be empty")
 	}
@@ -35,6 +40,11 @@ func (db *DB) MigrateBucket(bucketName, targetDBName string, deleteSource bool)
 	var migrationCount int
 	var migrationErrors []string
 
+	total, _ := db.Count(bucketName)
+	tracker := db.trackProgress(OpMigrate, bucketName, total)
+	defer tracker.finish()
+
+	written := make(map[string][]byte)
 	err = db.View(func(sourceTx *bolt.Tx) error {
 		sourceBucket := sourceTx.Bucket([]byte(bucketName))
 		if sourceBucket == nil {
@@ -42,28 +52,52 @@ func (db *DB) MigrateBucket(bucketName, targetDBName string, deleteSource bool)
 		}
 
 		return sourceBucket.ForEach(func(k, v []byte) error {
-			actualData := compression.DecompressData(v)
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			actualData := compression.DecompressData(db.ColdValue(bucketName, string(k), v))
 
-			err := targetDB.Update(func(targetTx *bolt.Tx) error {
+			err := targetDB.update(func(targetTx *bolt.Tx) error {
 				targetBucket := targetTx.Bucket([]byte(bucketName))
 				if targetBucket == nil {
 					return fmt.Errorf("bucket '%s' not found in target database", bucketName)
 				}
 
-				compressedData := compression.CompressData(actualData)
-				return targetBucket.Put(k, compressedData)
+				if _, seen := written[string(k)]; !seen {
+					var previous []byte
+					if existing := targetBucket.Get(k); existing != nil {
+						previous = append([]byte{}, existing...)
+					}
+					written[string(k)] = previous
+				}
+				targetDB.bloomAdd(bucketName, string(k))
+				if err := targetBucket.Put(k, targetDB.encode(bucketName, actualData)); err != nil {
+					return err
+				}
+				return logChange(targetTx, bucketName, string(k), time.Now())
 			})
 
 			if err != nil {
 				migrationErrors = append(migrationErrors, fmt.Sprintf("key %s: %v", string(k), err))
+				tracker.step(1, true)
 				return nil
 			}
 
 			migrationCount++
+			tracker.step(1, false)
 			return nil
 		})
 	})
 
+	if ctx.Err() != nil {
+		if rollbackErr := rollbackWritten(targetDB, bucketName, written); rollbackErr != nil {
+			return fmt.Errorf("migration cancelled: %w (rollback failed: %v)", ctx.Err(), rollbackErr)
+		}
+		logger.Warning("Migration of bucket '%s' to '%s' cancelled, rolled back %d records", bucketName, targetDBName, len(written))
+		return fmt.Errorf("migration cancelled: %w", ctx.Err())
+	}
+
 	if err != nil {
 		return fmt.Errorf("migration failed: %w", err)
 	}
@@ -82,6 +116,29 @@ func (db *DB) MigrateBucket(bucketName, targetDBName string, deleteSource bool)
 	return nil
 }
 
+func rollbackWritten(target *DB, bucketName string, written map[string][]byte) error {
+	return target.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return nil
+		}
+
+		for key, previous := range written {
+			if previous == nil {
+				if err := b.Delete([]byte(key)); err != nil {
+					return err
+				}
+			} else if err := b.Put([]byte(key), previous); err != nil {
+				return err
+			}
+			if err := logChange(tx, bucketName, key, time.Now()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (db *DB) MigrateBucketWithTransform(bucketName, targetDBName string, transform func(key []byte, data []byte) ([]byte, []byte, error), deleteSource bool) error {
 	if bucketName == "" {
 		return fmt.Errorf("bucket name cannot be empty")
@@ -115,7 +172,7 @@ func (db *DB) MigrateBucketWithTransform(bucketName, targetDBName string, transf
 		}
 
 		return sourceBucket.ForEach(func(k, v []byte) error {
-			actualData := compression.DecompressData(v)
+			actualData := compression.DecompressData(db.ColdValue(bucketName, string(k), v))
 
 			newKey, newData, err := transform(k, actualData)
 			if err != nil {
@@ -127,14 +184,17 @@ func (db *DB) MigrateBucketWithTransform(bucketName, targetDBName string, transf
 				return nil
 			}
 
-			err = targetDB.Update(func(targetTx *bolt.Tx) error {
+			err = targetDB.update(func(targetTx *bolt.Tx) error {
 				targetBucket := targetTx.Bucket([]byte(bucketName))
 				if targetBucket == nil {
 					return fmt.Errorf("bucket '%s' not found in target database", bucketName)
 				}
 
-				compressedData := compression.CompressData(newData)
-				return targetBucket.Put(newKey, compressedData)
+				targetDB.bloomAdd(bucketName, string(newKey))
+				if err := targetBucket.Put(newKey, targetDB.encode(bucketName, newData)); err != nil {
+					return err
+				}
+				return logChange(targetTx, bucketName, string(newKey), time.Now())
 			})
 
 			if err != nil {
@@ -203,16 +263,19 @@ func MigrateBucketBetweenDatabases(sourceBucketName, sourceDBName, targetBucketN
 		}
 
 		return sourceBucket.ForEach(func(k, v []byte) error {
-			actualData := compression.DecompressData(v)
+			actualData := compression.DecompressData(sourceDB.ColdValue(sourceBucketName, string(k), v))
 
-			err := targetDB.Update(func(targetTx *bolt.Tx) error {
+			err := targetDB.update(func(targetTx *bolt.Tx) error {
 				targetBucket := targetTx.Bucket([]byte(targetBucketName))
 				if targetBucket == nil {
 					return fmt.Errorf("bucket '%s' not found in target database", targetBucketName)
 				}
 
-				compressedData := compression.CompressData(actualData)
-				return targetBucket.Put(k, compressedData)
+				targetDB.bloomAdd(targetBucketName, string(k))
+				if err := targetBucket.Put(k, targetDB.encode(targetBucketName, actualData)); err != nil {
+					return err
+				}
+				return logChange(targetTx, targetBucketName, string(k), time.Now())
 			})
 
 			if err != nil {
@@ -243,23 +306,149 @@ func MigrateBucketBetweenDatabases(sourceBucketName, sourceDBName, targetBucketN
 	return nil
 }
 
-func (db *DB) Compact() error {
-	tempPath := db.name + "_temp.db"
-
-	tempDB, err := bolt.Open(tempPath, 0600, &bolt.Options{
-		Timeout:         10 * time.Second,
-		InitialMmapSize: 10 * 1024 * 1024,
-		PageSize:        8096,
-		NoSync:          false,
-		NoFreelistSync:  false,
-		FreelistType:    bolt.FreelistMapType,
-		NoGrowSync:      true,
-		MmapFlags:       0,
+func (db *DB) MigrateKeys(bucketName, targetDBName string, keys []string, deleteSource bool) error {
+	if bucketName == "" {
+		return fmt.Errorf("bucket name cannot be empty")
+	}
+	if targetDBName == "" {
+		return fmt.Errorf("target database name cannot be empty")
+	}
+	if targetDBName == db.name {
+		return fmt.Errorf("source and target database cannot be the same")
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	targetDB, err := GetNamed(targetDBName)
+	if err != nil {
+		return fmt.Errorf("failed to get target database '%s': %w", targetDBName, err)
+	}
+
+	if err := targetDB.CreateBucket(bucketName); err != nil {
+		return fmt.Errorf("failed to create bucket in target database: %w", err)
+	}
+
+	values := make(map[string][]byte, len(keys))
+	err = db.View(func(sourceTx *bolt.Tx) error {
+		sourceBucket := sourceTx.Bucket([]byte(bucketName))
+		if sourceBucket == nil {
+			return fmt.Errorf("bucket '%s' not found in source database", bucketName)
+		}
+
+		for _, key := range keys {
+			if v := sourceBucket.Get([]byte(key)); v != nil {
+				values[key] = targetDB.encode(bucketName, compression.DecompressData(db.ColdValue(bucketName, key, v)))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+
+	err = targetDB.update(func(targetTx *bolt.Tx) error {
+		targetBucket := targetTx.Bucket([]byte(bucketName))
+		if targetBucket == nil {
+			return fmt.Errorf("bucket '%s' not found in target database", bucketName)
+		}
+
+		for key, data := range values {
+			targetDB.bloomAdd(bucketName, key)
+			if err := targetBucket.Put([]byte(key), data); err != nil {
+				return fmt.Errorf("key %s: %w", key, err)
+			}
+			if err := logChange(targetTx, bucketName, key, time.Now()); err != nil {
+				return fmt.Errorf("key %s: %w", key, err)
+			}
+		}
+		return nil
 	})
+	if err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+
+	if deleteSource {
+		observed := db.hasObservers(bucketName)
+		err = db.update(func(tx *bolt.Tx) error {
+			sourceBucket := tx.Bucket([]byte(bucketName))
+			if sourceBucket == nil {
+				return nil
+			}
+			for key := range values {
+				stored, err := db.removeKey(tx, sourceBucket, bucketName, key, false)
+				if err != nil {
+					return fmt.Errorf("key %s: %w", key, err)
+				}
+				if observed && stored != nil {
+					if err := db.stageChange(tx, bucketName, key, compression.DecompressData(stored), nil, ChangeDelete); err != nil {
+						return fmt.Errorf("key %s: %w", key, err)
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete migrated keys from source: %w", err)
+		}
+	}
+
+	logger.Success("Migrated %d keys of bucket '%s' from database '%s' to '%s'", len(values), bucketName, db.name, targetDBName)
+	return nil
+}
+
+type CompactOptions struct {
+	TempDir    string
+	KeepBackup bool
+	NoSync     bool
+}
+
+func (db *DB) Compact() error {
+	return db.CompactWithOptions(CompactOptions{})
+}
+
+func (db *DB) CompactWithOptions(opts CompactOptions) error {
+	return db.CompactContext(context.Background(), opts)
+}
+
+func (db *DB) CompactContext(ctx context.Context, opts CompactOptions) error {
+	if db.IsReadOnly() {
+		return errors.ErrReadOnly
+	}
+	originalPath := db.Path()
+	tempDir := opts.TempDir
+	if tempDir == "" {
+		tempDir = db.options.MaintenanceDir
+	}
+	tempPath := compactTempPath(originalPath, tempDir)
+	backupPath := maintenancePath(originalPath, db.options.MaintenanceDir, backupSuffix)
+
+	for _, path := range []string{tempPath, backupPath} {
+		if fileExists(path) {
+			return fmt.Errorf("failed to start compaction: %s already exists", path)
+		}
+	}
+	journal, err := beginCompaction(db.options, originalPath, tempPath, backupPath)
+	if err != nil {
+		return fmt.Errorf("failed to start compaction: %w", err)
+	}
+	defer finishCompaction(journal)
+
+	tempOptions := boltOptions(db.options)
+	tempOptions.NoSync = opts.NoSync
+
+	tempDB, err := bolt.Open(tempPath, 0600, tempOptions)
 	if err != nil {
 		return fmt.Errorf("failed to create temp database: %w", err)
 	}
 
+	db.writeMutex.Lock()
+	defer db.writeMutex.Unlock()
+
+	buckets, _ := db.ListBuckets()
+	tracker := db.trackProgress(OpCompact, "", len(buckets))
+	defer tracker.finish()
+
 	err = db.View(func(sourceTx *bolt.Tx) error {
 		return tempDB.Update(func(targetTx *bolt.Tx) error {
 			return sourceTx.ForEach(func(bucketName []byte, sourceBucket *bolt.Bucket) error {
@@ -267,64 +456,119 @@ func (db *DB) Compact() error {
 				if err != nil {
 					return fmt.Errorf("failed to create bucket %s: %w", string(bucketName), err)
 				}
+				targetBucket.SetSequence(sourceBucket.Sequence())
 
-				return sourceBucket.ForEach(func(k, v []byte) error {
-					return targetBucket.Put(k, v)
-				})
+				if err := copyBucket(ctx, sourceBucket, targetBucket); err != nil {
+					return err
+				}
+				tracker.progress.Done++
+				db.ReportProgress(tracker.progress)
+				return nil
 			})
 		})
 	})
 
+	if err == nil && opts.NoSync {
+		err = tempDB.Sync()
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+
 	if err != nil {
 		tempDB.Close()
 		os.Remove(tempPath)
+		if ctx.Err() != nil {
+			return fmt.Errorf("compaction cancelled: %w", ctx.Err())
+		}
 		return fmt.Errorf("failed to copy data: %w", err)
 	}
 
 	tempDB.Close()
 
-	originalPath := db.DB.Path()
-	backupPath := originalPath + ".backup"
+	err = db.swapHandle(func() error {
+		if err := db.DB.Close(); err != nil {
+			os.Remove(tempPath)
+			return fmt.Errorf("failed to close original database: %w", err)
+		}
 
-	if err := db.DB.Close(); err != nil {
-		os.Remove(tempPath)
-		return fmt.Errorf("failed to close original database: %w", err)
-	}
+		if err := moveFile(originalPath, backupPath); err != nil {
+			os.Remove(tempPath)
+			db.reopen(originalPath)
+			return fmt.Errorf("failed to backup original database: %w", err)
+		}
 
-	if err := os.Rename(originalPath, backupPath); err != nil {
-		os.Remove(tempPath)
-		return fmt.Errorf("failed to backup original database: %w", err)
+		if err := moveFile(tempPath, originalPath); err != nil {
+			moveFile(backupPath, originalPath)
+			os.Remove(tempPath)
+			db.reopen(originalPath)
+			return fmt.Errorf("failed to replace database: %w", err)
+		}
+
+		if err := db.reopen(originalPath); err != nil {
+			moveFile(backupPath, originalPath)
+			if reopenErr := db.reopen(originalPath); reopenErr != nil {
+				return fmt.Errorf("failed to reopen database: %w (restoring original failed: %v)", err, reopenErr)
+			}
+			return fmt.Errorf("failed to reopen database: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	if err := os.Rename(tempPath, originalPath); err != nil {
-		os.Rename(backupPath, originalPath)
-		return fmt.Errorf("failed to replace database: %w", err)
+	if opts.KeepBackup {
+		keptPath := maintenancePath(originalPath, db.options.MaintenanceDir, fmt.Sprintf(".compact-%d.bak", time.Now().Unix()))
+		if err := os.Rename(backupPath, keptPath); err != nil {
+			logger.Warning("could not keep compaction backup of '%s': %v", db.name, err)
+		} else {
+			logger.Success("Kept pre-compaction backup of '%s' at %s", db.name, keptPath)
+		}
+	} else {
+		os.Remove(backupPath)
 	}
 
-	newDB, err := bolt.Open(originalPath, 0600, &bolt.Options{
-		Timeout:         10 * time.Second,
-		InitialMmapSize: 10 * 1024 * 1024,
-		PageSize:        8096,
-		NoSync:          false,
-		NoFreelistSync:  false,
-		FreelistType:    bolt.FreelistMapType,
-		NoGrowSync:      true,
-		MmapFlags:       0,
+	logger.Success("Database '%s' compacted successfully", db.name)
+	return nil
+}
+
+func copyBucket(ctx context.Context, source, target *bolt.Bucket) error {
+	copied := 0
+	return source.ForEach(func(k, v []byte) error {
+		if copied++; copied%progressInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		if v != nil {
+			return target.Put(k, v)
+		}
+
+		nestedSource := source.Bucket(k)
+		nestedTarget, err := target.CreateBucket(k)
+		if err != nil {
+			return err
+		}
+		nestedTarget.SetSequence(nestedSource.Sequence())
+		return copyBucket(ctx, nestedSource, nestedTarget)
 	})
+}
+
+func (db *DB) reopen(path string) error {
+	newDB, err := bolt.Open(path, 0600, boltOptions(db.options))
 	if err != nil {
-		os.Rename(backupPath, originalPath)
-		return fmt.Errorf("failed to reopen database: %w", err)
+		return err
 	}
-
 	db.DB = newDB
-	os.Remove(backupPath)
-
-	logger.Success("Database '%s' compacted successfully", db.name)
+	db.readOnly.Store(newDB.IsReadOnly())
+	db.recordFileIdentity()
 	return nil
 }
 
 func (db *DB) CompactBucket(bucketName string) error {
-	return db.Update(func(tx *bolt.Tx) error {
+	return db.update(func(tx *bolt.Tx) error {
 		sourceBucket := tx.Bucket([]byte(bucketName))
 		if sourceBucket == nil {
 			return errors.ErrBucketMissing
@@ -381,36 +625,50 @@ func (db *DB) CompressAllBuckets() error {
 	logger.Success("Starting compression for %d buckets in database '%s'", len(buckets), db.name)
 
 	for _, bucketName := range buckets {
+		if strings.HasPrefix(bucketName, "__") || !db.CompressionEnabled(bucketName) {
+			continue
+		}
+
 		bucketProcessed := 0
 		bucketErrors := 0
 
-		err := db.Update(func(tx *bolt.Tx) error {
+		total, _ := db.Count(bucketName)
+		tracker := db.trackProgress(OpCompress, bucketName, total)
+
+		err := db.update(func(tx *bolt.Tx) error {
 			bucket := tx.Bucket([]byte(bucketName))
 			if bucket == nil {
 				return fmt.Errorf("bucket '%s' not found", bucketName)
 			}
 
 			return bucket.ForEach(func(k, v []byte) error {
-				if len(v) == 0 {
+				if len(v) == 0 || isColdStub(v) {
 					return nil
 				}
 
 				decompressed := compression.DecompressData(v)
-				recompressed := compression.CompressData(decompressed)
+				recompressed := db.encode(bucketName, decompressed)
 
 				if len(recompressed) < len(v) {
-					if err := bucket.Put(k, recompressed); err != nil {
+					putErr := db.admit(tx, bucketName, string(k), v, recompressed)
+					if putErr == nil {
+						putErr = bucket.Put(k, recompressed)
+					}
+					if putErr != nil {
 						bucketErrors++
-						totalErrors = append(totalErrors, fmt.Sprintf("bucket '%s', key '%s': %v", bucketName, string(k), err))
+						totalErrors = append(totalErrors, fmt.Sprintf("bucket '%s', key '%s': %v", bucketName, string(k), putErr))
+						tracker.step(1, true)
 						return nil
 					}
 				}
 
 				bucketProcessed++
 				totalProcessed++
+				tracker.step(1, false)
 				return nil
 			})
 		})
+		tracker.finish()
 
 		if err != nil {
 			totalErrors = append(totalErrors, fmt.Sprintf("bucket '%s': %v", bucketName, err))
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"time"
+
+	"github.com/andr1ww/odin/internal/compression"
+	"github.com/andr1ww/odin/internal/reflection"
+)
+
+type Options struct {
+	Timeout         time.Duration
+	NoSync          bool
+	MmapSize        int
+	MigrationPolicy MigrationPolicy
+	Compression     bool
+
+	CompressionLevel     int
+	CompressionThreshold int
+
+	Progress ProgressReporter
+
+	SlowThreshold time.Duration
+	Interceptors  []Interceptor
+
+	FS             FS
+	MaintenanceDir string
+	ReadOnly       bool
+	Naming         reflection.NamingStrategy
+	StrictDecoding bool
+	OnSkip         func(*DecodeError)
+	Quarantine     bool
+	ScanWorkers    int
+	PooledDecoding bool
+
+	MaintenanceLatency    time.Duration
+	MaintenancePriorities map[string]Priority
+
+	MaxConcurrentWrites int
+	MaxConcurrentScans  int
+	MaxQueued           int
+	QueueTimeout        time.Duration
+
+	standby bool
+}
+
+type Option func(*Options)
+
+func defaultOptions() Options {
+	return Options{
+		Timeout:         10 * time.Second,
+		MmapSize:        10 * 1024 * 1024,
+		MigrationPolicy: MigrationBackground,
+		Compression:     !compressionDisabled.Load(),
+
+		CompressionLevel:     DefaultCompression,
+		CompressionThreshold: compression.DefaultThreshold,
+	}
+}
+
+func buildOptions(opts []Option) Options {
+	options := defaultOptions()
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&options)
+		}
+	}
+	return options
+}
+
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = timeout
+	}
+}
+
+func WithNoSync(noSync bool) Option {
+	return func(o *Options) {
+		o.NoSync = noSync
+	}
+}
+
+func WithMmapSize(bytes int) Option {
+	return func(o *Options) {
+		o.MmapSize = bytes
+	}
+}
+
+func WithNamingStrategy(strategy reflection.NamingStrategy) Option {
+	return func(o *Options) {
+		o.Naming = strategy
+	}
+}
+
+func WithReadOnly(readOnly bool) Option {
+	return func(o *Options) {
+		o.ReadOnly = readOnly
+	}
+}
+
+func WithMaintenanceDir(dir string) Option {
+	return func(o *Options) {
+		o.MaintenanceDir = dir
+	}
+}
+
+func WithMigrationPolicy(policy MigrationPolicy) Option {
+	return func(o *Options) {
+		o.MigrationPolicy = policy
+	}
+}
+
+func WithSlowThreshold(threshold time.Duration) Option {
+	return func(o *Options) {
+		o.SlowThreshold = threshold
+	}
+}
+
+func WithCompression(enabled bool) Option {
+	return func(o *Options) {
+		o.Compression = enabled
+	}
+}
+
+func WithCompressionLevel(level int) Option {
+	return func(o *Options) {
+		o.CompressionLevel = level
+	}
+}
+
+func WithCompressionThreshold(threshold int) Option {
+	return func(o *Options) {
+		o.CompressionThreshold = threshold
+	}
+}
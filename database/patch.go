@@ -0,0 +1,58 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/jsonpatch"
+)
+
+func (db *DB) UpdateValue(bucketName string, key string, fn func(current []byte) ([]byte, error)) error {
+	return db.Intercept(OpInfo{Op: OpUpdate, Bucket: bucketName, Key: key, Keys: 1}, func() error {
+		defer db.ObserveOp(OpUpdate, bucketName, "", time.Now(), 1)
+		return db.modify(bucketName, key, func(current []byte) ([]byte, error) {
+			next, err := fn(current)
+			if err != nil || next == nil {
+				return nil, err
+			}
+			if !json.Valid(next) {
+				return nil, fmt.Errorf("%w: update of %s/%s did not produce JSON", errors.ErrInvalidData, bucketName, key)
+			}
+			return next, nil
+		})
+	})
+}
+
+func (db *DB) Merge(bucketName string, key string, patch []byte) error {
+	return db.Intercept(OpInfo{Op: OpMerge, Bucket: bucketName, Key: key, Keys: 1}, func() error {
+		return db.modify(bucketName, key, func(current []byte) ([]byte, error) {
+			if current == nil {
+				return nil, errors.ErrNotFound
+			}
+
+			merged, err := jsonpatch.MergePatch(current, patch)
+			if err != nil {
+				return nil, fmt.Errorf("merge patch %s/%s: %w", bucketName, key, err)
+			}
+			return merged, nil
+		})
+	})
+}
+
+func (db *DB) Patch(bucketName string, key string, ops []byte) error {
+	return db.Intercept(OpInfo{Op: OpPatch, Bucket: bucketName, Key: key, Keys: 1}, func() error {
+		return db.modify(bucketName, key, func(current []byte) ([]byte, error) {
+			if current == nil {
+				return nil, errors.ErrNotFound
+			}
+
+			patched, err := jsonpatch.Apply(current, ops)
+			if err != nil {
+				return nil, fmt.Errorf("json patch %s/%s: %w", bucketName, key, err)
+			}
+			return patched, nil
+		})
+	})
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/andr1ww/odin/errors"
+)
+
+func normalizePath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved
+	}
+
+	dir, file := filepath.Split(abs)
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		return filepath.Join(resolved, file)
+	}
+	return abs
+}
+
+func pathOwner(path string, exclude string) (string, bool) {
+	for name, db := range manager.databases {
+		if name != exclude && normalizePath(db.Path()) == path {
+			return name, true
+		}
+	}
+	for name, decl := range manager.declarations {
+		if name != exclude && normalizePath(decl.path) == path {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func pathInUseError(path, owner string) error {
+	return fmt.Errorf("%w: %s is already registered as '%s'", errors.ErrDatabasePathInUse, path, owner)
+}
@@ -0,0 +1,93 @@
+package database
+
+const progressInterval = 500
+
+const (
+	OpCompact     = "compact"
+	OpMigrate     = "migrate_bucket"
+	OpCompress    = "compress"
+	OpRecompress  = "recompress"
+	OpAutoMigrate = "automigrate"
+)
+
+type Progress struct {
+	Operation string `json:"operation"`
+	Bucket    string `json:"bucket,omitempty"`
+	Done      int    `json:"done"`
+	Total     int    `json:"total"`
+	Errors    int    `json:"errors"`
+	Finished  bool   `json:"finished"`
+}
+
+type ProgressReporter interface {
+	Report(Progress)
+}
+
+type ProgressFunc func(Progress)
+
+func (f ProgressFunc) Report(p Progress) {
+	f(p)
+}
+
+func ProgressChannel(ch chan<- Progress) ProgressReporter {
+	return ProgressFunc(func(p Progress) {
+		select {
+		case ch <- p:
+		default:
+		}
+	})
+}
+
+func WithProgressReporter(reporter ProgressReporter) Option {
+	return func(o *Options) {
+		o.Progress = reporter
+	}
+}
+
+func (db *DB) SetProgressReporter(reporter ProgressReporter) {
+	db.configured.Store(true)
+	db.progressMutex.Lock()
+	defer db.progressMutex.Unlock()
+	db.options.Progress = reporter
+}
+
+func (db *DB) ReportProgress(p Progress) {
+	db.progressMutex.RLock()
+	reporter := db.options.Progress
+	db.progressMutex.RUnlock()
+
+	if reporter != nil {
+		reporter.Report(p)
+	}
+}
+
+type progressTracker struct {
+	db       *DB
+	progress Progress
+	reported int
+}
+
+func (db *DB) trackProgress(operation, bucketName string, total int) *progressTracker {
+	t := &progressTracker{db: db, progress: Progress{Operation: operation, Bucket: bucketName, Total: total}}
+	db.ReportProgress(t.progress)
+	return t
+}
+
+func (t *progressTracker) step(n int, failed bool) {
+	t.progress.Done += n
+	if failed {
+		t.progress.Errors++
+	}
+	if t.progress.Done-t.reported >= progressInterval {
+		t.reported = t.progress.Done
+		t.db.ReportProgress(t.progress)
+	}
+}
+
+func (t *progressTracker) finish() {
+	t.progress.Finished = true
+	if t.progress.Total < t.progress.Done {
+		t.progress.Total = t.progress.Done
+	}
+	t.db.ReportProgress(t.progress)
+}
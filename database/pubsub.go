@@ -0,0 +1,263 @@
+package database
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/logger"
+	"github.com/andr1ww/odin/internal/reflection"
+)
+
+const (
+	defaultSubscriberBuffer = 256
+	defaultHistorySize      = 1024
+)
+
+type SlowConsumerPolicy int
+
+const (
+	DropNewest SlowConsumerPolicy = iota
+	DropOldest
+	Disconnect
+)
+
+type SubscribeOption func(*subscribeConfig)
+
+type subscribeConfig struct {
+	buffer   int
+	from     uint64
+	policy   SlowConsumerPolicy
+	replayed bool
+}
+
+func WithBuffer(size int) SubscribeOption {
+	return func(c *subscribeConfig) {
+		if size > 0 {
+			c.buffer = size
+		}
+	}
+}
+
+func FromSequence(seq uint64) SubscribeOption {
+	return func(c *subscribeConfig) {
+		c.from = seq
+		c.replayed = true
+	}
+}
+
+func WithSlowConsumer(policy SlowConsumerPolicy) SubscribeOption {
+	return func(c *subscribeConfig) {
+		c.policy = policy
+	}
+}
+
+type Subscription struct {
+	C <-chan Change
+
+	db       *DB
+	id       int
+	topic    string
+	criteria map[string]interface{}
+	policy   SlowConsumerPolicy
+	ch       chan Change
+	dropped  atomic.Uint64
+
+	mutex  sync.Mutex
+	closed bool
+	err    error
+}
+
+func (db *DB) Subscribe(topic string, criteria map[string]interface{}, opts ...SubscribeOption) (*Subscription, error) {
+	criteria, err := reflection.CompileFilter(criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	config := subscribeConfig{buffer: defaultSubscriberBuffer}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	db.pubsubMutex.Lock()
+	defer db.pubsubMutex.Unlock()
+
+	if !db.historyConfigured {
+		db.historySize = defaultHistorySize
+		db.historyConfigured = true
+	}
+
+	var replay []Change
+	if config.replayed {
+		retained := db.publishedSeq + 1
+		if len(db.history) > 0 {
+			retained = db.history[0].Seq
+		}
+		if max(config.from, 1) < retained {
+			return nil, errors.ErrSequenceTruncated
+		}
+		for _, change := range db.history {
+			if change.Seq >= config.from && matchesSubscription(topic, criteria, change) {
+				replay = append(replay, change)
+			}
+		}
+	}
+
+	ch := make(chan Change, config.buffer+len(replay))
+	for _, change := range replay {
+		ch <- change
+	}
+
+	db.nextSubscriber++
+	sub := &Subscription{
+		C:        ch,
+		db:       db,
+		id:       db.nextSubscriber,
+		topic:    topic,
+		criteria: criteria,
+		policy:   config.policy,
+		ch:       ch,
+	}
+	db.subscribers[sub.id] = sub
+	return sub, nil
+}
+
+func (s *Subscription) Close() {
+	s.db.pubsubMutex.Lock()
+	defer s.db.pubsubMutex.Unlock()
+	s.closeLocked(nil)
+}
+
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+func (s *Subscription) Err() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.err
+}
+
+func (s *Subscription) closeLocked(reason error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.err = reason
+	delete(s.db.subscribers, s.id)
+	close(s.ch)
+}
+
+func (s *Subscription) deliver(change Change) {
+	select {
+	case s.ch <- change:
+		return
+	default:
+	}
+
+	switch s.policy {
+	case DropOldest:
+		select {
+		case <-s.ch:
+			s.dropped.Add(1)
+		default:
+		}
+		select {
+		case s.ch <- change:
+		default:
+			s.dropped.Add(1)
+		}
+	case Disconnect:
+		logger.Warning("subscriber on topic '%s' fell behind at sequence %d, disconnecting", s.topic, change.Seq)
+		s.closeLocked(errors.ErrSlowConsumer)
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+func (db *DB) SetChangeHistory(size int) {
+	db.configured.Store(true)
+	db.pubsubMutex.Lock()
+	defer db.pubsubMutex.Unlock()
+
+	if size < 0 {
+		size = 0
+	}
+	db.historySize = size
+	db.historyConfigured = true
+	if len(db.history) > size {
+		db.history = append([]Change(nil), db.history[len(db.history)-size:]...)
+	}
+}
+
+func (db *DB) ChangeSequence() uint64 {
+	db.pubsubMutex.Lock()
+	defer db.pubsubMutex.Unlock()
+	return db.changeSeq
+}
+
+func (db *DB) hasSubscribers(bucketName string) bool {
+	db.pubsubMutex.Lock()
+	defer db.pubsubMutex.Unlock()
+
+	if db.historySize > 0 {
+		return true
+	}
+	for _, sub := range db.subscribers {
+		if sub.topic == "" || sub.topic == bucketName {
+			return true
+		}
+	}
+	return false
+}
+
+func (db *DB) recordHistory(change Change) {
+	if db.historySize == 0 {
+		return
+	}
+
+	db.history = append(db.history, change)
+	if len(db.history) > 2*db.historySize {
+		db.history = append([]Change(nil), db.history[len(db.history)-db.historySize:]...)
+	}
+}
+
+func (db *DB) deliverSubscribers(change Change) {
+	for _, sub := range db.subscribers {
+		if matchesSubscription(sub.topic, sub.criteria, change) {
+			sub.deliver(change)
+		}
+	}
+}
+
+func matchesSubscription(topic string, criteria map[string]interface{}, change Change) bool {
+	if topic != "" && topic != change.Bucket {
+		return false
+	}
+	if len(criteria) == 0 {
+		return true
+	}
+
+	data := change.Value
+	if data == nil {
+		data = change.Old
+	}
+
+	var doc Document
+	if err := js.Unmarshal(data, &doc); err != nil || doc == nil {
+		return false
+	}
+	return reflection.MatchesDocument(doc, criteria)
+}
+
+func (db *DB) closeSubscriptions() {
+	db.pubsubMutex.Lock()
+	defer db.pubsubMutex.Unlock()
+
+	for _, sub := range db.subscribers {
+		sub.closeLocked(nil)
+	}
+}
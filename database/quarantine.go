@@ -0,0 +1,252 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/andr1ww/odin/internal/logger"
+	bolt "go.etcd.io/bbolt"
+)
+
+const quarantinePrefix = "__quarantine_"
+
+type QuarantineRecord struct {
+	Bucket        string    `json:"bucket"`
+	Key           string    `json:"key"`
+	Error         string    `json:"error"`
+	Corrupt       bool      `json:"corrupt"`
+	Raw           []byte    `json:"raw"`
+	QuarantinedAt time.Time `json:"quarantined_at"`
+}
+
+type QuarantineStats struct {
+	Skipped     uint64
+	Quarantined uint64
+	Failed      uint64
+	Pending     int
+	LastError   string
+}
+
+type quarantine struct {
+	mutex   sync.Mutex
+	pending map[migrationKey]*DecodeError
+	running bool
+	stats   map[string]*QuarantineStats
+}
+
+func QuarantineBucketName(bucketName string) string {
+	return quarantinePrefix + bucketName
+}
+
+func WithQuarantine(enabled bool) Option {
+	return func(o *Options) {
+		o.Quarantine = enabled
+	}
+}
+
+func (db *DB) QuarantineStats(bucketName string) QuarantineStats {
+	q := &db.quarantine
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	var stats QuarantineStats
+	if current, ok := q.stats[bucketName]; ok {
+		stats = *current
+	}
+	for id := range q.pending {
+		if id.bucket == bucketName {
+			stats.Pending++
+		}
+	}
+	return stats
+}
+
+func (q *quarantine) bucketStats(bucketName string) *QuarantineStats {
+	if q.stats == nil {
+		q.stats = make(map[string]*QuarantineStats)
+	}
+	stats, ok := q.stats[bucketName]
+	if !ok {
+		stats = &QuarantineStats{}
+		q.stats[bucketName] = stats
+	}
+	return stats
+}
+
+func (db *DB) quarantineRecord(decodeErr *DecodeError) {
+	q := &db.quarantine
+	id := migrationKey{bucket: decodeErr.Bucket, key: decodeErr.Key}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.bucketStats(id.bucket).Skipped++
+	if !db.options.Quarantine || db.rejectsWrites() {
+		return
+	}
+	if _, queued := q.pending[id]; queued {
+		return
+	}
+	if q.pending == nil {
+		q.pending = make(map[migrationKey]*DecodeError)
+	}
+
+	q.pending[id] = decodeErr
+	if !q.running {
+		q.running = db.goBackground(db.drainQuarantine)
+		if !q.running {
+			delete(q.pending, id)
+		}
+	}
+}
+
+func (db *DB) drainQuarantine() {
+	q := &db.quarantine
+
+	for {
+		q.mutex.Lock()
+		var decodeErr *DecodeError
+		for _, v := range q.pending {
+			decodeErr = v
+			break
+		}
+
+		select {
+		case <-db.done:
+			decodeErr = nil
+		default:
+		}
+
+		if decodeErr == nil {
+			q.running = false
+			q.mutex.Unlock()
+			return
+		}
+		delete(q.pending, migrationKey{bucket: decodeErr.Bucket, key: decodeErr.Key})
+		q.mutex.Unlock()
+
+		if err := db.writeQuarantine(decodeErr); err != nil {
+			logger.Error("quarantining %s/%s in database '%s' failed: %v", decodeErr.Bucket, decodeErr.Key, db.name, err)
+		}
+	}
+}
+
+func (db *DB) FlushQuarantine() error {
+	q := &db.quarantine
+
+	q.mutex.Lock()
+	pending := make([]*DecodeError, 0, len(q.pending))
+	for id, decodeErr := range q.pending {
+		pending = append(pending, decodeErr)
+		delete(q.pending, id)
+	}
+	q.mutex.Unlock()
+
+	var firstErr error
+	for _, decodeErr := range pending {
+		if err := db.writeQuarantine(decodeErr); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (db *DB) writeQuarantine(decodeErr *DecodeError) error {
+	stored := false
+	err := db.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(decodeErr.Bucket))
+		if b == nil {
+			return nil
+		}
+		raw := b.Get([]byte(decodeErr.Key))
+		if raw == nil {
+			return nil
+		}
+
+		record := QuarantineRecord{
+			Bucket:        decodeErr.Bucket,
+			Key:           decodeErr.Key,
+			Error:         decodeErr.Err.Error(),
+			Corrupt:       decodeErr.Corrupt,
+			Raw:           append([]byte(nil), raw...),
+			QuarantinedAt: time.Now().UTC(),
+		}
+		data, marshalErr := js.Marshal(record)
+		if marshalErr != nil {
+			return marshalErr
+		}
+
+		qb, createErr := tx.CreateBucketIfNotExists([]byte(QuarantineBucketName(decodeErr.Bucket)))
+		if createErr != nil {
+			return createErr
+		}
+		stored = true
+		return qb.Put([]byte(decodeErr.Key), data)
+	})
+
+	q := &db.quarantine
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	stats := q.bucketStats(decodeErr.Bucket)
+	switch {
+	case err != nil:
+		stats.Failed++
+		stats.LastError = fmt.Sprintf("%s: %v", decodeErr.Key, err)
+		return fmt.Errorf("quarantine %s/%s: %w", decodeErr.Bucket, decodeErr.Key, err)
+	case stored:
+		stats.Quarantined++
+	}
+	return nil
+}
+
+func (db *DB) Quarantined(bucketName string) ([]QuarantineRecord, error) {
+	var records []QuarantineRecord
+	err := db.View(func(tx *bolt.Tx) error {
+		qb := tx.Bucket([]byte(QuarantineBucketName(bucketName)))
+		if qb == nil {
+			return nil
+		}
+		return qb.ForEach(func(k, v []byte) error {
+			var record QuarantineRecord
+			if err := js.Unmarshal(v, &record); err != nil {
+				return fmt.Errorf("quarantine entry '%s': %w", k, err)
+			}
+			records = append(records, record)
+			return nil
+		})
+	})
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].QuarantinedAt.Before(records[j].QuarantinedAt)
+	})
+	return records, err
+}
+
+func (db *DB) ReleaseQuarantined(bucketName, key string) error {
+	return db.update(func(tx *bolt.Tx) error {
+		qb := tx.Bucket([]byte(QuarantineBucketName(bucketName)))
+		if qb == nil {
+			return nil
+		}
+		return qb.Delete([]byte(key))
+	})
+}
+
+func (db *DB) ClearQuarantine(bucketName string) error {
+	err := db.update(func(tx *bolt.Tx) error {
+		name := []byte(QuarantineBucketName(bucketName))
+		if tx.Bucket(name) == nil {
+			return nil
+		}
+		return tx.DeleteBucket(name)
+	})
+	if err == nil {
+		q := &db.quarantine
+		q.mutex.Lock()
+		delete(q.stats, bucketName)
+		q.mutex.Unlock()
+	}
+	return err
+}
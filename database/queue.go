@@ -0,0 +1,332 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	err "errors"
+	"fmt"
+	"time"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/keys"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	queuePrefix        = "__queue_"
+	deadLetterPrefix   = "__dlq_"
+	defaultMaxAttempts = 5
+	queueReclaimBatch  = 256
+)
+
+var (
+	queueReady    = []byte("ready")
+	queueInFlight = []byte("inflight")
+	queueLeases   = []byte("leases")
+)
+
+type Message struct {
+	ID       string          `json:"id"`
+	Payload  json.RawMessage `json:"payload"`
+	Attempts int             `json:"attempts"`
+	Enqueued time.Time       `json:"enqueued"`
+	Deadline time.Time       `json:"deadline"`
+}
+
+func (m *Message) Decode(v interface{}) error {
+	return js.Unmarshal(m.Payload, v)
+}
+
+type QueueStats struct {
+	Ready    int `json:"ready"`
+	InFlight int `json:"in_flight"`
+	Dead     int `json:"dead"`
+}
+
+type QueueOption func(*Queue)
+
+func WithMaxAttempts(attempts int) QueueOption {
+	return func(q *Queue) {
+		if attempts > 0 {
+			q.maxAttempts = attempts
+		}
+	}
+}
+
+type Queue struct {
+	db          *DB
+	name        string
+	bucket      []byte
+	dead        []byte
+	maxAttempts int
+}
+
+func (db *DB) Queue(name string, opts ...QueueOption) (*Queue, error) {
+	if name == "" {
+		return nil, err.New("queue name cannot be empty")
+	}
+
+	q := &Queue{
+		db:          db,
+		name:        name,
+		bucket:      []byte(queuePrefix + name),
+		dead:        []byte(deadLetterPrefix + name),
+		maxAttempts: defaultMaxAttempts,
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	err := db.update(func(tx *bolt.Tx) error {
+		root, err := tx.CreateBucketIfNotExists(q.bucket)
+		if err != nil {
+			return err
+		}
+		for _, name := range [][]byte{queueReady, queueInFlight, queueLeases} {
+			if _, err := root.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		_, err = tx.CreateBucketIfNotExists(q.dead)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (q *Queue) Name() string {
+	return q.name
+}
+
+func (q *Queue) Enqueue(payload interface{}) (string, error) {
+	if payload == nil {
+		return "", errors.ErrNilValue
+	}
+
+	data, err := js.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	var id string
+	err = q.db.update(func(tx *bolt.Tx) error {
+		root := tx.Bucket(q.bucket)
+		seq, err := root.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		id = fmt.Sprintf("%016x", seq)
+		return putMessage(root.Bucket(queueReady), now, &Message{ID: id, Payload: data, Enqueued: now})
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func (q *Queue) Dequeue(visibility time.Duration) (*Message, error) {
+	if visibility <= 0 {
+		return nil, err.New("visibility timeout must be positive")
+	}
+
+	var msg *Message
+	err := q.db.update(func(tx *bolt.Tx) error {
+		root := tx.Bucket(q.bucket)
+		now := time.Now()
+		if err := q.reclaim(tx, root, now); err != nil {
+			return err
+		}
+
+		ready := root.Bucket(queueReady)
+		k, v := ready.Cursor().First()
+		if k == nil {
+			return nil
+		}
+		if at, _ := keys.SplitTimeKey(k); at.After(now) {
+			return nil
+		}
+
+		msg = &Message{}
+		if err := js.Unmarshal(v, msg); err != nil {
+			return err
+		}
+		if err := ready.Delete(k); err != nil {
+			return err
+		}
+
+		msg.Attempts++
+		msg.Deadline = time.Unix(0, now.Add(visibility).UnixNano())
+		if err := putMessage(root.Bucket(queueInFlight), msg.Deadline, msg); err != nil {
+			return err
+		}
+		return root.Bucket(queueLeases).Put([]byte(msg.ID), keys.EncodeTime(msg.Deadline))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if msg == nil {
+		return nil, errors.ErrQueueEmpty
+	}
+	return msg, nil
+}
+
+func (q *Queue) Ack(msg *Message) error {
+	return q.db.update(func(tx *bolt.Tx) error {
+		_, err := q.release(tx.Bucket(q.bucket), msg)
+		return err
+	})
+}
+
+func (q *Queue) Nack(msg *Message, delay time.Duration) error {
+	return q.db.update(func(tx *bolt.Tx) error {
+		root := tx.Bucket(q.bucket)
+		held, err := q.release(root, msg)
+		if err != nil {
+			return err
+		}
+		return q.retry(tx, root, held, time.Now().Add(delay))
+	})
+}
+
+func (q *Queue) release(root *bolt.Bucket, msg *Message) (*Message, error) {
+	if msg == nil {
+		return nil, errors.ErrNilValue
+	}
+
+	leases := root.Bucket(queueLeases)
+	lease := leases.Get([]byte(msg.ID))
+	if lease == nil || !bytes.Equal(lease, keys.EncodeTime(msg.Deadline)) || time.Now().After(msg.Deadline) {
+		return nil, errors.ErrLeaseExpired
+	}
+
+	inflight := root.Bucket(queueInFlight)
+	key := keys.TimeKey(msg.Deadline, []byte(msg.ID))
+	held := &Message{}
+	if err := js.Unmarshal(inflight.Get(key), held); err != nil {
+		return nil, err
+	}
+	if err := inflight.Delete(key); err != nil {
+		return nil, err
+	}
+	if err := leases.Delete([]byte(msg.ID)); err != nil {
+		return nil, err
+	}
+	return held, nil
+}
+
+func (q *Queue) retry(tx *bolt.Tx, root *bolt.Bucket, msg *Message, at time.Time) error {
+	msg.Deadline = time.Time{}
+	if msg.Attempts >= q.maxAttempts {
+		data, err := js.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(q.dead).Put([]byte(msg.ID), data)
+	}
+	return putMessage(root.Bucket(queueReady), at, msg)
+}
+
+func (q *Queue) reclaim(tx *bolt.Tx, root *bolt.Bucket, now time.Time) error {
+	inflight := root.Bucket(queueInFlight)
+	leases := root.Bucket(queueLeases)
+
+	var expired [][]byte
+	c := inflight.Cursor()
+	for k, _ := c.First(); k != nil && len(expired) < queueReclaimBatch; k, _ = c.Next() {
+		if deadline, _ := keys.SplitTimeKey(k); deadline.After(now) {
+			break
+		}
+		expired = append(expired, k)
+	}
+
+	for _, k := range expired {
+		msg := &Message{}
+		if err := js.Unmarshal(inflight.Get(k), msg); err != nil {
+			return err
+		}
+		if err := inflight.Delete(k); err != nil {
+			return err
+		}
+		if err := leases.Delete([]byte(msg.ID)); err != nil {
+			return err
+		}
+		if err := q.retry(tx, root, msg, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func putMessage(b *bolt.Bucket, at time.Time, msg *Message) error {
+	data, err := js.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return b.Put(keys.TimeKey(at, []byte(msg.ID)), data)
+}
+
+func (q *Queue) DeadLetters() ([]Message, error) {
+	var messages []Message
+	err := q.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(q.dead).ForEach(func(_, v []byte) error {
+			var msg Message
+			if err := js.Unmarshal(v, &msg); err != nil {
+				return err
+			}
+			messages = append(messages, msg)
+			return nil
+		})
+	})
+	return messages, err
+}
+
+func (q *Queue) Redrive() (int, error) {
+	moved := 0
+	err := q.db.update(func(tx *bolt.Tx) error {
+		dead := tx.Bucket(q.dead)
+		ready := tx.Bucket(q.bucket).Bucket(queueReady)
+		now := time.Now()
+
+		var ids [][]byte
+		err := dead.ForEach(func(k, v []byte) error {
+			msg := &Message{}
+			if err := js.Unmarshal(v, msg); err != nil {
+				return err
+			}
+			msg.Attempts = 0
+			if err := putMessage(ready, now, msg); err != nil {
+				return err
+			}
+			ids = append(ids, k)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			if err := dead.Delete(id); err != nil {
+				return err
+			}
+		}
+		moved = len(ids)
+		return nil
+	})
+	return moved, err
+}
+
+func (q *Queue) Stats() (QueueStats, error) {
+	var stats QueueStats
+	err := q.db.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket(q.bucket)
+		stats.Ready = root.Bucket(queueReady).Stats().KeyN
+		stats.InFlight = root.Bucket(queueInFlight).Stats().KeyN
+		stats.Dead = tx.Bucket(q.dead).Stats().KeyN
+		return nil
+	})
+	return stats, err
+}
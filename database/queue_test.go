@@ -0,0 +1,57 @@
+package database
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andr1ww/odin/internal/logger"
+)
+
+func TestCompressAllBucketsLeavesDeadLettersReadable(t *testing.T) {
+	logger.DisableLogging()
+	name := "queue-compress"
+
+	if err := Connect(name, filepath.Join(t.TempDir(), "main.db"), WithCompression(true)); err != nil {
+		t.Fatal(err)
+	}
+	defer Close(name)
+
+	db, err := GetNamed(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := db.Queue("jobs", WithMaxAttempts(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := map[string]string{"body": strings.Repeat("retry me ", 16)}
+	if _, err := q.Enqueue(payload); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := q.Dequeue(time.Minute)
+	if err != nil || msg == nil {
+		t.Fatalf("dequeue = %v, %v", msg, err)
+	}
+	if err := q.Nack(msg, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.CompressAllBuckets(); err != nil {
+		t.Fatal(err)
+	}
+
+	dead, err := q.DeadLetters()
+	if err != nil || len(dead) != 1 {
+		t.Fatalf("dead letters after compressing = %v, %v", dead, err)
+	}
+	var body map[string]string
+	if err := dead[0].Decode(&body); err != nil || body["body"] != payload["body"] {
+		t.Fatalf("dead letter payload = %v, %v", body, err)
+	}
+	if moved, err := q.Redrive(); err != nil || moved != 1 {
+		t.Fatalf("redrive = %d, %v", moved, err)
+	}
+}
@@ -0,0 +1,226 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/logger"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	QuotaKeys  = "keys"
+	QuotaBytes = "bytes"
+)
+
+type Quota struct {
+	MaxKeys    int
+	MaxBytes   int64
+	OnExceeded func(QuotaError)
+}
+
+type QuotaError struct {
+	Bucket  string
+	Key     string
+	Limit   string
+	Current int64
+	Max     int64
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("quota exceeded for bucket '%s': writing '%s' would bring %s to %d (max %d)", e.Bucket, e.Key, e.Limit, e.Current, e.Max)
+}
+
+func (e *QuotaError) Unwrap() error {
+	return errors.ErrQuotaExceeded
+}
+
+type quotaState struct {
+	quota  Quota
+	cap    Cap
+	loaded bool
+	keys   int64
+	bytes  int64
+
+	tx           *bolt.Tx
+	pendingKeys  int64
+	pendingBytes int64
+}
+
+func (db *DB) SetQuota(bucketName string, quota Quota) {
+	db.configured.Store(true)
+	db.quotaMutex.Lock()
+	defer db.quotaMutex.Unlock()
+	db.limitState(bucketName).quota = quota
+}
+
+func (db *DB) RemoveQuota(bucketName string) {
+	db.quotaMutex.Lock()
+	defer db.quotaMutex.Unlock()
+	if state, exists := db.quotas[bucketName]; exists {
+		state.quota = Quota{}
+		db.pruneLimits(bucketName, state)
+	}
+}
+
+func (db *DB) limitState(bucketName string) *quotaState {
+	state, exists := db.quotas[bucketName]
+	if !exists {
+		state = &quotaState{}
+		db.quotas[bucketName] = state
+	}
+	return state
+}
+
+func (db *DB) pruneLimits(bucketName string, state *quotaState) {
+	if state.quota.MaxKeys == 0 && state.quota.MaxBytes == 0 && state.cap == (Cap{}) {
+		delete(db.quotas, bucketName)
+	}
+}
+
+func (db *DB) GetQuota(bucketName string) (Quota, bool) {
+	db.quotaMutex.Lock()
+	defer db.quotaMutex.Unlock()
+	state, exists := db.quotas[bucketName]
+	if !exists || (state.quota.MaxKeys == 0 && state.quota.MaxBytes == 0) {
+		return Quota{}, false
+	}
+	return state.quota, true
+}
+
+func (db *DB) QuotaUsage(bucketName string) (int, int64, error) {
+	db.quotaMutex.Lock()
+	state, exists := db.quotas[bucketName]
+	if exists && state.loaded {
+		defer db.quotaMutex.Unlock()
+		return int(state.keys), state.bytes, nil
+	}
+	db.quotaMutex.Unlock()
+
+	var keyCount, byteCount int64
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrBucketMissing
+		}
+		keyCount, byteCount = measureBucket(b)
+		return nil
+	})
+	return int(keyCount), byteCount, err
+}
+
+func measureBucket(b *bolt.Bucket) (int64, int64) {
+	var keyCount, byteCount int64
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if v == nil {
+			continue
+		}
+		keyCount++
+		byteCount += int64(len(k) + len(v))
+	}
+	return keyCount, byteCount
+}
+
+func (db *DB) admit(tx *bolt.Tx, bucketName, key string, old, value []byte) error {
+	return db.account(tx, bucketName, key, old, value, true)
+}
+
+// account tracks quota usage for a write. Replicated writes pass enforce as
+// false: they are never rejected, stamped for a cap or evicted locally.
+func (db *DB) account(tx *bolt.Tx, bucketName, key string, old, value []byte, enforce bool) error {
+	db.releaseCold(tx, bucketName, key, old, value)
+	if value != nil {
+		db.bloomAdd(bucketName, key)
+	}
+
+	db.quotaMutex.Lock()
+	defer db.quotaMutex.Unlock()
+
+	state, exists := db.quotas[bucketName]
+	if !exists {
+		return nil
+	}
+	if !state.loaded {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return nil
+		}
+		state.keys, state.bytes = measureBucket(b)
+		state.loaded = true
+	}
+
+	if state.tx != tx {
+		state.tx, state.pendingKeys, state.pendingBytes = tx, 0, 0
+		capped := false
+		tx.OnCommit(func() {
+			db.quotaMutex.Lock()
+			grew := false
+			if state.tx == tx {
+				grew = state.pendingKeys > 0 || state.pendingBytes > 0
+				state.keys += state.pendingKeys
+				state.bytes += state.pendingBytes
+				state.tx, state.pendingKeys, state.pendingBytes = nil, 0, 0
+			}
+			capped = grew && state.cap != (Cap{})
+			db.quotaMutex.Unlock()
+		})
+		db.afterCommit(tx, func() {
+			if !capped || !enforce {
+				return
+			}
+			if err := db.enforceCap(bucketName); err != nil {
+				logger.Error("failed to enforce cap on bucket '%s': %v", bucketName, err)
+			}
+		})
+	}
+
+	var keyDelta, byteDelta int64
+	if old != nil {
+		keyDelta--
+		byteDelta -= int64(len(key) + len(old))
+	}
+	if value != nil {
+		keyDelta++
+		byteDelta += int64(len(key) + len(value))
+	}
+
+	keyCount := state.keys + state.pendingKeys + keyDelta
+	byteCount := state.bytes + state.pendingBytes + byteDelta
+
+	quota := state.quota
+	var violation *QuotaError
+	switch {
+	case quota.MaxKeys > 0 && keyDelta > 0 && keyCount > int64(quota.MaxKeys):
+		violation = &QuotaError{Bucket: bucketName, Key: key, Limit: QuotaKeys, Current: keyCount, Max: int64(quota.MaxKeys)}
+	case quota.MaxBytes > 0 && byteDelta > 0 && byteCount > quota.MaxBytes:
+		violation = &QuotaError{Bucket: bucketName, Key: key, Limit: QuotaBytes, Current: byteCount, Max: quota.MaxBytes}
+	}
+	if violation != nil && enforce {
+		if quota.OnExceeded != nil {
+			go quota.OnExceeded(*violation)
+		}
+		return violation
+	}
+
+	if enforce && value != nil && old == nil && state.cap != (Cap{}) {
+		if err := stampCreated(tx, bucketName, key, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	state.pendingKeys += keyDelta
+	state.pendingBytes += byteDelta
+	return nil
+}
+
+func (db *DB) resetQuotaUsage(tx *bolt.Tx, bucketName string) {
+	tx.OnCommit(func() {
+		db.quotaMutex.Lock()
+		defer db.quotaMutex.Unlock()
+		if state, exists := db.quotas[bucketName]; exists {
+			state.loaded, state.tx = false, nil
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	err "errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/logger"
+)
+
+func openLimited(t *testing.T, name string) *DB {
+	t.Helper()
+	logger.DisableLogging()
+
+	if err := Connect(name, filepath.Join(t.TempDir(), "main.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Close(name) })
+
+	db, err := GetNamed(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateBucket("items"); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestQuotaRejectsNewKeysOnly(t *testing.T) {
+	db := openLimited(t, "quota-keys")
+	db.SetQuota("items", Quota{MaxKeys: 2})
+
+	for _, key := range []string{"a", "b"} {
+		if err := db.Put("items", key, map[string]string{"name": key}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	putErr := db.Put("items", "c", map[string]string{"name": "c"})
+	var quotaErr *QuotaError
+	if !err.As(putErr, &quotaErr) || !err.Is(putErr, errors.ErrQuotaExceeded) {
+		t.Fatalf("expected a QuotaError, got %v", putErr)
+	}
+	if quotaErr.Limit != QuotaKeys || quotaErr.Current != 3 || quotaErr.Max != 2 {
+		t.Fatalf("unexpected quota error %+v", quotaErr)
+	}
+
+	if err := db.Put("items", "a", map[string]string{"name": "updated"}); err != nil {
+		t.Fatalf("updating an existing key was rejected: %v", err)
+	}
+	if err := db.Delete("items", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put("items", "c", map[string]string{"name": "c"}); err != nil {
+		t.Fatalf("write after freeing a key was rejected: %v", err)
+	}
+
+	count, _, err := db.QuotaUsage("items")
+	if err != nil || count != 2 {
+		t.Fatalf("expected 2 keys in use, got %d (%v)", count, err)
+	}
+}
+
+func TestQuotaRollsBackFailedTransactions(t *testing.T) {
+	db := openLimited(t, "quota-rollback")
+	db.SetQuota("items", Quota{MaxKeys: 2})
+
+	atomicErr := db.Atomic(func(tx *Tx) error {
+		for i := 0; i < 3; i++ {
+			if err := tx.Put("items", fmt.Sprintf("k%d", i), i); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if !err.Is(atomicErr, errors.ErrQuotaExceeded) {
+		t.Fatalf("expected the batch to exceed the quota, got %v", atomicErr)
+	}
+
+	count, _, err := db.QuotaUsage("items")
+	if err != nil || count != 0 {
+		t.Fatalf("rolled back batch left %d keys counted (%v)", count, err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := db.Put("items", fmt.Sprintf("k%d", i), i); err != nil {
+			t.Fatalf("write %d after a rolled back batch was rejected: %v", i, err)
+		}
+	}
+}
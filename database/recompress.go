@@ -0,0 +1,296 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andr1ww/odin/internal/compression"
+	"github.com/andr1ww/odin/internal/logger"
+	bolt "go.etcd.io/bbolt"
+)
+
+const defaultRecompressBatch = 200
+
+type RecompressOptions struct {
+	Buckets       []string
+	RatePerSecond int
+	BatchSize     int
+	UpgradeLegacy bool
+	OnProgress    func(RecompressProgress)
+}
+
+type RecompressProgress struct {
+	Bucket     string `json:"bucket"`
+	Scanned    int    `json:"scanned"`
+	Rewritten  int    `json:"rewritten"`
+	BytesSaved int64  `json:"bytes_saved"`
+	Done       bool   `json:"done"`
+
+	total int
+}
+
+type RecompressJob struct {
+	db     *DB
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mutex    sync.Mutex
+	progress []RecompressProgress
+	err      error
+}
+
+func (db *DB) StartRecompression(ctx context.Context, opts RecompressOptions) *RecompressJob {
+	ctx, cancel := context.WithCancel(ctx)
+	job := &RecompressJob{db: db, cancel: cancel, done: make(chan struct{})}
+
+	started := db.goBackground(func() {
+		defer close(job.done)
+		defer cancel()
+
+		go func() {
+			select {
+			case <-db.done:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		job.finish(job.run(ctx, opts))
+	})
+	if !started {
+		cancel()
+		job.err = context.Canceled
+		close(job.done)
+	}
+	return job
+}
+
+func (db *DB) Recompress(ctx context.Context, opts RecompressOptions) ([]RecompressProgress, error) {
+	return db.StartRecompression(ctx, opts).Wait()
+}
+
+func (db *DB) UpgradeFormats(ctx context.Context, opts RecompressOptions) ([]RecompressProgress, error) {
+	opts.UpgradeLegacy = true
+	return db.Recompress(ctx, opts)
+}
+
+func (j *RecompressJob) Cancel() {
+	j.cancel()
+}
+
+func (j *RecompressJob) Done() <-chan struct{} {
+	return j.done
+}
+
+func (j *RecompressJob) Wait() ([]RecompressProgress, error) {
+	<-j.done
+	return j.Progress(), j.err
+}
+
+func (j *RecompressJob) Progress() []RecompressProgress {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return append([]RecompressProgress(nil), j.progress...)
+}
+
+func (j *RecompressJob) finish(err error) {
+	j.mutex.Lock()
+	j.err = err
+	j.mutex.Unlock()
+
+	if err != nil {
+		logger.Warning("recompression of database '%s' stopped: %v", j.db.name, err)
+		return
+	}
+
+	var rewritten int
+	var saved int64
+	for _, p := range j.Progress() {
+		rewritten += p.Rewritten
+		saved += p.BytesSaved
+	}
+	logger.Success("recompression of database '%s' finished: rewrote %d records, saved %d bytes", j.db.name, rewritten, saved)
+}
+
+func (j *RecompressJob) report(progress RecompressProgress, index int, fn func(RecompressProgress)) {
+	j.mutex.Lock()
+	if index == len(j.progress) {
+		j.progress = append(j.progress, progress)
+	} else {
+		j.progress[index] = progress
+	}
+	j.mutex.Unlock()
+
+	if fn != nil {
+		fn(progress)
+	}
+	j.db.ReportProgress(Progress{Operation: OpRecompress, Bucket: progress.Bucket, Done: progress.Scanned, Total: progress.total, Finished: progress.Done})
+}
+
+func (j *RecompressJob) run(ctx context.Context, opts RecompressOptions) error {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultRecompressBatch
+	}
+
+	buckets := opts.Buckets
+	if len(buckets) == 0 {
+		all, err := j.db.ListBuckets()
+		if err != nil {
+			return err
+		}
+		for _, name := range all {
+			if !strings.HasPrefix(name, "__") {
+				buckets = append(buckets, name)
+			}
+		}
+	}
+
+	for i, bucketName := range buckets {
+		total, _ := j.db.Count(bucketName)
+		progress := RecompressProgress{Bucket: bucketName, total: total}
+		if !j.db.CompressionEnabled(bucketName) && !opts.UpgradeLegacy {
+			progress.Done = true
+			j.report(progress, i, opts.OnProgress)
+			continue
+		}
+
+		var after []byte
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			start := time.Now()
+			batch, err := j.db.readRaw(bucketName, after, opts.BatchSize)
+			if err != nil {
+				return err
+			}
+			if len(batch) == 0 {
+				break
+			}
+			after = []byte(batch[len(batch)-1].key)
+
+			rewritten, saved, err := j.db.recompressBatch(bucketName, batch, opts.UpgradeLegacy)
+			if err != nil {
+				return err
+			}
+			progress.Scanned += len(batch)
+			progress.Rewritten += rewritten
+			progress.BytesSaved += saved
+			j.report(progress, i, opts.OnProgress)
+
+			if err := throttle(ctx, start, len(batch), opts.RatePerSecond); err != nil {
+				return err
+			}
+			if err := j.db.YieldToForeground(ctx, JobRecompress); err != nil {
+				return err
+			}
+		}
+
+		progress.Done = true
+		j.report(progress, i, opts.OnProgress)
+	}
+	return nil
+}
+
+type rawEntry struct {
+	key   string
+	value []byte
+}
+
+func (db *DB) readRaw(bucketName string, after []byte, limit int) ([]rawEntry, error) {
+	batch := make([]rawEntry, 0, limit)
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return nil
+		}
+
+		c := b.Cursor()
+		k, v := c.First()
+		if after != nil {
+			k, v = c.Seek(after)
+			if k != nil && bytes.Equal(k, after) {
+				k, v = c.Next()
+			}
+		}
+		for ; k != nil && len(batch) < limit; k, v = c.Next() {
+			if v == nil {
+				continue
+			}
+			batch = append(batch, rawEntry{key: string(k), value: append([]byte(nil), v...)})
+		}
+		return nil
+	})
+	return batch, err
+}
+
+func (db *DB) recompressBatch(bucketName string, batch []rawEntry, upgrade bool) (int, int64, error) {
+	rewrites := make(map[string][]byte)
+	for _, entry := range batch {
+		if len(entry.value) == 0 || isColdStub(entry.value) {
+			continue
+		}
+		legacy := upgrade && compression.IsLegacy(entry.value)
+		if legacy {
+			if _, decodeErr := compression.Decompress(entry.value); decodeErr != nil {
+				logger.Warning("leaving undecodable legacy value %s/%s in place: %v", bucketName, entry.key, decodeErr)
+				continue
+			}
+		}
+		if encoded := db.encode(bucketName, compression.DecompressData(entry.value)); legacy || len(encoded) < len(entry.value) {
+			rewrites[entry.key] = encoded
+		}
+	}
+	if len(rewrites) == 0 {
+		return 0, 0, nil
+	}
+
+	rewritten := 0
+	var saved int64
+	err := db.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return nil
+		}
+
+		for _, entry := range batch {
+			encoded, exists := rewrites[entry.key]
+			if !exists || !bytes.Equal(b.Get([]byte(entry.key)), entry.value) {
+				continue
+			}
+			if err := db.admit(tx, bucketName, entry.key, entry.value, encoded); err != nil {
+				return err
+			}
+			if err := b.Put([]byte(entry.key), encoded); err != nil {
+				return err
+			}
+			rewritten++
+			saved += int64(len(entry.value) - len(encoded))
+		}
+		return nil
+	})
+	return rewritten, saved, err
+}
+
+func throttle(ctx context.Context, start time.Time, records, rate int) error {
+	if rate <= 0 {
+		return ctx.Err()
+	}
+
+	wait := time.Duration(records)*time.Second/time.Duration(rate) - time.Since(start)
+	if wait <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
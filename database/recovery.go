@@ -0,0 +1,196 @@
+package database
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/logger"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	backupSuffix   = ".backup"
+	tempSuffix     = "_temp.db"
+	journalSuffix  = ".compact"
+	journalTimeout = 100 * time.Millisecond
+)
+
+var (
+	journalBucket    = []byte("compaction")
+	journalTempKey   = []byte("temp")
+	journalBackupKey = []byte("backup")
+)
+
+func compactTempPath(dbPath, tempDir string) string {
+	dir := tempDir
+	if dir == "" {
+		dir = filepath.Dir(dbPath)
+	}
+
+	base := filepath.Base(dbPath)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	return filepath.Join(dir, base+tempSuffix)
+}
+
+func maintenancePath(dbPath, dir, suffix string) string {
+	if dir == "" {
+		dir = filepath.Dir(dbPath)
+	}
+	return filepath.Join(dir, filepath.Base(dbPath)+suffix)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func compactJournalPath(dbPath, dir string) string {
+	return maintenancePath(dbPath, dir, journalSuffix)
+}
+
+func beginCompaction(options Options, dbPath, tempPath, backupPath string) (*bolt.DB, error) {
+	journalOptions := boltOptions(options)
+	journalOptions.Timeout = journalTimeout
+	journal, err := bolt.Open(compactJournalPath(dbPath, options.MaintenanceDir), 0600, journalOptions)
+	if err != nil {
+		if err == bolt.ErrTimeout {
+			return nil, fmt.Errorf("%w: another compaction of %s is running", errors.ErrDatabaseLocked, dbPath)
+		}
+		return nil, err
+	}
+
+	err = journal.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(journalBucket)
+		if err != nil {
+			return err
+		}
+		if err := b.Put(journalTempKey, []byte(tempPath)); err != nil {
+			return err
+		}
+		return b.Put(journalBackupKey, []byte(backupPath))
+	})
+	if err != nil {
+		finishCompaction(journal)
+		return nil, err
+	}
+	return journal, nil
+}
+
+func finishCompaction(journal *bolt.DB) {
+	path := journal.Path()
+	journal.Close()
+	os.Remove(path)
+}
+
+func recoverMaintenanceFiles(options Options, name, dbPath string) error {
+	dirs := []string{""}
+	if dir := options.MaintenanceDir; dir != "" && !samePath(dir, filepath.Dir(dbPath)) {
+		dirs = append(dirs, dir)
+	}
+
+	for _, dir := range dirs {
+		if err := recoverCompaction(options, name, dbPath, compactJournalPath(dbPath, dir)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func recoverCompaction(options Options, name, dbPath, journalPath string) error {
+	if !fileExists(journalPath) {
+		return nil
+	}
+
+	journalOptions := boltOptions(options)
+	journalOptions.Timeout = journalTimeout
+	journal, err := bolt.Open(journalPath, 0600, journalOptions)
+	if err == bolt.ErrTimeout {
+		return fmt.Errorf("%w: %s is being compacted by another process", errors.ErrDatabaseLocked, dbPath)
+	}
+	if err != nil {
+		return fmt.Errorf("open compaction journal %s: %w", journalPath, err)
+	}
+	defer finishCompaction(journal)
+
+	var tempPath, backupPath string
+	journal.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket(journalBucket); b != nil {
+			tempPath = string(b.Get(journalTempKey))
+			backupPath = string(b.Get(journalBackupKey))
+		}
+		return nil
+	})
+
+	if backupPath != "" && fileExists(backupPath) {
+		if !fileExists(dbPath) {
+			if err := moveFile(backupPath, dbPath); err != nil {
+				return fmt.Errorf("restore %s from interrupted compaction: %w", dbPath, err)
+			}
+			logger.Warning("restored database '%s' from backup left by an interrupted compaction", name)
+		} else {
+			if err := os.Remove(backupPath); err != nil {
+				return fmt.Errorf("remove stale backup %s: %w", backupPath, err)
+			}
+			logger.Warning("removed stale compaction backup %s", backupPath)
+		}
+	}
+
+	if tempPath != "" && fileExists(tempPath) && !samePath(tempPath, dbPath) {
+		if err := os.Remove(tempPath); err != nil {
+			return fmt.Errorf("remove stale temp file %s: %w", tempPath, err)
+		}
+		logger.Warning("removed stale compaction temp file %s", tempPath)
+	}
+
+	return nil
+}
+
+func samePath(a, b string) bool {
+	return normalizePath(a) == normalizePath(b)
+}
+
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	staging := dst + ".tmp"
+	if err := copyFile(src, staging); err != nil {
+		os.Remove(staging)
+		return err
+	}
+
+	if err := os.Rename(staging, dst); err != nil {
+		os.Remove(staging)
+		return err
+	}
+	return os.Remove(src)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.OpenFile(src, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,230 @@
+package database
+
+import (
+	err "errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/compression"
+	"github.com/andr1ww/odin/internal/keys"
+	"github.com/andr1ww/odin/internal/logger"
+	bolt "go.etcd.io/bbolt"
+)
+
+const trashPrefix = "__trash_"
+
+type TrashEntry struct {
+	Key       string
+	DeletedAt time.Time
+}
+
+func TrashBucketName(bucketName string) string {
+	return trashPrefix + bucketName
+}
+
+func isTrashBucket(bucketName string) bool {
+	return strings.HasPrefix(bucketName, trashPrefix)
+}
+
+func (db *DB) EnableRecycleBin(retention time.Duration) {
+	db.configured.Store(true)
+	db.DisableRecycleBin()
+
+	stop := make(chan struct{})
+	db.recycleMutex.Lock()
+	db.recycleRetention = retention
+	db.recycleStop = stop
+	db.recycleMutex.Unlock()
+
+	if retention <= 0 {
+		return
+	}
+
+	interval := retention / 2
+	if interval > time.Hour {
+		interval = time.Hour
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	db.goBackground(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := db.PurgeTrash(); err != nil {
+					logger.Error("purging recycle bin of database '%s' failed: %v", db.name, err)
+				}
+			case <-stop:
+				return
+			case <-db.done:
+				return
+			}
+		}
+	})
+}
+
+func (db *DB) DisableRecycleBin() {
+	db.recycleMutex.Lock()
+	defer db.recycleMutex.Unlock()
+
+	if db.recycleStop != nil {
+		close(db.recycleStop)
+		db.recycleStop = nil
+	}
+	db.recycleRetention = 0
+}
+
+func (db *DB) recycleBinEnabled() bool {
+	db.recycleMutex.Lock()
+	defer db.recycleMutex.Unlock()
+	return db.recycleStop != nil
+}
+
+func moveToTrash(tx *bolt.Tx, bucketName string, key, value []byte) error {
+	if value == nil {
+		return nil
+	}
+
+	trash, err := tx.CreateBucketIfNotExists([]byte(TrashBucketName(bucketName)))
+	if err != nil {
+		return fmt.Errorf("create trash bucket for %s: %w", bucketName, err)
+	}
+
+	entry := make([]byte, keys.TimeSize+len(value))
+	copy(entry, keys.EncodeTime(time.Now()))
+	copy(entry[keys.TimeSize:], value)
+	return trash.Put(key, entry)
+}
+
+func (db *DB) Restore(bucketName string, key string) error {
+	if key == "" {
+		return err.New("key cannot be empty")
+	}
+
+	observed := db.hasObservers(bucketName)
+
+	return db.update(func(tx *bolt.Tx) error {
+		trash := tx.Bucket([]byte(TrashBucketName(bucketName)))
+		if trash == nil {
+			return errors.ErrNotFound
+		}
+
+		entry := trash.Get([]byte(key))
+		if len(entry) < keys.TimeSize {
+			return errors.ErrNotFound
+		}
+
+		b, err := tx.CreateBucketIfNotExists([]byte(bucketName))
+		if err != nil {
+			return fmt.Errorf("create bucket %s: %w", bucketName, err)
+		}
+		if b.Get([]byte(key)) != nil {
+			return fmt.Errorf("cannot restore '%s': key already exists in bucket '%s'", key, bucketName)
+		}
+
+		value := make([]byte, len(entry)-keys.TimeSize)
+		copy(value, entry[keys.TimeSize:])
+		if err := db.admit(tx, bucketName, key, nil, value); err != nil {
+			return err
+		}
+		if err := bumpVersion(tx, bucketName, key); err != nil {
+			return err
+		}
+		if err := logChange(tx, bucketName, key, time.Now()); err != nil {
+			return err
+		}
+		if observed {
+			if err := db.stageChange(tx, bucketName, key, nil, compression.DecompressData(value), ChangeInsert); err != nil {
+				return err
+			}
+		}
+		if err := b.Put([]byte(key), value); err != nil {
+			return err
+		}
+		return trash.Delete([]byte(key))
+	})
+}
+
+func (db *DB) ListTrash(bucketName string) ([]TrashEntry, error) {
+	var entries []TrashEntry
+
+	err := db.View(func(tx *bolt.Tx) error {
+		trash := tx.Bucket([]byte(TrashBucketName(bucketName)))
+		if trash == nil {
+			return nil
+		}
+
+		return trash.ForEach(func(k, v []byte) error {
+			if len(v) < keys.TimeSize {
+				return nil
+			}
+			entries = append(entries, TrashEntry{Key: string(k), DeletedAt: keys.DecodeTime(v[:keys.TimeSize])})
+			return nil
+		})
+	})
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DeletedAt.After(entries[j].DeletedAt)
+	})
+	return entries, err
+}
+
+func (db *DB) EmptyTrash(bucketName string) error {
+	return db.update(func(tx *bolt.Tx) error {
+		name := []byte(TrashBucketName(bucketName))
+		if tx.Bucket(name) == nil {
+			return nil
+		}
+		return tx.DeleteBucket(name)
+	})
+}
+
+func (db *DB) PurgeTrash() (int, error) {
+	db.recycleMutex.Lock()
+	retention := db.recycleRetention
+	db.recycleMutex.Unlock()
+
+	if retention <= 0 {
+		return 0, nil
+	}
+
+	cutoff := time.Now().Add(-retention)
+	var purged int
+
+	err := db.update(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, trash *bolt.Bucket) error {
+			if !isTrashBucket(string(name)) {
+				return nil
+			}
+
+			c := trash.Cursor()
+			for k, v := c.First(); k != nil; {
+				if len(v) >= keys.TimeSize && keys.DecodeTime(v[:keys.TimeSize]).Before(cutoff) {
+					if err := c.Delete(); err != nil {
+						return err
+					}
+					purged++
+					k, v = c.Seek(k)
+					continue
+				}
+				k, v = c.Next()
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return purged, err
+	}
+
+	if purged > 0 {
+		logger.Success("Purged %d expired records from recycle bin of database '%s'", purged, db.name)
+	}
+	return purged, nil
+}
@@ -0,0 +1,202 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/logger"
+	"github.com/andr1ww/odin/internal/reflection"
+	bolt "go.etcd.io/bbolt"
+)
+
+type ReopenPolicy struct {
+	Interval time.Duration
+	Attempts int
+	Backoff  time.Duration
+}
+
+func (p ReopenPolicy) withDefaults() ReopenPolicy {
+	if p.Attempts <= 0 {
+		p.Attempts = 5
+	}
+	if p.Backoff <= 0 {
+		p.Backoff = 100 * time.Millisecond
+	}
+	return p
+}
+
+func SetAutoReopen(policy ReopenPolicy) {
+	manager.mutex.Lock()
+	if manager.healthStop != nil {
+		close(manager.healthStop)
+		manager.healthStop = nil
+	}
+
+	if policy.Interval <= 0 {
+		manager.mutex.Unlock()
+		return
+	}
+
+	stop := make(chan struct{})
+	manager.healthStop = stop
+	manager.mutex.Unlock()
+
+	policy = policy.withDefaults()
+	go func() {
+		ticker := time.NewTicker(policy.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				checkHealth(policy, stop)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func checkHealth(policy ReopenPolicy, stop chan struct{}) {
+	for name, db := range GetAll() {
+		healthErr := db.Health()
+		if healthErr == nil {
+			continue
+		}
+
+		logger.Warning("database '%s' failed its health check: %v", name, healthErr)
+		emitUnhealthy(name, healthErr)
+
+		backoff := policy.Backoff
+		var reopenErr error
+		for attempt := 1; attempt <= policy.Attempts; attempt++ {
+			if reopenErr = db.Reopen(); reopenErr == nil {
+				logger.Success("database '%s' reopened after %d attempt(s)", name, attempt)
+				emitReopen(name, db)
+				break
+			}
+			if attempt == policy.Attempts {
+				break
+			}
+			select {
+			case <-time.After(backoff):
+			case <-stop:
+				return
+			}
+			backoff *= 2
+		}
+
+		if reopenErr != nil {
+			reopenErr = fmt.Errorf("%w: '%s' after %d attempts: %v", errors.ErrReopenFailed, name, policy.Attempts, reopenErr)
+			logger.Error("%v", reopenErr)
+			emitUnhealthy(name, reopenErr)
+		}
+	}
+}
+
+func (db *DB) Reopen() error {
+	db.reopenMutex.Lock()
+	defer db.reopenMutex.Unlock()
+
+	path := db.Path()
+	if _, statErr := os.Stat(path); statErr != nil {
+		return fmt.Errorf("%w: %v", errors.ErrDatabaseReplaced, statErr)
+	}
+
+	swapErr := db.swapHandle(func() error {
+		if closeErr := db.DB.Close(); closeErr != nil {
+			logger.Warning("closing database '%s' before reopen: %v", db.name, closeErr)
+		}
+		if reopenErr := db.reopen(path); reopenErr != nil {
+			return reopenErr
+		}
+		if !db.readOnly.Load() {
+			return reflection.FindAndInitBuckets(db.DB, db.name)
+		}
+		return nil
+	})
+	if swapErr != nil {
+		return swapErr
+	}
+	db.resetDerivedState()
+	return nil
+}
+
+func (db *DB) handle() *bolt.DB {
+	db.handleMutex.RLock()
+	defer db.handleMutex.RUnlock()
+	return db.DB
+}
+
+func (db *DB) swapHandle(swap func() error) error {
+	db.handleMutex.Lock()
+	defer db.handleMutex.Unlock()
+	return swap()
+}
+
+func (db *DB) View(fn func(*bolt.Tx) error) error {
+	db.handleMutex.RLock()
+	defer db.handleMutex.RUnlock()
+	return db.DB.View(fn)
+}
+
+func (db *DB) rawUpdate(fn func(*bolt.Tx) error) error {
+	var tx *bolt.Tx
+	updateErr := func() error {
+		db.writeMutex.RLock()
+		defer db.writeMutex.RUnlock()
+		db.handleMutex.RLock()
+		defer db.handleMutex.RUnlock()
+		return db.DB.Update(func(current *bolt.Tx) error {
+			tx = current
+			return fn(current)
+		})
+	}()
+	db.runFollowUps(tx, updateErr == nil)
+	return updateErr
+}
+
+func (db *DB) Begin(writable bool) (*bolt.Tx, error) {
+	return db.handle().Begin(writable)
+}
+
+func (db *DB) Path() string {
+	return db.handle().Path()
+}
+
+func (db *DB) IsReadOnly() bool {
+	return db.readOnly.Load()
+}
+
+func (db *DB) recordFileIdentity() {
+	if info, statErr := os.Stat(db.DB.Path()); statErr == nil {
+		db.fileInfo.Store(&info)
+	}
+}
+
+func (db *DB) checkFileIdentity() error {
+	opened := db.fileInfo.Load()
+	if opened == nil || (*opened).Sys() == nil {
+		return nil
+	}
+
+	current, statErr := os.Stat(db.Path())
+	if statErr != nil {
+		return fmt.Errorf("%w: %v", errors.ErrDatabaseReplaced, statErr)
+	}
+	if !os.SameFile(*opened, current) {
+		return errors.ErrDatabaseReplaced
+	}
+	return nil
+}
+
+func (db *DB) resetDerivedState() {
+	db.quotaMutex.Lock()
+	for _, state := range db.quotas {
+		state.loaded, state.tx = false, nil
+	}
+	db.quotaMutex.Unlock()
+	db.rebuildBloomFilters()
+}
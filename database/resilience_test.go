@@ -0,0 +1,101 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andr1ww/odin/internal/logger"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestReopenDuringScansAndCappedWrites(t *testing.T) {
+	logger.DisableLogging()
+	name := "reopen-concurrent"
+
+	if err := Connect(name, filepath.Join(t.TempDir(), "main.db"), WithQuarantine(true)); err != nil {
+		t.Fatal(err)
+	}
+	defer Close(name)
+
+	db, err := GetNamed(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateBucket("items"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte("items")).Put([]byte("broken"), []byte("{not json"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetCap("items", Cap{MaxRecords: 20}); err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	failures := make(chan error, 3)
+	var wg sync.WaitGroup
+	run := func(work func(i int) error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if err := work(i); err != nil {
+					failures <- err
+					return
+				}
+			}
+		}()
+	}
+
+	run(func(i int) error {
+		_, err := db.FindDocs("items", nil)
+		return err
+	})
+	run(func(i int) error {
+		return db.Put("items", fmt.Sprintf("key-%d", i), map[string]int{"n": i})
+	})
+	run(func(i int) error {
+		if i%4 == 3 {
+			return db.Compact()
+		}
+		return db.Reopen()
+	})
+
+	finished := make(chan struct{})
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		close(stop)
+		wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-time.After(20 * time.Second):
+		t.Fatal("reopen deadlocked against concurrent scans and capped writes")
+	}
+
+	select {
+	case err := <-failures:
+		t.Fatal(err)
+	default:
+	}
+
+	count, _, err := db.QuotaUsage("items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count > 20 {
+		t.Fatalf("capped bucket holds %d records, want at most 20", count)
+	}
+}
@@ -0,0 +1,156 @@
+package database
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/indexing"
+	"github.com/andr1ww/odin/internal/logger"
+	"github.com/andr1ww/odin/internal/reflection"
+)
+
+type RestoreOptions struct {
+	Overwrite bool
+	Key       []byte
+}
+
+func Restore(name, backupPath string, opts RestoreOptions) error {
+	manager.mutex.RLock()
+	if name == "" {
+		name = manager.defaultDB
+	}
+	name = resolveAlias(name)
+	db, connected := manager.databases[name]
+	declaration, declared := manager.declarations[name]
+	manager.mutex.RUnlock()
+
+	var dbPath string
+	var options Options
+	switch {
+	case name == "":
+		return errors.ErrNoDefaultDatabase
+	case connected:
+		dbPath, options = db.Path(), db.options
+	case declared:
+		dbPath, options = declaration.path, declaration.options
+	default:
+		return fmt.Errorf("database '%s' not found", name)
+	}
+
+	if fileExists(dbPath) && !opts.Overwrite {
+		return fmt.Errorf("%w: restoring '%s' would overwrite %s", errors.ErrDatabaseExists, name, dbPath)
+	}
+
+	stagedPath := maintenancePath(dbPath, options.MaintenanceDir, ".restore")
+	if stageErr := stageBackup(options.fs(), backupPath, stagedPath, opts.Key); stageErr != nil {
+		os.Remove(stagedPath)
+		return stageErr
+	}
+
+	report := &BackupReport{Path: backupPath}
+	if verifyErr := verifySnapshot(stagedPath, report); verifyErr != nil {
+		os.Remove(stagedPath)
+		return verifyErr
+	}
+	if !report.OK() {
+		os.Remove(stagedPath)
+		return fmt.Errorf("%w: %s", errors.ErrBackupInvalid, report)
+	}
+
+	if !connected {
+		var getErr error
+		if db, getErr = GetNamed(name); getErr != nil {
+			os.Remove(stagedPath)
+			return getErr
+		}
+	}
+	if replaceErr := db.replaceFile(stagedPath); replaceErr != nil {
+		os.Remove(stagedPath)
+		return fmt.Errorf("restore database '%s': %w", name, replaceErr)
+	}
+	emitRestore(name, db)
+
+	logger.Success("database '%s' restored from %s (%d records)", name, backupPath, report.Records)
+	return nil
+}
+
+// replaceFile swaps the file under db for the one at stagedPath while keeping
+// the *DB itself, so its runtime configuration and every holder stay valid.
+func (db *DB) replaceFile(stagedPath string) error {
+	path := db.Path()
+	previousPath := maintenancePath(path, db.options.MaintenanceDir, ".pre-restore")
+	diskIndexes := indexing.DiskIndexes(db.name)
+
+	swapErr := db.swapHandle(func() error {
+		if closeErr := db.DB.Close(); closeErr != nil {
+			return fmt.Errorf("close %s: %w", path, closeErr)
+		}
+		if moveErr := moveFile(path, previousPath); moveErr != nil {
+			db.reopen(path)
+			return fmt.Errorf("set aside %s: %w", path, moveErr)
+		}
+		if moveErr := moveFile(stagedPath, path); moveErr != nil {
+			moveFile(previousPath, path)
+			db.reopen(path)
+			return fmt.Errorf("replace %s: %w", path, moveErr)
+		}
+		if reopenErr := db.reopen(path); reopenErr != nil {
+			os.Remove(path)
+			moveFile(previousPath, path)
+			if rollbackErr := db.reopen(path); rollbackErr != nil {
+				return fmt.Errorf("reopen %s: %w (restoring the previous file failed: %v)", path, reopenErr, rollbackErr)
+			}
+			return fmt.Errorf("reopen %s: %w", path, reopenErr)
+		}
+		if !db.readOnly.Load() {
+			if initErr := reflection.FindAndInitBuckets(db.DB, db.name); initErr != nil {
+				logger.Warning("failed to initialize buckets of database '%s': %v", db.name, initErr)
+			}
+		}
+		return nil
+	})
+	if swapErr != nil {
+		return swapErr
+	}
+	os.Remove(previousPath)
+
+	db.resetDerivedState()
+	for bucketName, cacheEntries := range diskIndexes {
+		if indexErr := db.EnableDiskIndex(bucketName, cacheEntries); indexErr != nil {
+			logger.Warning("failed to re-enable disk index of '%s': %v", bucketName, indexErr)
+		}
+	}
+	return nil
+}
+
+func stageBackup(filesystem FS, backupPath, stagedPath string, key []byte) error {
+	source, openErr := filesystem.OpenFile(backupPath, os.O_RDONLY, 0)
+	if openErr != nil {
+		return openErr
+	}
+	defer source.Close()
+
+	reader, closeFn, archiveErr := openArchive(source, key)
+	if archiveErr != nil {
+		return archiveErr
+	}
+	defer closeFn()
+
+	staged, createErr := os.OpenFile(stagedPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if createErr != nil {
+		return createErr
+	}
+	_, copyErr := io.Copy(staged, reader)
+	if copyErr == nil {
+		copyErr = staged.Sync()
+	}
+	if closeErr := staged.Close(); copyErr == nil {
+		copyErr = closeErr
+	}
+	if copyErr != nil {
+		return fmt.Errorf("read backup: %w", copyErr)
+	}
+	return nil
+}
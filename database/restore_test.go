@@ -0,0 +1,72 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/andr1ww/odin/internal/logger"
+)
+
+func TestRestoreKeepsRunningDatabase(t *testing.T) {
+	logger.DisableLogging()
+	dir := t.TempDir()
+	name := "restore-in-place"
+
+	if err := Connect(name, filepath.Join(dir, "main.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer Close(name)
+
+	db, err := GetNamed(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateBucket("items"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put("items", "before", map[string]string{"name": "before"}); err != nil {
+		t.Fatal(err)
+	}
+
+	backup := filepath.Join(dir, "main.db.bak")
+	if err := db.Backup(backup); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put("items", "after", map[string]string{"name": "after"}); err != nil {
+		t.Fatal(err)
+	}
+
+	inserted := 0
+	db.RegisterTrigger("items", Trigger{
+		OnInsert: func(key string, value interface{}) error {
+			inserted++
+			return nil
+		},
+	})
+	db.SetQuota("items", Quota{MaxKeys: 2})
+
+	if err := Restore(name, backup, RestoreOptions{Overwrite: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := GetNamed(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored != db {
+		t.Fatal("restore replaced the *DB instead of swapping its handle")
+	}
+	if exists, err := db.Has("items", "after"); err != nil || exists {
+		t.Fatalf("expected 'after' to be gone after restore, got %v (%v)", exists, err)
+	}
+
+	if err := db.Put("items", "second", map[string]string{"name": "second"}); err != nil {
+		t.Fatal(err)
+	}
+	if inserted != 1 {
+		t.Fatalf("trigger fired %d times after restore, want 1", inserted)
+	}
+	if err := db.Put("items", "third", map[string]string{"name": "third"}); err == nil {
+		t.Fatal("quota set before restore was not enforced afterwards")
+	}
+}
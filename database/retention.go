@@ -0,0 +1,220 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/compression"
+	"github.com/andr1ww/odin/internal/logger"
+	bolt "go.etcd.io/bbolt"
+)
+
+type RetentionPolicy struct {
+	MaxAge          time.Duration
+	MaxKeys         int
+	ArchiveDatabase string
+}
+
+type RetentionReport struct {
+	Bucket   string
+	Archived int
+	Deleted  int
+}
+
+type retentionRecord struct {
+	key       string
+	createdAt time.Time
+}
+
+func (db *DB) SetRetention(bucketName string, policy RetentionPolicy) {
+	db.configured.Store(true)
+	db.retentionMutex.Lock()
+	defer db.retentionMutex.Unlock()
+	db.retention[bucketName] = policy
+
+	if policy.ArchiveDatabase != "" {
+		DependsOn(db.name, policy.ArchiveDatabase)
+	}
+}
+
+func (db *DB) RemoveRetention(bucketName string) {
+	db.retentionMutex.Lock()
+	defer db.retentionMutex.Unlock()
+	delete(db.retention, bucketName)
+}
+
+func (db *DB) GetRetention(bucketName string) (RetentionPolicy, bool) {
+	db.retentionMutex.Lock()
+	defer db.retentionMutex.Unlock()
+	policy, exists := db.retention[bucketName]
+	return policy, exists
+}
+
+func (db *DB) ApplyRetention(bucketName string) (*RetentionReport, error) {
+	policy, exists := db.GetRetention(bucketName)
+	if !exists {
+		return nil, fmt.Errorf("no retention policy for bucket '%s'", bucketName)
+	}
+
+	expired, err := db.findExpired(bucketName, policy, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RetentionReport{Bucket: bucketName}
+	if len(expired) == 0 {
+		return report, nil
+	}
+
+	if policy.ArchiveDatabase != "" {
+		if err := db.MigrateKeys(bucketName, policy.ArchiveDatabase, expired, true); err != nil {
+			return nil, fmt.Errorf("archive expired records of '%s': %w", bucketName, err)
+		}
+		report.Archived = len(expired)
+	} else {
+		recycle := db.recycleBinEnabled() && !isTrashBucket(bucketName)
+		observed := db.hasObservers(bucketName)
+
+		err := db.update(func(tx *bolt.Tx) error {
+			b := tx.Bucket([]byte(bucketName))
+			if b == nil {
+				return errors.ErrBucketMissing
+			}
+			for _, key := range expired {
+				stored, err := db.removeKey(tx, b, bucketName, key, recycle)
+				if err != nil {
+					return err
+				}
+				if observed && stored != nil {
+					if err := db.stageChange(tx, bucketName, key, compression.DecompressData(stored), nil, ChangeExpire); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("delete expired records of '%s': %w", bucketName, err)
+		}
+		report.Deleted = len(expired)
+	}
+
+	logger.Success("Retention for bucket '%s': %d archived, %d deleted", bucketName, report.Archived, report.Deleted)
+	return report, nil
+}
+
+func (db *DB) ApplyAllRetention() ([]*RetentionReport, error) {
+	db.retentionMutex.Lock()
+	buckets := make([]string, 0, len(db.retention))
+	for bucketName := range db.retention {
+		buckets = append(buckets, bucketName)
+	}
+	db.retentionMutex.Unlock()
+	sort.Strings(buckets)
+
+	var reports []*RetentionReport
+	for _, bucketName := range buckets {
+		report, err := db.ApplyRetention(bucketName)
+		if err != nil {
+			return reports, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func (db *DB) StartRetention(interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	db.StopRetention()
+
+	stop := make(chan struct{})
+	db.retentionMutex.Lock()
+	db.retentionStop = stop
+	db.retentionMutex.Unlock()
+
+	db.goBackground(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if db.YieldToForeground(context.Background(), JobRetention) != nil {
+					return
+				}
+				if _, err := db.ApplyAllRetention(); err != nil {
+					logger.Error("retention run for database '%s' failed: %v", db.name, err)
+				}
+			case <-stop:
+				return
+			case <-db.done:
+				return
+			}
+		}
+	})
+}
+
+func (db *DB) StopRetention() {
+	db.retentionMutex.Lock()
+	defer db.retentionMutex.Unlock()
+
+	if db.retentionStop != nil {
+		close(db.retentionStop)
+		db.retentionStop = nil
+	}
+}
+
+func (db *DB) findExpired(bucketName string, policy RetentionPolicy, now time.Time) ([]string, error) {
+	var records []retentionRecord
+
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrBucketMissing
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			if v == nil {
+				return nil
+			}
+
+			var meta struct {
+				CreatedAt time.Time `json:"created_at"`
+			}
+			js.Unmarshal(compression.DecompressData(db.ColdValue(bucketName, string(k), v)), &meta)
+
+			records = append(records, retentionRecord{key: string(k), createdAt: meta.CreatedAt})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var expired []string
+	remaining := records[:0]
+	for _, record := range records {
+		if policy.MaxAge > 0 && !record.createdAt.IsZero() && now.Sub(record.createdAt) > policy.MaxAge {
+			expired = append(expired, record.key)
+			continue
+		}
+		remaining = append(remaining, record)
+	}
+
+	if policy.MaxKeys > 0 && len(remaining) > policy.MaxKeys {
+		sort.SliceStable(remaining, func(i, j int) bool {
+			return remaining[i].createdAt.Before(remaining[j].createdAt)
+		})
+		for _, record := range remaining[:len(remaining)-policy.MaxKeys] {
+			expired = append(expired, record.key)
+		}
+	}
+
+	return expired, nil
+}
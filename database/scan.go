@@ -0,0 +1,147 @@
+package database
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/pool"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	scanBatchSize  = 64
+	maxScanWorkers = 6
+)
+
+type scanRecord struct {
+	seq   int
+	key   []byte
+	value []byte
+}
+
+type scanExecutor struct {
+	once sync.Once
+	jobs chan func()
+}
+
+var scanBatchPool = pool.New("database.scan", func() *[]scanRecord {
+	batch := make([]scanRecord, 0, scanBatchSize)
+	return &batch
+}, func(batch *[]scanRecord) int {
+	return cap(*batch) * 48
+})
+
+func WithScanWorkers(workers int) Option {
+	return func(o *Options) {
+		o.ScanWorkers = workers
+	}
+}
+
+func (db *DB) ScanWorkers() int {
+	if db.options.ScanWorkers > 0 {
+		return db.options.ScanWorkers
+	}
+	return min(runtime.NumCPU(), maxScanWorkers)
+}
+
+func (db *DB) scanJobs() chan func() {
+	db.scanner.once.Do(func() {
+		db.scanner.jobs = make(chan func())
+		for i := 0; i < db.ScanWorkers(); i++ {
+			db.goBackground(func() {
+				for {
+					select {
+					case job := <-db.scanner.jobs:
+						job()
+					case <-db.done:
+						return
+					}
+				}
+			})
+		}
+	})
+	return db.scanner.jobs
+}
+
+func (db *DB) ScanParallel(bucketName string, visit func(k, v []byte) error) error {
+	return db.scanParallel(bucketName, func(_ int, k, v []byte) error {
+		return visit(k, v)
+	})
+}
+
+func (db *DB) scanParallel(bucketName string, visit func(seq int, k, v []byte) error) error {
+	release, acquireErr := db.acquire(db.limits.scans)
+	if acquireErr != nil {
+		return acquireErr
+	}
+	defer release()
+
+	jobs := db.scanJobs()
+
+	return db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrBucketMissing
+		}
+
+		var wg sync.WaitGroup
+		var failed atomic.Bool
+		var failOnce sync.Once
+		var failure error
+
+		run := func(batch *[]scanRecord) {
+			defer func() {
+				*batch = (*batch)[:0]
+				scanBatchPool.Put(batch)
+				wg.Done()
+			}()
+			for _, record := range *batch {
+				if failed.Load() {
+					return
+				}
+				value := record.value
+				if isColdStub(value) {
+					value = db.ColdValue(bucketName, string(record.key), value)
+				}
+				if visitErr := visit(record.seq, record.key, value); visitErr != nil {
+					failOnce.Do(func() { failure = visitErr })
+					failed.Store(true)
+					return
+				}
+			}
+		}
+		submit := func(batch *[]scanRecord) {
+			wg.Add(1)
+			select {
+			case jobs <- func() { run(batch) }:
+			default:
+				run(batch)
+			}
+		}
+
+		batch := scanBatchPool.Get()
+		cursor := b.Cursor()
+		seq := 0
+		for k, v := cursor.First(); k != nil && !failed.Load(); k, v = cursor.Next() {
+			if v == nil {
+				continue
+			}
+			*batch = append(*batch, scanRecord{seq: seq, key: k, value: v})
+			seq++
+			if len(*batch) == scanBatchSize {
+				submit(batch)
+				batch = scanBatchPool.Get()
+			}
+		}
+		if len(*batch) > 0 {
+			submit(batch)
+		} else {
+			scanBatchPool.Put(batch)
+		}
+
+		wg.Wait()
+		return failure
+	})
+}
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+)
+
+const (
+	JobRecompress = "recompress"
+	JobExpiry     = "expiry"
+	JobReindex    = "reindex"
+	JobRetention  = "retention"
+)
+
+const (
+	foregroundWeight   = 8
+	foregroundIdle     = time.Second
+	yieldPoll          = 10 * time.Millisecond
+	maxYieldNormal     = time.Second
+	maxYieldLow        = 10 * time.Second
+	defaultJobPriority = PriorityNormal
+)
+
+type MaintenanceStats struct {
+	Latency time.Duration
+	Busy    bool
+	Yields  map[string]int
+	Waited  map[string]time.Duration
+}
+
+type scheduler struct {
+	mutex    sync.Mutex
+	latency  time.Duration
+	lastSeen time.Time
+	yields   map[string]int
+	waited   map[string]time.Duration
+}
+
+var foregroundOps = map[string]bool{
+	OpGet:       true,
+	OpHas:       true,
+	OpPut:       true,
+	OpPutMany:   true,
+	OpDelete:    true,
+	OpGetAll:    true,
+	OpFindWhere: true,
+	OpAtomic:    true,
+	OpUpdate:    true,
+}
+
+func WithMaintenanceLatency(threshold time.Duration) Option {
+	return func(o *Options) {
+		o.MaintenanceLatency = threshold
+	}
+}
+
+func WithMaintenancePriority(job string, priority Priority) Option {
+	return func(o *Options) {
+		if o.MaintenancePriorities == nil {
+			o.MaintenancePriorities = make(map[string]Priority)
+		}
+		o.MaintenancePriorities[job] = priority
+	}
+}
+
+func (db *DB) observeForeground(op string, elapsed time.Duration) {
+	if db.options.MaintenanceLatency <= 0 || !foregroundOps[op] {
+		return
+	}
+
+	db.scheduler.mutex.Lock()
+	defer db.scheduler.mutex.Unlock()
+
+	if db.scheduler.lastSeen.IsZero() || time.Since(db.scheduler.lastSeen) > foregroundIdle {
+		db.scheduler.latency = elapsed
+	} else {
+		db.scheduler.latency += (elapsed - db.scheduler.latency) / foregroundWeight
+	}
+	db.scheduler.lastSeen = time.Now()
+}
+
+func (db *DB) foregroundLatency() time.Duration {
+	db.scheduler.mutex.Lock()
+	defer db.scheduler.mutex.Unlock()
+
+	if time.Since(db.scheduler.lastSeen) > foregroundIdle {
+		return 0
+	}
+	return db.scheduler.latency
+}
+
+func (db *DB) maintenancePriority(job string) Priority {
+	if priority, ok := db.options.MaintenancePriorities[job]; ok {
+		return priority
+	}
+	return defaultJobPriority
+}
+
+func (db *DB) foregroundBusy(priority Priority) bool {
+	threshold := db.options.MaintenanceLatency
+	if priority == PriorityLow {
+		threshold /= 2
+	}
+	return db.foregroundLatency() > threshold
+}
+
+func (db *DB) YieldToForeground(ctx context.Context, job string) error {
+	if db.options.MaintenanceLatency <= 0 {
+		return ctx.Err()
+	}
+	priority := db.maintenancePriority(job)
+	if priority >= PriorityHigh || !db.foregroundBusy(priority) {
+		return ctx.Err()
+	}
+
+	limit := maxYieldNormal
+	if priority == PriorityLow {
+		limit = maxYieldLow
+	}
+
+	started := time.Now()
+	defer func() {
+		db.scheduler.mutex.Lock()
+		defer db.scheduler.mutex.Unlock()
+		if db.scheduler.yields == nil {
+			db.scheduler.yields = make(map[string]int)
+			db.scheduler.waited = make(map[string]time.Duration)
+		}
+		db.scheduler.yields[job]++
+		db.scheduler.waited[job] += time.Since(started)
+	}()
+
+	timer := time.NewTimer(yieldPoll)
+	defer timer.Stop()
+	for db.foregroundBusy(priority) && time.Since(started) < limit {
+		select {
+		case <-timer.C:
+			timer.Reset(yieldPoll)
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-db.done:
+			return context.Canceled
+		}
+	}
+	return ctx.Err()
+}
+
+func (db *DB) MaintenanceStats() MaintenanceStats {
+	latency := db.foregroundLatency()
+
+	db.scheduler.mutex.Lock()
+	defer db.scheduler.mutex.Unlock()
+
+	stats := MaintenanceStats{
+		Latency: latency,
+		Busy:    db.options.MaintenanceLatency > 0 && latency > db.options.MaintenanceLatency,
+		Yields:  make(map[string]int, len(db.scheduler.yields)),
+		Waited:  make(map[string]time.Duration, len(db.scheduler.waited)),
+	}
+	for job, count := range db.scheduler.yields {
+		stats.Yields[job] = count
+	}
+	for job, waited := range db.scheduler.waited {
+		stats.Waited[job] = waited
+	}
+	return stats
+}
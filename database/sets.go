@@ -0,0 +1,332 @@
+package database
+
+import (
+	"bytes"
+	err "errors"
+	"fmt"
+	"math"
+
+	"github.com/andr1ww/odin/internal/keys"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	setPrefix  = "__set_"
+	zsetPrefix = "__zset_"
+)
+
+var (
+	zsetMembers = []byte("m")
+	zsetScores  = []byte("s")
+)
+
+type ZMember struct {
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+}
+
+func setBucketName(set string) []byte {
+	return []byte(setPrefix + set)
+}
+
+func zsetBucketName(zset string) []byte {
+	return []byte(zsetPrefix + zset)
+}
+
+func (db *DB) SAdd(set string, members ...string) (int, error) {
+	if set == "" {
+		return 0, err.New("set name cannot be empty")
+	}
+	for _, member := range members {
+		if member == "" {
+			return 0, err.New("set member cannot be empty")
+		}
+	}
+
+	added := 0
+	err := db.update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(setBucketName(set))
+		if err != nil {
+			return err
+		}
+		for _, member := range members {
+			if b.Get([]byte(member)) != nil {
+				continue
+			}
+			if err := b.Put([]byte(member), []byte{}); err != nil {
+				return err
+			}
+			added++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
+func (db *DB) SRem(set string, members ...string) (int, error) {
+	removed := 0
+	err := db.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(setBucketName(set))
+		if b == nil {
+			return nil
+		}
+		for _, member := range members {
+			if b.Get([]byte(member)) == nil {
+				continue
+			}
+			if err := b.Delete([]byte(member)); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+func (db *DB) SIsMember(set, member string) (bool, error) {
+	found := false
+	err := db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket(setBucketName(set)); b != nil {
+			found = b.Get([]byte(member)) != nil
+		}
+		return nil
+	})
+	return found, err
+}
+
+func (db *DB) SMembers(set string) ([]string, error) {
+	var members []string
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(setBucketName(set))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, _ []byte) error {
+			members = append(members, string(k))
+			return nil
+		})
+	})
+	return members, err
+}
+
+func (db *DB) SCard(set string) (int, error) {
+	count := 0
+	err := db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket(setBucketName(set)); b != nil {
+			count = b.Stats().KeyN
+		}
+		return nil
+	})
+	return count, err
+}
+
+func zsetMemberKey(member string) []byte {
+	key := make([]byte, 0, len(zsetMembers)+len(member)+1)
+	key = append(key, zsetMembers...)
+	key = append(key, 0)
+	return append(key, member...)
+}
+
+func zsetScoreKey(score []byte, member string) []byte {
+	key := make([]byte, 0, len(zsetScores)+len(score)+len(member)+1)
+	key = append(key, zsetScores...)
+	key = append(key, 0)
+	key = append(key, score...)
+	return append(key, member...)
+}
+
+func zsetScoreBound(score float64) []byte {
+	return zsetScoreKey(keys.EncodeFloat64(score), "")
+}
+
+func (db *DB) ZAdd(zset string, score float64, member string) error {
+	_, err := db.zset(zset, member, func(float64, bool) float64 { return score })
+	return err
+}
+
+func (db *DB) ZIncrBy(zset string, delta float64, member string) (float64, error) {
+	return db.zset(zset, member, func(current float64, _ bool) float64 { return current + delta })
+}
+
+func (db *DB) zset(zset, member string, next func(current float64, exists bool) float64) (float64, error) {
+	if zset == "" {
+		return 0, err.New("sorted set name cannot be empty")
+	}
+	if member == "" {
+		return 0, err.New("sorted set member cannot be empty")
+	}
+
+	var score float64
+	err := db.update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(zsetBucketName(zset))
+		if err != nil {
+			return err
+		}
+
+		memberKey := zsetMemberKey(member)
+		current, exists := 0.0, false
+		if old := b.Get(memberKey); old != nil {
+			current, exists = keys.DecodeFloat64(old), true
+			if err := b.Delete(zsetScoreKey(old, member)); err != nil {
+				return err
+			}
+		}
+
+		score = next(current, exists)
+		if math.IsNaN(score) {
+			return fmt.Errorf("sorted set score for %q is not a number", member)
+		}
+
+		encoded := keys.EncodeFloat64(score)
+		if err := b.Put(memberKey, encoded); err != nil {
+			return err
+		}
+		return b.Put(zsetScoreKey(encoded, member), []byte{})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return score, nil
+}
+
+func (db *DB) ZRem(zset string, members ...string) (int, error) {
+	removed := 0
+	err := db.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(zsetBucketName(zset))
+		if b == nil {
+			return nil
+		}
+		for _, member := range members {
+			memberKey := zsetMemberKey(member)
+			old := b.Get(memberKey)
+			if old == nil {
+				continue
+			}
+			if err := b.Delete(zsetScoreKey(old, member)); err != nil {
+				return err
+			}
+			if err := b.Delete(memberKey); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+func (db *DB) ZScore(zset, member string) (float64, bool, error) {
+	var score float64
+	found := false
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(zsetBucketName(zset))
+		if b == nil {
+			return nil
+		}
+		if data := b.Get(zsetMemberKey(member)); data != nil {
+			score, found = keys.DecodeFloat64(data), true
+		}
+		return nil
+	})
+	return score, found, err
+}
+
+func (db *DB) ZCard(zset string) (int, error) {
+	count := 0
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(zsetBucketName(zset))
+		if b == nil {
+			return nil
+		}
+		prefix := append(append([]byte{}, zsetMembers...), 0)
+		c := b.Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+func (db *DB) ZRangeByScore(zset string, min, max float64, limit int) ([]ZMember, error) {
+	var members []ZMember
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(zsetBucketName(zset))
+		if b == nil {
+			return nil
+		}
+
+		prefix := append(append([]byte{}, zsetScores...), 0)
+		c := b.Cursor()
+		for k, _ := c.Seek(zsetScoreBound(min)); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			if limit > 0 && len(members) >= limit {
+				break
+			}
+			member, ok := decodeZScoreKey(k, prefix)
+			if !ok {
+				continue
+			}
+			if member.Score > max {
+				break
+			}
+			members = append(members, member)
+		}
+		return nil
+	})
+	return members, err
+}
+
+func (db *DB) ZRevRangeByScore(zset string, max, min float64, limit int) ([]ZMember, error) {
+	var members []ZMember
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(zsetBucketName(zset))
+		if b == nil {
+			return nil
+		}
+
+		prefix := append(append([]byte{}, zsetScores...), 0)
+		c := b.Cursor()
+
+		var k []byte
+		if next := math.Nextafter(max, math.Inf(1)); next > max {
+			k, _ = c.Seek(zsetScoreBound(next))
+		}
+		if k == nil {
+			k, _ = c.Last()
+		}
+
+		for ; k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Prev() {
+			if limit > 0 && len(members) >= limit {
+				break
+			}
+			member, ok := decodeZScoreKey(k, prefix)
+			if !ok || member.Score > max {
+				continue
+			}
+			if member.Score < min {
+				break
+			}
+			members = append(members, member)
+		}
+		return nil
+	})
+	return members, err
+}
+
+func decodeZScoreKey(k, prefix []byte) (ZMember, bool) {
+	rest := k[len(prefix):]
+	if len(rest) < 8 {
+		return ZMember{}, false
+	}
+	return ZMember{Member: string(rest[8:]), Score: keys.DecodeFloat64(rest[:8])}, true
+}
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"context"
+	err "errors"
+	"fmt"
+	"sort"
+
+	"github.com/andr1ww/odin/internal/logger"
+)
+
+func RegisterCloseHook(hook func(ctx context.Context) error) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	manager.closeHooks = append(manager.closeHooks, hook)
+}
+
+func DependsOn(name, dependency string) {
+	if name == "" || dependency == "" || name == dependency {
+		return
+	}
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	if manager.dependencies[name] == nil {
+		manager.dependencies[name] = make(map[string]bool)
+	}
+	manager.dependencies[name][dependency] = true
+}
+
+func Shutdown(ctx context.Context) error {
+	manager.mutex.Lock()
+	databases := manager.databases
+	previousDefault := manager.defaultDB
+	hooks := append([]func(ctx context.Context) error(nil), manager.closeHooks...)
+	order := closeOrder(databases, manager.dependencies)
+	manager.databases = make(map[string]*DB)
+	manager.defaultDB = ""
+	if manager.idleStop != nil {
+		close(manager.idleStop)
+		manager.idleStop = nil
+	}
+	if manager.healthStop != nil {
+		close(manager.healthStop)
+		manager.healthStop = nil
+	}
+	manager.mutex.Unlock()
+
+	var errs []error
+
+	for _, name := range order {
+		databases[name].signalStop()
+	}
+	for _, name := range order {
+		if err := databases[name].waitBackground(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, name := range order {
+		db := databases[name]
+		if handle := db.handle(); handle.NoSync {
+			if err := handle.Sync(); err != nil {
+				errs = append(errs, fmt.Errorf("flush database '%s': %w", name, err))
+			}
+		}
+	}
+
+	for _, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("close hook: %w", err))
+		}
+	}
+
+	for _, name := range order {
+		if err := ctx.Err(); err != nil {
+			logger.Warning("shutdown deadline reached, closing database '%s' anyway", name)
+		}
+		if err := databases[name].closeHandle(); err != nil {
+			errs = append(errs, fmt.Errorf("error closing database '%s': %w", name, err))
+		}
+		emitClose(name)
+	}
+
+	if previousDefault != "" {
+		emitDefaultChanged(previousDefault, "")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("shutdown completed with errors: %w", err.Join(errs...))
+	}
+
+	logger.Success("shutdown complete: %d databases closed", len(order))
+	return nil
+}
+
+func closeOrder(databases map[string]*DB, dependencies map[string]map[string]bool) []string {
+	names := make([]string, 0, len(databases))
+	for name := range databases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	dependents := make(map[string]int, len(names))
+	for _, name := range names {
+		for dependency := range dependencies[name] {
+			if _, open := databases[dependency]; open {
+				dependents[dependency]++
+			}
+		}
+	}
+
+	order := make([]string, 0, len(names))
+	visited := make(map[string]bool, len(names))
+	for len(order) < len(names) {
+		progressed := false
+		for _, name := range names {
+			if visited[name] || dependents[name] > 0 {
+				continue
+			}
+
+			visited[name] = true
+			order = append(order, name)
+			progressed = true
+			for dependency := range dependencies[name] {
+				dependents[dependency]--
+			}
+		}
+
+		if !progressed {
+			for _, name := range names {
+				if !visited[name] {
+					visited[name] = true
+					order = append(order, name)
+				}
+			}
+		}
+	}
+	return order
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"sort"
+	"time"
+
+	"github.com/andr1ww/odin/internal/logger"
+)
+
+type SlowOpStats struct {
+	Op     string
+	Bucket string
+	Count  uint64
+	Total  time.Duration
+	Max    time.Duration
+}
+
+func (db *DB) SetSlowThreshold(threshold time.Duration) {
+	db.configured.Store(true)
+	db.slowThreshold.Store(int64(threshold))
+}
+
+func (db *DB) SlowThreshold() time.Duration {
+	return time.Duration(db.slowThreshold.Load())
+}
+
+func (db *DB) ObserveOp(op, bucketName, detail string, started time.Time, rows int) {
+	elapsed := time.Since(started)
+	db.observeForeground(op, elapsed)
+
+	threshold := time.Duration(db.slowThreshold.Load())
+	if threshold <= 0 || elapsed < threshold {
+		return
+	}
+
+	if detail != "" {
+		detail = " [" + detail + "]"
+	}
+	logger.Warning("slow %s on bucket '%s' in database '%s' took %s, %d rows scanned%s", op, bucketName, db.name, elapsed, rows, detail)
+
+	db.slowMutex.Lock()
+	defer db.slowMutex.Unlock()
+
+	if db.slowOps == nil {
+		db.slowOps = make(map[string]*SlowOpStats)
+	}
+	id := op + "\x00" + bucketName
+	stats, exists := db.slowOps[id]
+	if !exists {
+		stats = &SlowOpStats{Op: op, Bucket: bucketName}
+		db.slowOps[id] = stats
+	}
+	stats.Count++
+	stats.Total += elapsed
+	stats.Max = max(stats.Max, elapsed)
+}
+
+func (db *DB) SlowOps() []SlowOpStats {
+	db.slowMutex.Lock()
+	defer db.slowMutex.Unlock()
+
+	result := make([]SlowOpStats, 0, len(db.slowOps))
+	for _, stats := range db.slowOps {
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Total > result[j].Total
+	})
+	return result
+}
+
+func (db *DB) ResetSlowOps() {
+	db.slowMutex.Lock()
+	defer db.slowMutex.Unlock()
+
+	db.slowOps = nil
+}
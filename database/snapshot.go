@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func (db *DB) BackupTo(w io.Writer) (int64, error) {
+	var written int64
+	err := db.View(func(tx *bolt.Tx) error {
+		var writeErr error
+		written, writeErr = tx.WriteTo(w)
+		return writeErr
+	})
+	return written, err
+}
+
+func (db *DB) LoadBackup(r io.Reader) error {
+	if writeErr := db.writable(); writeErr != nil {
+		return writeErr
+	}
+	return db.loadBackup(r)
+}
+
+func (db *DB) LoadReplicated(r io.Reader) error {
+	return db.loadBackup(r)
+}
+
+func (db *DB) loadBackup(r io.Reader) error {
+	tmpPath := maintenancePath(db.Path(), db.options.MaintenanceDir, ".restore")
+	tmp, createErr := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if createErr != nil {
+		return createErr
+	}
+	defer os.Remove(tmpPath)
+
+	_, copyErr := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); copyErr == nil {
+		copyErr = closeErr
+	}
+	if copyErr != nil {
+		return fmt.Errorf("read backup: %w", copyErr)
+	}
+
+	source, openErr := bolt.Open(tmpPath, 0600, &bolt.Options{ReadOnly: true, Timeout: time.Second})
+	if openErr != nil {
+		return fmt.Errorf("open backup: %w", openErr)
+	}
+	defer source.Close()
+
+	loadErr := source.View(func(src *bolt.Tx) error {
+		return db.commitUpdate(func(tx *bolt.Tx) error {
+			var existing [][]byte
+			tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+				existing = append(existing, append([]byte(nil), name...))
+				return nil
+			})
+			for _, name := range existing {
+				if err := tx.DeleteBucket(name); err != nil {
+					return fmt.Errorf("drop %s: %w", name, err)
+				}
+			}
+
+			return src.ForEach(func(name []byte, b *bolt.Bucket) error {
+				target, err := tx.CreateBucket(name)
+				if err != nil {
+					return fmt.Errorf("create %s: %w", name, err)
+				}
+				target.SetSequence(b.Sequence())
+				return copyBucket(context.Background(), b, target)
+			})
+		}, nil)
+	})
+	if loadErr != nil {
+		return loadErr
+	}
+
+	db.resetDerivedState()
+	emitRestore(db.name, db)
+	return nil
+}
@@ -0,0 +1,311 @@
+package database
+
+import (
+	err "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/logger"
+	bolt "go.etcd.io/bbolt"
+)
+
+const snapshotTxHeader = "X-Odin-Txid"
+
+type Standby struct {
+	name   string
+	path   string
+	source string
+	client *http.Client
+	opts   []Option
+
+	mutex    sync.Mutex
+	txid     string
+	lastSync time.Time
+	lastErr  error
+	promoted bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Update runs fn in a raw write transaction. Keys written this way never
+// reach the bloom filters, so every filter is marked stale until it is
+// rebuilt.
+func (db *DB) Update(fn func(*bolt.Tx) error) error {
+	return db.update(func(tx *bolt.Tx) error {
+		db.invalidateBloomFilters()
+		return fn(tx)
+	})
+}
+
+func (db *DB) update(fn func(*bolt.Tx) error) error {
+	if writeErr := db.writable(); writeErr != nil {
+		return writeErr
+	}
+	return db.commitUpdate(fn, nil)
+}
+
+func (db *DB) limitedUpdate(fn func(*bolt.Tx) error) error {
+	if writeErr := db.writable(); writeErr != nil {
+		return writeErr
+	}
+
+	release, acquireErr := db.acquire(db.limits.writes)
+	if acquireErr != nil {
+		return acquireErr
+	}
+	return db.commitUpdate(fn, release)
+}
+
+func (db *DB) commitUpdate(fn func(*bolt.Tx) error, release func()) error {
+	var tx *bolt.Tx
+	committed := false
+	defer func() {
+		db.finishCommit(tx, committed)
+		db.runFollowUps(tx, committed)
+	}()
+
+	updateErr := func() error {
+		if release != nil {
+			defer release()
+		}
+		db.writeMutex.RLock()
+		defer db.writeMutex.RUnlock()
+		db.handleMutex.RLock()
+		defer db.handleMutex.RUnlock()
+		return db.DB.Update(func(current *bolt.Tx) error {
+			tx = current
+			return fn(current)
+		})
+	}()
+	committed = updateErr == nil
+	return updateErr
+}
+
+func (db *DB) IsStandby() bool {
+	return db.standby.Load()
+}
+
+func (db *DB) SetReplica(replica bool) {
+	db.replica.Store(replica)
+}
+
+func (db *DB) IsReplica() bool {
+	return db.replica.Load()
+}
+
+func (db *DB) writable() error {
+	switch {
+	case db.IsStandby():
+		return errors.ErrStandby
+	case db.IsReplica():
+		return errors.ErrReplica
+	case db.IsReadOnly():
+		return errors.ErrReadOnly
+	}
+	return nil
+}
+
+func (db *DB) rejectsWrites() bool {
+	return db.writable() != nil
+}
+
+func (db *DB) SnapshotHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		viewErr := db.View(func(tx *bolt.Tx) error {
+			txid := strconv.Itoa(tx.ID())
+			if r.Header.Get("If-None-Match") == txid {
+				w.WriteHeader(http.StatusNotModified)
+				return nil
+			}
+
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Content-Length", strconv.FormatInt(tx.Size(), 10))
+			w.Header().Set("ETag", txid)
+			w.Header().Set(snapshotTxHeader, txid)
+			_, writeErr := tx.WriteTo(w)
+			return writeErr
+		})
+		if viewErr != nil {
+			logger.Error("failed to ship snapshot of database '%s': %v", db.name, viewErr)
+		}
+	})
+}
+
+func Follow(name, dbPath, source string, interval time.Duration, opts ...Option) (*Standby, error) {
+	if source == "" {
+		return nil, err.New("standby needs a snapshot source")
+	}
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	s := &Standby{
+		name:   name,
+		path:   dbPath,
+		source: source,
+		client: &http.Client{Timeout: 10 * time.Minute},
+		opts:   opts,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if syncErr := s.Sync(); syncErr != nil {
+		return nil, syncErr
+	}
+
+	go s.run(interval)
+	return s, nil
+}
+
+func (s *Standby) run(interval time.Duration) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if syncErr := s.Sync(); syncErr != nil {
+				logger.Error("standby '%s' failed to sync from %s: %v", s.name, s.source, syncErr)
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (s *Standby) Sync() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.promoted {
+		return fmt.Errorf("standby '%s' was promoted", s.name)
+	}
+
+	changed, pullErr := s.pull()
+	s.lastErr = pullErr
+	if pullErr != nil {
+		return pullErr
+	}
+	s.lastSync = time.Now()
+	if changed {
+		logger.Success("standby '%s' synced snapshot %s from %s", s.name, s.txid, s.source)
+	}
+	return nil
+}
+
+func (s *Standby) pull() (bool, error) {
+	req, reqErr := http.NewRequest(http.MethodGet, s.source, nil)
+	if reqErr != nil {
+		return false, reqErr
+	}
+	if s.txid != "" {
+		req.Header.Set("If-None-Match", s.txid)
+	}
+
+	resp, getErr := s.client.Do(req)
+	if getErr != nil {
+		return false, getErr
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return false, nil
+	case http.StatusOK:
+	default:
+		return false, fmt.Errorf("snapshot source answered %s", resp.Status)
+	}
+
+	options := buildOptions(s.opts)
+	tmpPath := maintenancePath(s.path, options.MaintenanceDir, ".standby")
+	if writeErr := writeSnapshot(tmpPath, resp.Body, resp.ContentLength); writeErr != nil {
+		os.Remove(tmpPath)
+		return false, writeErr
+	}
+
+	if db, exists := GetAll()[s.name]; exists {
+		if replaceErr := db.replaceFile(tmpPath); replaceErr != nil {
+			os.Remove(tmpPath)
+			return false, replaceErr
+		}
+	} else {
+		if renameErr := moveFile(tmpPath, s.path); renameErr != nil {
+			os.Remove(tmpPath)
+			return false, renameErr
+		}
+		options.standby = true
+		if _, connectErr := connect(s.name, s.path, options, false); connectErr != nil {
+			return false, connectErr
+		}
+	}
+
+	s.txid = resp.Header.Get(snapshotTxHeader)
+	return true, nil
+}
+
+func writeSnapshot(path string, body io.Reader, size int64) error {
+	file, createErr := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if createErr != nil {
+		return createErr
+	}
+
+	written, copyErr := io.Copy(file, body)
+	if copyErr == nil && size >= 0 && written != size {
+		copyErr = fmt.Errorf("snapshot truncated: got %d of %d bytes", written, size)
+	}
+	if copyErr == nil {
+		copyErr = file.Sync()
+	}
+	if closeErr := file.Close(); copyErr == nil {
+		copyErr = closeErr
+	}
+	return copyErr
+}
+
+func (s *Standby) LastSync() (time.Time, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.lastSync, s.lastErr
+}
+
+func (s *Standby) Stop() {
+	s.mutex.Lock()
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+	s.mutex.Unlock()
+	<-s.done
+}
+
+func (s *Standby) PromoteToPrimary() (*DB, error) {
+	s.Stop()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	db, getErr := GetNamed(s.name)
+	if getErr != nil {
+		return nil, getErr
+	}
+	s.promoted = true
+	db.standby.Store(false)
+
+	logger.Success("standby '%s' promoted to primary at snapshot %s", s.name, s.txid)
+	return db, nil
+}
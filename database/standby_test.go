@@ -0,0 +1,71 @@
+package database
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andr1ww/odin/internal/logger"
+)
+
+func TestStandbySyncKeepsDatabase(t *testing.T) {
+	logger.DisableLogging()
+	dir := t.TempDir()
+
+	if err := Connect("standby-primary", filepath.Join(dir, "primary.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer Close("standby-primary")
+	primary, err := GetNamed("standby-primary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := primary.CreateBucket("items"); err != nil {
+		t.Fatal(err)
+	}
+	if err := primary.Put("items", "first", map[string]string{"name": "first"}); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(primary.SnapshotHandler())
+	defer server.Close()
+
+	standby, err := Follow("standby-replica", filepath.Join(dir, "standby.db"), server.URL, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close("standby-replica")
+	defer standby.Stop()
+
+	follower, err := GetNamed("standby-replica")
+	if err != nil {
+		t.Fatal(err)
+	}
+	follower.SetQuota("items", Quota{MaxKeys: 10})
+
+	if err := primary.Put("items", "second", map[string]string{"name": "second"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := standby.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := GetNamed("standby-replica")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current != follower {
+		t.Fatal("standby sync replaced the *DB instead of swapping its handle")
+	}
+	var second map[string]string
+	if err := follower.Get("items", "second", &second); err != nil || second["name"] != "second" {
+		t.Fatalf("expected synced record, got %v (%v)", second, err)
+	}
+	if _, configured := follower.GetQuota("items"); !configured {
+		t.Fatal("standby sync dropped the runtime quota")
+	}
+	if err := follower.Put("items", "local", map[string]string{}); err == nil {
+		t.Fatal("standby accepted a write after sync")
+	}
+}
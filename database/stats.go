@@ -0,0 +1,83 @@
+package database
+
+import (
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/compression"
+	"github.com/andr1ww/odin/internal/indexing"
+	bolt "go.etcd.io/bbolt"
+)
+
+type IndexStats = indexing.Stats
+
+type BucketStats struct {
+	Name         string
+	KeyCount     int
+	TotalBytes   int64
+	KeyBytes     int64
+	AvgValueSize float64
+	MaxValueSize int
+	Codecs       map[string]int
+	Pages        bolt.BucketStats
+	Index        IndexStats
+}
+
+func (db *DB) BucketStats(bucketName string) (*BucketStats, error) {
+	stats := &BucketStats{
+		Name:   bucketName,
+		Codecs: make(map[string]int),
+	}
+
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrBucketMissing
+		}
+
+		stats.Pages = b.Stats()
+		stats.Index = indexing.BucketIndexStats(bucketName)
+
+		return b.ForEach(func(k, v []byte) error {
+			if v == nil {
+				return nil
+			}
+
+			stats.KeyCount++
+			stats.KeyBytes += int64(len(k))
+			stats.TotalBytes += int64(len(v))
+			if len(v) > stats.MaxValueSize {
+				stats.MaxValueSize = len(v)
+			}
+			if isColdStub(v) {
+				stats.Codecs["cold"]++
+			} else {
+				stats.Codecs[compression.CodecName(v)]++
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if stats.KeyCount > 0 {
+		stats.AvgValueSize = float64(stats.TotalBytes) / float64(stats.KeyCount)
+	}
+	return stats, nil
+}
+
+func (db *DB) AllBucketStats() (map[string]*BucketStats, error) {
+	buckets, err := db.ListBuckets()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*BucketStats, len(buckets))
+	for _, name := range buckets {
+		stats, err := db.BucketStats(name)
+		if err != nil {
+			return nil, err
+		}
+		result[name] = stats
+	}
+	return result, nil
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"encoding/json"
+	err "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/compression"
+	"github.com/andr1ww/odin/internal/keys"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	streamPrefix       = "__stream_"
+	AnyVersion   int64 = -1
+	NoStream     int64 = 0
+)
+
+type Event struct {
+	Seq  uint64          `json:"seq"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+	Time time.Time       `json:"time"`
+}
+
+func (e *Event) Decode(target interface{}) error {
+	return js.Unmarshal(e.Data, target)
+}
+
+func NewEvent(eventType string, data interface{}) (Event, error) {
+	raw, err := js.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("error marshaling event data: %w", err)
+	}
+	return Event{Type: eventType, Data: raw, Time: time.Now()}, nil
+}
+
+func (db *DB) AppendEvent(stream string, expectedVersion int64, eventType string, data interface{}) (uint64, error) {
+	event, err := NewEvent(eventType, data)
+	if err != nil {
+		return 0, err
+	}
+
+	seqs, err := db.AppendEvents(stream, expectedVersion, event)
+	if err != nil {
+		return 0, err
+	}
+	return seqs[0], nil
+}
+
+func (db *DB) AppendEvents(stream string, expectedVersion int64, events ...Event) ([]uint64, error) {
+	if stream == "" {
+		return nil, err.New("stream name cannot be empty")
+	}
+	if len(events) == 0 {
+		return nil, err.New("no events to append")
+	}
+
+	seqs := make([]uint64, 0, len(events))
+	err := db.update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(streamPrefix + stream))
+		if err != nil {
+			return fmt.Errorf("create stream %s: %w", stream, err)
+		}
+
+		if expectedVersion != AnyVersion && b.Sequence() != uint64(expectedVersion) {
+			return fmt.Errorf("%w: stream '%s' is at version %d, expected %d", errors.ErrVersionConflict, stream, b.Sequence(), expectedVersion)
+		}
+
+		for _, event := range events {
+			if event.Time.IsZero() {
+				event.Time = time.Now()
+			}
+
+			seq, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+			event.Seq = seq
+
+			data, err := js.Marshal(event)
+			if err != nil {
+				return fmt.Errorf("error marshaling event: %w", err)
+			}
+
+			if err := b.Put(keys.EncodeUint64(seq), compression.CompressData(data)); err != nil {
+				return err
+			}
+			seqs = append(seqs, seq)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return seqs, nil
+}
+
+func (db *DB) ReadStream(stream string, fromSeq uint64, limit int) ([]Event, error) {
+	var events []Event
+
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(streamPrefix + stream))
+		if b == nil {
+			return nil
+		}
+
+		c := b.Cursor()
+		for k, v := c.Seek(keys.EncodeUint64(fromSeq)); k != nil; k, v = c.Next() {
+			if limit > 0 && len(events) >= limit {
+				break
+			}
+
+			var event Event
+			if err := js.Unmarshal(compression.DecompressData(v), &event); err != nil {
+				return fmt.Errorf("decode event %d of stream '%s': %w", keys.DecodeUint64(k), stream, err)
+			}
+			events = append(events, event)
+		}
+		return nil
+	})
+	return events, err
+}
+
+func (db *DB) StreamVersion(stream string) (uint64, error) {
+	var version uint64
+
+	err := db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(streamPrefix + stream)); b != nil {
+			version = b.Sequence()
+		}
+		return nil
+	})
+	return version, err
+}
+
+func (db *DB) ListStreams() ([]string, error) {
+	buckets, err := db.ListBuckets()
+	if err != nil {
+		return nil, err
+	}
+
+	var streams []string
+	for _, name := range buckets {
+		if strings.HasPrefix(name, streamPrefix) {
+			streams = append(streams, strings.TrimPrefix(name, streamPrefix))
+		}
+	}
+	return streams, nil
+}
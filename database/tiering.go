@@ -0,0 +1,424 @@
+package database
+
+import (
+	"bytes"
+	err "errors"
+	"fmt"
+	"time"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/keys"
+	"github.com/andr1ww/odin/internal/logger"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	accessPrefix       = "__access_"
+	coldStubMarker     = 0xFE
+	defaultTierBatch   = 500
+	defaultTierSweep   = time.Hour
+	defaultAccessFlush = time.Minute
+)
+
+type TieringPolicy struct {
+	ColdAfter time.Duration
+	Target    string
+	Interval  time.Duration
+	BatchSize int
+}
+
+type tierState struct {
+	policy TieringPolicy
+	access map[string]time.Time
+	stop   chan struct{}
+}
+
+func accessBucketName(bucketName string) []byte {
+	return []byte(accessPrefix + bucketName)
+}
+
+func coldStub(target string) []byte {
+	return append([]byte{coldStubMarker}, target...)
+}
+
+func isColdStub(data []byte) bool {
+	return len(data) > 1 && data[0] == coldStubMarker
+}
+
+func (db *DB) SetTiering(bucketName string, policy TieringPolicy) error {
+	if policy.ColdAfter <= 0 {
+		return err.New("tiering needs a positive ColdAfter")
+	}
+	if policy.Target == "" || policy.Target == db.name {
+		return err.New("tiering needs a separate target database")
+	}
+	if policy.Interval <= 0 {
+		policy.Interval = defaultTierSweep
+	}
+	if policy.BatchSize <= 0 {
+		policy.BatchSize = defaultTierBatch
+	}
+	DependsOn(db.name, policy.Target)
+	db.configured.Store(true)
+
+	db.tierMutex.Lock()
+	if previous, exists := db.tiering[bucketName]; exists {
+		close(previous.stop)
+	}
+	state := &tierState{policy: policy, access: make(map[string]time.Time), stop: make(chan struct{})}
+	db.tiering[bucketName] = state
+	db.tierMutex.Unlock()
+
+	db.goBackground(func() { db.runTiering(bucketName, state) })
+	return nil
+}
+
+func (db *DB) GetTiering(bucketName string) (TieringPolicy, bool) {
+	db.tierMutex.Lock()
+	defer db.tierMutex.Unlock()
+	if state, exists := db.tiering[bucketName]; exists {
+		return state.policy, true
+	}
+	return TieringPolicy{}, false
+}
+
+func (db *DB) RemoveTiering(bucketName string) {
+	db.tierMutex.Lock()
+	defer db.tierMutex.Unlock()
+	if state, exists := db.tiering[bucketName]; exists {
+		close(state.stop)
+		delete(db.tiering, bucketName)
+	}
+}
+
+func (db *DB) runTiering(bucketName string, state *tierState) {
+	sweep := time.NewTicker(state.policy.Interval)
+	defer sweep.Stop()
+	flush := time.NewTicker(min(defaultAccessFlush, state.policy.Interval))
+	defer flush.Stop()
+
+	for {
+		select {
+		case <-flush.C:
+			if err := db.flushAccess(bucketName, state); err != nil {
+				logger.Error("failed to record access times for bucket '%s': %v", bucketName, err)
+			}
+		case <-sweep.C:
+			if _, err := db.ApplyTiering(bucketName); err != nil {
+				logger.Error("tiering of bucket '%s' failed: %v", bucketName, err)
+			}
+		case <-state.stop:
+			return
+		case <-db.done:
+			db.flushAccess(bucketName, state)
+			return
+		}
+	}
+}
+
+func (db *DB) tierState(bucketName string) *tierState {
+	db.tierMutex.Lock()
+	defer db.tierMutex.Unlock()
+	return db.tiering[bucketName]
+}
+
+func (db *DB) touch(bucketName, key string) {
+	db.tierMutex.Lock()
+	defer db.tierMutex.Unlock()
+	if state, exists := db.tiering[bucketName]; exists {
+		state.access[key] = time.Now()
+	}
+}
+
+func (db *DB) flushAccess(bucketName string, state *tierState) error {
+	db.tierMutex.Lock()
+	pending := state.access
+	state.access = make(map[string]time.Time)
+	db.tierMutex.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+	return db.update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(accessBucketName(bucketName))
+		if err != nil {
+			return err
+		}
+		for key, at := range pending {
+			if err := b.Put([]byte(key), keys.EncodeTime(at)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func dropAccess(tx *bolt.Tx, bucketName, key string) error {
+	if b := tx.Bucket(accessBucketName(bucketName)); b != nil {
+		return b.Delete([]byte(key))
+	}
+	return nil
+}
+
+func (db *DB) ApplyTiering(bucketName string) (int, error) {
+	state := db.tierState(bucketName)
+	if state == nil {
+		return 0, fmt.Errorf("no tiering policy for bucket '%s'", bucketName)
+	}
+	if err := db.flushAccess(bucketName, state); err != nil {
+		return 0, err
+	}
+
+	target, getErr := GetNamed(state.policy.Target)
+	if getErr != nil {
+		return 0, getErr
+	}
+	if createErr := target.CreateBucket(bucketName); createErr != nil {
+		return 0, createErr
+	}
+
+	cutoff := time.Now().Add(-state.policy.ColdAfter)
+	moved := 0
+	var after []byte
+	for {
+		batch, next, scanErr := db.coldBatch(bucketName, after, cutoff, state.policy.BatchSize)
+		if scanErr != nil {
+			return moved, scanErr
+		}
+		if len(batch) > 0 {
+			n, moveErr := db.moveCold(target, bucketName, batch)
+			moved += n
+			if moveErr != nil {
+				return moved, moveErr
+			}
+		}
+		if next == nil {
+			break
+		}
+		after = next
+	}
+
+	if moved > 0 {
+		logger.Success("moved %d cold records from '%s' in database '%s' to '%s'", moved, bucketName, db.name, state.policy.Target)
+	}
+	return moved, nil
+}
+
+func (db *DB) coldBatch(bucketName string, after []byte, cutoff time.Time, limit int) ([]rawEntry, []byte, error) {
+	var batch []rawEntry
+	var unseen []string
+	var next, last []byte
+
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrBucketMissing
+		}
+		access := tx.Bucket(accessBucketName(bucketName))
+
+		c := b.Cursor()
+		k, v := c.First()
+		if after != nil {
+			k, v = c.Seek(after)
+			if k != nil && bytes.Equal(k, after) {
+				k, v = c.Next()
+			}
+		}
+
+		scanned := 0
+		for ; k != nil; k, v = c.Next() {
+			if scanned == limit {
+				next = append([]byte(nil), last...)
+				break
+			}
+			scanned++
+			last = k
+			if v == nil || isColdStub(v) {
+				continue
+			}
+
+			var stamp []byte
+			if access != nil {
+				stamp = access.Get(k)
+			}
+			if stamp == nil {
+				unseen = append(unseen, string(k))
+				continue
+			}
+			if keys.DecodeTime(stamp).Before(cutoff) {
+				batch = append(batch, rawEntry{key: string(k), value: append([]byte(nil), v...)})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(unseen) > 0 {
+		stampErr := db.update(func(tx *bolt.Tx) error {
+			b, err := tx.CreateBucketIfNotExists(accessBucketName(bucketName))
+			if err != nil {
+				return err
+			}
+			now := keys.EncodeTime(time.Now())
+			for _, key := range unseen {
+				if b.Get([]byte(key)) == nil {
+					if err := b.Put([]byte(key), now); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		if stampErr != nil {
+			return nil, nil, stampErr
+		}
+	}
+	return batch, next, nil
+}
+
+func (db *DB) moveCold(target *DB, bucketName string, batch []rawEntry) (int, error) {
+	putErr := target.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrBucketMissing
+		}
+		for _, entry := range batch {
+			if err := b.Put([]byte(entry.key), entry.value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if putErr != nil {
+		return 0, putErr
+	}
+
+	moved := 0
+	stub := coldStub(target.name)
+	var stale []string
+	updateErr := db.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrBucketMissing
+		}
+		for _, entry := range batch {
+			if !bytes.Equal(b.Get([]byte(entry.key)), entry.value) {
+				stale = append(stale, entry.key)
+				continue
+			}
+			if err := db.admit(tx, bucketName, entry.key, entry.value, stub); err != nil {
+				return err
+			}
+			if err := b.Put([]byte(entry.key), stub); err != nil {
+				return err
+			}
+			moved++
+		}
+		return nil
+	})
+	if updateErr != nil {
+		stale = stale[:0]
+		for _, entry := range batch {
+			stale = append(stale, entry.key)
+		}
+		moved = 0
+	}
+
+	if len(stale) > 0 {
+		target.dropCold(bucketName, stale...)
+	}
+	return moved, updateErr
+}
+
+func (db *DB) dropCold(bucketName string, keys ...string) {
+	dropErr := db.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return nil
+		}
+		for _, key := range keys {
+			if err := b.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if dropErr != nil {
+		logger.Error("failed to drop cold copies from '%s' in database '%s': %v", bucketName, db.name, dropErr)
+	}
+}
+
+func (db *DB) releaseCold(tx *bolt.Tx, bucketName, key string, old, value []byte) {
+	if !isColdStub(old) || bytes.Equal(old, value) {
+		return
+	}
+	target := string(old[1:])
+	db.afterCommit(tx, func() {
+		if cold, getErr := GetNamed(target); getErr == nil {
+			cold.dropCold(bucketName, key)
+		}
+	})
+}
+
+func readCold(bucketName, key string, stub []byte) ([]byte, error) {
+	cold, getErr := GetNamed(string(stub[1:]))
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	var raw []byte
+	viewErr := cold.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrNotFound
+		}
+		if value := b.Get([]byte(key)); value != nil {
+			raw = append([]byte(nil), value...)
+			return nil
+		}
+		return errors.ErrNotFound
+	})
+	return raw, viewErr
+}
+
+func (db *DB) ColdValue(bucketName, key string, data []byte) []byte {
+	if !isColdStub(data) {
+		return data
+	}
+	raw, readErr := readCold(bucketName, key, data)
+	if readErr != nil {
+		logger.Warning("cold record '%s' of bucket '%s' unavailable: %v", key, bucketName, readErr)
+		return data
+	}
+	return raw
+}
+
+func (db *DB) thaw(bucketName, key string, stub []byte) ([]byte, error) {
+	raw, readErr := readCold(bucketName, key, stub)
+	if readErr != nil {
+		return nil, fmt.Errorf("cold record '%s' unavailable: %w", key, readErr)
+	}
+	if db.rejectsWrites() {
+		return raw, nil
+	}
+
+	updateErr := db.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrBucketMissing
+		}
+		if !bytes.Equal(b.Get([]byte(key)), stub) {
+			return nil
+		}
+		if err := db.admit(tx, bucketName, key, stub, raw); err != nil {
+			return err
+		}
+		return b.Put([]byte(key), raw)
+	})
+	if updateErr != nil {
+		return nil, updateErr
+	}
+	return raw, nil
+}
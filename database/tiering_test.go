@@ -0,0 +1,153 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andr1ww/odin/internal/logger"
+	bolt "go.etcd.io/bbolt"
+)
+
+func openTiered(t *testing.T, hotName, coldName string) *DB {
+	t.Helper()
+	logger.DisableLogging()
+	dir := t.TempDir()
+
+	for _, name := range []string{coldName, hotName} {
+		if err := Connect(name, filepath.Join(dir, name+".db")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() {
+		Close(hotName)
+		Close(coldName)
+	})
+
+	hot, err := GetNamed(hotName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hot.CreateBucket("items"); err != nil {
+		t.Fatal(err)
+	}
+	return hot
+}
+
+func freeze(t *testing.T, hot *DB, coldName string, records map[string]time.Time) {
+	t.Helper()
+	for key, created := range records {
+		if err := hot.Put("items", key, map[string]interface{}{"name": key, "created_at": created}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := hot.SetTiering("items", TieringPolicy{ColdAfter: time.Millisecond, Target: coldName, Interval: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hot.ApplyTiering("items"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	moved, err := hot.ApplyTiering("items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved != len(records) {
+		t.Fatalf("moved %d records to the cold tier, want %d", moved, len(records))
+	}
+}
+
+func storedValue(t *testing.T, db *DB, key string) []byte {
+	t.Helper()
+	var raw []byte
+	if err := db.View(func(tx *bolt.Tx) error {
+		raw = append([]byte(nil), tx.Bucket([]byte("items")).Get([]byte(key))...)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestColdReadOnReplicaDoesNotThaw(t *testing.T) {
+	hot := openTiered(t, "tier-replica-hot", "tier-replica-cold")
+	freeze(t, hot, "tier-replica-cold", map[string]time.Time{"a": time.Now()})
+
+	hot.SetReplica(true)
+	defer hot.SetReplica(false)
+
+	var record map[string]interface{}
+	if err := hot.Get("items", "a", &record); err != nil {
+		t.Fatalf("cold read on a replica failed: %v", err)
+	}
+	if record["name"] != "a" {
+		t.Fatalf("unexpected cold record %v", record)
+	}
+	if !isColdStub(storedValue(t, hot, "a")) {
+		t.Fatal("cold read on a replica rewrote the stub")
+	}
+}
+
+func TestCapBackfillReadsColdRecords(t *testing.T) {
+	hot := openTiered(t, "tier-cap-hot", "tier-cap-cold")
+	freeze(t, hot, "tier-cap-cold", map[string]time.Time{
+		"a-late":  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		"b-early": time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+
+	if err := hot.SetCap("items", Cap{MaxRecords: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := hot.Has("items", "b-early"); exists {
+		t.Fatal("cap kept the older cold record instead of evicting it")
+	}
+	if exists, _ := hot.Has("items", "a-late"); !exists {
+		t.Fatal("cap evicted the newer cold record")
+	}
+}
+
+func TestConditionalWriteMatchesColdHash(t *testing.T) {
+	hot := openTiered(t, "tier-cond-hot", "tier-cond-cold")
+	freeze(t, hot, "tier-cond-cold", map[string]time.Time{"a": time.Now()})
+
+	hash, err := hot.Hash("items", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hot.PutIf("items", "a", map[string]string{"name": "updated"}, Condition{MatchHash: hash}); err != nil {
+		t.Fatalf("conditional write on a cold record failed: %v", err)
+	}
+}
+
+func TestRetentionReadsColdCreatedAt(t *testing.T) {
+	hot := openTiered(t, "tier-retention-hot", "tier-retention-cold")
+	freeze(t, hot, "tier-retention-cold", map[string]time.Time{
+		"cold-old":  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		"cold-late": time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err := hot.Put("items", "hot-early", map[string]interface{}{"name": "hot-early", "created_at": time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)}); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := hot.BucketStats("items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Codecs["cold"] != 2 {
+		t.Fatalf("expected 2 cold records in the codec counts, got %v", stats.Codecs)
+	}
+
+	hot.SetRetention("items", RetentionPolicy{MaxAge: 20 * 365 * 24 * time.Hour, MaxKeys: 1})
+	report, err := hot.ApplyRetention("items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Deleted != 2 {
+		t.Fatalf("expected 2 records removed, got %+v", report)
+	}
+	for key, want := range map[string]bool{"cold-old": false, "hot-early": false, "cold-late": true} {
+		if exists, _ := hot.Has("items", key); exists != want {
+			t.Fatalf("%s present = %v, want %v", key, exists, want)
+		}
+	}
+}
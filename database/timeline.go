@@ -0,0 +1,191 @@
+package database
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/keys"
+	bolt "go.etcd.io/bbolt"
+)
+
+const timelinePrefix = "__created_"
+
+var (
+	timelineOrder = []byte("order")
+	timelineKeys  = []byte("keys")
+)
+
+func timelineBucketName(bucketName string) []byte {
+	return []byte(timelinePrefix + bucketName)
+}
+
+func (db *DB) SetCreated(bucketName, key string, at time.Time) error {
+	return db.SetCreatedMany(bucketName, map[string]time.Time{key: at})
+}
+
+func (db *DB) SetCreatedMany(bucketName string, times map[string]time.Time) error {
+	return db.update(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(bucketName)) == nil {
+			return errors.ErrBucketMissing
+		}
+
+		order, byKey, err := timelineBuckets(tx, bucketName)
+		if err != nil {
+			return err
+		}
+
+		for key, at := range times {
+			if at.IsZero() {
+				continue
+			}
+
+			stamp := keys.EncodeTime(at)
+			previous := byKey.Get([]byte(key))
+			if bytes.Equal(previous, stamp) {
+				continue
+			}
+			if previous != nil {
+				if err := order.Delete(keys.TimeKey(keys.DecodeTime(previous), []byte(key))); err != nil {
+					return err
+				}
+			}
+			if err := order.Put(keys.TimeKey(at, []byte(key)), nil); err != nil {
+				return err
+			}
+			if err := byKey.Put([]byte(key), stamp); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func timelineBuckets(tx *bolt.Tx, bucketName string) (*bolt.Bucket, *bolt.Bucket, error) {
+	root, err := tx.CreateBucketIfNotExists(timelineBucketName(bucketName))
+	if err != nil {
+		return nil, nil, err
+	}
+	order, err := root.CreateBucketIfNotExists(timelineOrder)
+	if err != nil {
+		return nil, nil, err
+	}
+	byKey, err := root.CreateBucketIfNotExists(timelineKeys)
+	if err != nil {
+		return nil, nil, err
+	}
+	return order, byKey, nil
+}
+
+func stampCreated(tx *bolt.Tx, bucketName, key string, at time.Time) error {
+	order, byKey, err := timelineBuckets(tx, bucketName)
+	if err != nil {
+		return err
+	}
+	if byKey.Get([]byte(key)) != nil {
+		return nil
+	}
+	if err := order.Put(keys.TimeKey(at, []byte(key)), nil); err != nil {
+		return err
+	}
+	return byKey.Put([]byte(key), keys.EncodeTime(at))
+}
+
+func dropCreated(tx *bolt.Tx, bucketName, key string) error {
+	root := tx.Bucket(timelineBucketName(bucketName))
+	if root == nil {
+		return nil
+	}
+
+	byKey := root.Bucket(timelineKeys)
+	previous := byKey.Get([]byte(key))
+	if previous == nil {
+		return nil
+	}
+
+	if err := root.Bucket(timelineOrder).Delete(keys.TimeKey(keys.DecodeTime(previous), []byte(key))); err != nil {
+		return err
+	}
+	return byKey.Delete([]byte(key))
+}
+
+func (db *DB) CreatedBetween(bucketName string, from, to time.Time, limit int, newestFirst bool) ([]string, error) {
+	var result []string
+	err := db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(bucketName)) == nil {
+			return errors.ErrBucketMissing
+		}
+		result = createdBetween(tx, bucketName, from, to, limit, newestFirst)
+		return nil
+	})
+	return result, err
+}
+
+// CreatedCovering returns the keys created between from and to, oldest
+// first, for query planning. ok is false unless every record of the bucket
+// is on the timeline, because only then is a key missing from the range
+// also missing from the bucket.
+func (db *DB) CreatedCovering(bucketName string, from, to time.Time) ([]string, bool, error) {
+	var result []string
+	covered := false
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrBucketMissing
+		}
+
+		root := tx.Bucket(timelineBucketName(bucketName))
+		if root == nil || root.Bucket(timelineKeys).Stats().KeyN != b.Stats().KeyN {
+			return nil
+		}
+		result, covered = createdBetween(tx, bucketName, from, to, 0, false), true
+		return nil
+	})
+	return result, covered, err
+}
+
+func createdBetween(tx *bolt.Tx, bucketName string, from, to time.Time, limit int, newestFirst bool) []string {
+	root := tx.Bucket(timelineBucketName(bucketName))
+	if root == nil {
+		return nil
+	}
+
+	var result []string
+	full := func() bool { return limit > 0 && len(result) >= limit }
+	c := root.Bucket(timelineOrder).Cursor()
+
+	if !newestFirst {
+		k, _ := c.First()
+		if !from.IsZero() {
+			k, _ = c.Seek(keys.EncodeTime(from))
+		}
+		for ; k != nil && !full(); k, _ = c.Next() {
+			at, key := keys.SplitTimeKey(k)
+			if !to.IsZero() && at.After(to) {
+				break
+			}
+			result = append(result, string(key))
+		}
+		return result
+	}
+
+	var k []byte
+	if to.IsZero() {
+		k, _ = c.Last()
+	} else {
+		k, _ = c.Seek(keys.EncodeTime(to.Add(time.Nanosecond)))
+		if k == nil {
+			k, _ = c.Last()
+		} else {
+			k, _ = c.Prev()
+		}
+	}
+	for ; k != nil && !full(); k, _ = c.Prev() {
+		at, key := keys.SplitTimeKey(k)
+		if at.Before(from) {
+			break
+		}
+		result = append(result, string(key))
+	}
+	return result
+}
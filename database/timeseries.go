@@ -0,0 +1,271 @@
+package database
+
+import (
+	"bytes"
+	err "errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/compression"
+	"github.com/andr1ww/odin/internal/keys"
+	bolt "go.etcd.io/bbolt"
+)
+
+const timeSeriesPrefix = "__ts_"
+
+type Point struct {
+	Time   time.Time         `json:"time"`
+	Value  float64           `json:"value"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type Aggregation int
+
+const (
+	AggregateAvg Aggregation = iota
+	AggregateMin
+	AggregateMax
+	AggregateSum
+	AggregateCount
+	AggregateFirst
+	AggregateLast
+)
+
+type TimeSeries struct {
+	db     *DB
+	name   string
+	bucket []byte
+}
+
+func (db *DB) TimeSeries(name string) (*TimeSeries, error) {
+	if name == "" {
+		return nil, err.New("time series name cannot be empty")
+	}
+
+	bucketName := timeSeriesPrefix + name
+	if err := db.CreateBucket(bucketName); err != nil {
+		return nil, err
+	}
+
+	return &TimeSeries{db: db, name: name, bucket: []byte(bucketName)}, nil
+}
+
+func (ts *TimeSeries) Name() string {
+	return ts.name
+}
+
+func (ts *TimeSeries) Append(seriesID string, point Point) error {
+	return ts.AppendBatch(seriesID, []Point{point})
+}
+
+func (ts *TimeSeries) AppendBatch(seriesID string, points []Point) error {
+	if seriesID == "" {
+		return err.New("series id cannot be empty")
+	}
+
+	stamps := make([]time.Time, len(points))
+	encoded := make([][]byte, len(points))
+	for i, point := range points {
+		if point.Time.IsZero() {
+			point.Time = time.Now()
+		}
+		if err := checkPointTime(point.Time); err != nil {
+			return err
+		}
+
+		data, err := js.Marshal(point)
+		if err != nil {
+			return fmt.Errorf("error marshaling point: %w", err)
+		}
+		stamps[i], encoded[i] = point.Time, compression.CompressData(data)
+	}
+
+	return ts.db.update(func(tx *bolt.Tx) error {
+		root := tx.Bucket(ts.bucket)
+		if root == nil {
+			return errors.ErrBucketMissing
+		}
+
+		series, err := root.CreateBucketIfNotExists([]byte(seriesID))
+		if err != nil {
+			return fmt.Errorf("create series %s: %w", seriesID, err)
+		}
+
+		for i := range points {
+			nanos := stamps[i].UnixNano()
+			key := keys.EncodeInt64(nanos)
+			for series.Get(key) != nil {
+				nanos++
+				key = keys.EncodeInt64(nanos)
+			}
+
+			if err := series.Put(key, encoded[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (ts *TimeSeries) QueryRange(seriesID string, from, to time.Time) ([]Point, error) {
+	if err := checkPointTime(from, to); err != nil {
+		return nil, err
+	}
+
+	var points []Point
+
+	err := ts.scan(seriesID, from, to, func(point Point) {
+		points = append(points, point)
+	})
+	return points, err
+}
+
+func (ts *TimeSeries) Downsample(seriesID string, from, to time.Time, step time.Duration, agg Aggregation) ([]Point, error) {
+	if step <= 0 {
+		return nil, err.New("downsample step must be positive")
+	}
+	if err := checkPointTime(from, to); err != nil {
+		return nil, err
+	}
+
+	type window struct {
+		start time.Time
+		count int
+		sum   float64
+		min   float64
+		max   float64
+		first float64
+		last  float64
+	}
+
+	var windows []*window
+	err := ts.scan(seriesID, from, to, func(point Point) {
+		start := from.Add(point.Time.Sub(from) / step * step)
+
+		var w *window
+		if len(windows) > 0 && windows[len(windows)-1].start.Equal(start) {
+			w = windows[len(windows)-1]
+		} else {
+			w = &window{start: start, min: math.Inf(1), max: math.Inf(-1), first: point.Value}
+			windows = append(windows, w)
+		}
+
+		w.count++
+		w.sum += point.Value
+		w.min = math.Min(w.min, point.Value)
+		w.max = math.Max(w.max, point.Value)
+		w.last = point.Value
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]Point, 0, len(windows))
+	for _, w := range windows {
+		var value float64
+		switch agg {
+		case AggregateMin:
+			value = w.min
+		case AggregateMax:
+			value = w.max
+		case AggregateSum:
+			value = w.sum
+		case AggregateCount:
+			value = float64(w.count)
+		case AggregateFirst:
+			value = w.first
+		case AggregateLast:
+			value = w.last
+		default:
+			value = w.sum / float64(w.count)
+		}
+		points = append(points, Point{Time: w.start, Value: value})
+	}
+	return points, nil
+}
+
+func (ts *TimeSeries) DeleteBefore(seriesID string, before time.Time) (int, error) {
+	if err := checkPointTime(before); err != nil {
+		return 0, err
+	}
+
+	var deleted int
+
+	err := ts.db.update(func(tx *bolt.Tx) error {
+		root := tx.Bucket(ts.bucket)
+		if root == nil {
+			return errors.ErrBucketMissing
+		}
+
+		series := root.Bucket([]byte(seriesID))
+		if series == nil {
+			return nil
+		}
+
+		limit := keys.EncodeTime(before)
+		c := series.Cursor()
+		for k, _ := c.First(); k != nil && bytes.Compare(k, limit) < 0; k, _ = c.Next() {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+			deleted++
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+func (ts *TimeSeries) Series() ([]string, error) {
+	var series []string
+
+	err := ts.db.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket(ts.bucket)
+		if root == nil {
+			return errors.ErrBucketMissing
+		}
+
+		return root.ForEach(func(k, v []byte) error {
+			if v == nil {
+				series = append(series, string(k))
+			}
+			return nil
+		})
+	})
+	return series, err
+}
+
+func checkPointTime(times ...time.Time) error {
+	for _, t := range times {
+		if !keys.ValidTime(t) {
+			return fmt.Errorf("time %s is unset or outside the years 1678 to 2262", t.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+func (ts *TimeSeries) scan(seriesID string, from, to time.Time, fn func(Point)) error {
+	return ts.db.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket(ts.bucket)
+		if root == nil {
+			return errors.ErrBucketMissing
+		}
+
+		series := root.Bucket([]byte(seriesID))
+		if series == nil {
+			return nil
+		}
+
+		limit := keys.EncodeTime(to)
+		c := series.Cursor()
+		for k, v := c.Seek(keys.EncodeTime(from)); k != nil && bytes.Compare(k, limit) < 0; k, v = c.Next() {
+			var point Point
+			if err := js.Unmarshal(compression.DecompressData(v), &point); err != nil {
+				continue
+			}
+			fn(point)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andr1ww/odin/internal/logger"
+)
+
+func openSeries(t *testing.T, name string) *TimeSeries {
+	t.Helper()
+	logger.DisableLogging()
+
+	if err := Connect(name, filepath.Join(t.TempDir(), "main.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Close(name) })
+
+	db, err := GetNamed(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts, err := db.TimeSeries("metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
+
+func TestAppendBatchLeavesPointsUntouched(t *testing.T) {
+	ts := openSeries(t, "series-batch")
+
+	points := []Point{{Value: 1}, {Value: 2}}
+	before := time.Now()
+	if err := ts.AppendBatch("cpu", points); err != nil {
+		t.Fatal(err)
+	}
+	for i, point := range points {
+		if !point.Time.IsZero() {
+			t.Fatalf("point %d of the caller's slice was stamped with %s", i, point.Time)
+		}
+	}
+
+	stored, err := ts.QueryRange("cpu", before, time.Now().Add(time.Second))
+	if err != nil || len(stored) != 2 {
+		t.Fatalf("expected 2 stamped points, got %v (%v)", stored, err)
+	}
+}
+
+func TestSeriesRejectsUnencodableTimes(t *testing.T) {
+	ts := openSeries(t, "series-times")
+	now := time.Now()
+	outOfRange := time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if err := ts.Append("cpu", Point{Time: outOfRange, Value: 1}); err == nil {
+		t.Fatal("appended a point past the encodable range")
+	}
+	if _, err := ts.QueryRange("cpu", time.Time{}, now); err == nil {
+		t.Fatal("queried from a zero time")
+	}
+	if _, err := ts.Downsample("cpu", time.Time{}, now, time.Minute, AggregateAvg); err == nil {
+		t.Fatal("downsampled from a zero time")
+	}
+	if _, err := ts.DeleteBefore("cpu", time.Time{}); err == nil {
+		t.Fatal("deleted before a zero time")
+	}
+}
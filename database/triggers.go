@@ -0,0 +1,76 @@
+package database
+
+import (
+	"github.com/andr1ww/odin/internal/logger"
+)
+
+type Trigger struct {
+	New      func() interface{}
+	OnInsert func(key string, value interface{}) error
+	OnUpdate func(key string, old, value interface{}) error
+	OnDelete func(key string, old interface{}) error
+}
+
+func (db *DB) RegisterTrigger(bucketName string, trigger Trigger) {
+	db.configured.Store(true)
+	db.triggerMutex.Lock()
+	defer db.triggerMutex.Unlock()
+	db.triggers[bucketName] = append(db.triggers[bucketName], trigger)
+}
+
+func (db *DB) ClearTriggers(bucketName string) {
+	db.triggerMutex.Lock()
+	defer db.triggerMutex.Unlock()
+	delete(db.triggers, bucketName)
+}
+
+func (db *DB) hasTriggers(bucketName string) bool {
+	db.triggerMutex.RLock()
+	defer db.triggerMutex.RUnlock()
+	return len(db.triggers[bucketName]) > 0
+}
+
+func (db *DB) fireTriggers(bucketName, key string, old, data []byte) {
+	db.triggerMutex.RLock()
+	triggers := append([]Trigger(nil), db.triggers[bucketName]...)
+	db.triggerMutex.RUnlock()
+
+	for _, trigger := range triggers {
+		var err error
+
+		switch {
+		case old == nil && data != nil:
+			if trigger.OnInsert != nil {
+				err = trigger.OnInsert(key, decodeTriggerValue(trigger, data))
+			}
+		case old != nil && data != nil:
+			if trigger.OnUpdate != nil {
+				err = trigger.OnUpdate(key, decodeTriggerValue(trigger, old), decodeTriggerValue(trigger, data))
+			}
+		case old != nil:
+			if trigger.OnDelete != nil {
+				err = trigger.OnDelete(key, decodeTriggerValue(trigger, old))
+			}
+		}
+
+		if err != nil {
+			logger.Error("trigger on bucket '%s' failed for key '%s': %v", bucketName, key, err)
+		}
+	}
+}
+
+func decodeTriggerValue(trigger Trigger, data []byte) interface{} {
+	if trigger.New != nil {
+		value := trigger.New()
+		if err := js.Unmarshal(data, value); err == nil {
+			return value
+		}
+		return nil
+	}
+
+	var doc Document
+	if err := js.Unmarshal(data, &doc); err != nil {
+		return nil
+	}
+	return doc
+}
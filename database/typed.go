@@ -0,0 +1,46 @@
+package database
+
+import (
+	"reflect"
+
+	"github.com/andr1ww/odin/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+const MapKeyField = "_key"
+
+func GetAllAs[T any](db *DB, bucketName string) ([]T, error) {
+	result, err := db.GetAllTyped(bucketName, reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+	return result.([]T), nil
+}
+
+func (db *DB) GetAllMaps(bucketName string) ([]map[string]interface{}, error) {
+	var rows []map[string]interface{}
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrBucketMissing
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			if len(v) == 0 {
+				return nil
+			}
+
+			var row map[string]interface{}
+			if err := db.Decode(bucketName, string(k), v, &row); err != nil {
+				return db.SkipRecord(bucketName, string(k), err)
+			}
+			if row == nil {
+				return nil
+			}
+			row[MapKeyField] = string(k)
+			rows = append(rows, row)
+			return nil
+		})
+	})
+	return rows, err
+}
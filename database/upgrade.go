@@ -0,0 +1,173 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/compression"
+	"github.com/andr1ww/odin/internal/logger"
+	bolt "go.etcd.io/bbolt"
+)
+
+type MigrationPolicy int32
+
+const (
+	MigrationOff MigrationPolicy = iota
+	MigrationInline
+	MigrationBackground
+)
+
+type MigrationStats struct {
+	Queued       uint64
+	Deduplicated uint64
+	Migrated     uint64
+	Skipped      uint64
+	Failed       uint64
+	Pending      int
+	LastError    string
+}
+
+type migrationKey struct {
+	bucket string
+	key    string
+}
+
+type migrator struct {
+	mutex   sync.Mutex
+	pending map[migrationKey][]byte
+	running bool
+	stats   MigrationStats
+}
+
+func (db *DB) SetMigrationPolicy(policy MigrationPolicy) {
+	db.configured.Store(true)
+	db.migrationPolicy.Store(int32(policy))
+}
+
+func (db *DB) GetMigrationPolicy() MigrationPolicy {
+	return MigrationPolicy(db.migrationPolicy.Load())
+}
+
+func (db *DB) MigrationStats() MigrationStats {
+	db.migrations.mutex.Lock()
+	defer db.migrations.mutex.Unlock()
+
+	stats := db.migrations.stats
+	stats.Pending = len(db.migrations.pending)
+	return stats
+}
+
+func needsFormatMigration(raw, decoded []byte) bool {
+	return len(raw) > 0 && (raw[0] == 0 || raw[0] == 1) && len(decoded) > 50
+}
+
+func (db *DB) migrateFormat(bucketName, key string, raw []byte) error {
+	if db.rejectsWrites() {
+		return nil
+	}
+	switch db.GetMigrationPolicy() {
+	case MigrationInline:
+		return db.rewriteFormat(bucketName, key, raw)
+	case MigrationBackground:
+		db.enqueueMigration(bucketName, key, raw)
+	}
+	return nil
+}
+
+func (db *DB) enqueueMigration(bucketName, key string, raw []byte) {
+	m := &db.migrations
+	id := migrationKey{bucket: bucketName, key: key}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, queued := m.pending[id]; queued {
+		m.stats.Deduplicated++
+		return
+	}
+	if m.pending == nil {
+		m.pending = make(map[migrationKey][]byte)
+	}
+
+	m.pending[id] = raw
+	m.stats.Queued++
+
+	if !m.running {
+		m.running = db.goBackground(db.drainMigrations)
+		if !m.running {
+			delete(m.pending, id)
+		}
+	}
+}
+
+func (db *DB) drainMigrations() {
+	m := &db.migrations
+
+	for {
+		m.mutex.Lock()
+		var id migrationKey
+		var raw []byte
+		found := false
+		for k, v := range m.pending {
+			id, raw, found = k, v, true
+			break
+		}
+
+		select {
+		case <-db.done:
+			found = false
+		default:
+		}
+
+		if !found {
+			m.running = false
+			m.mutex.Unlock()
+			return
+		}
+		delete(m.pending, id)
+		m.mutex.Unlock()
+
+		if err := db.rewriteFormat(id.bucket, id.key, raw); err != nil {
+			logger.Error("format migration of %s/%s in database '%s' failed: %v", id.bucket, id.key, db.name, err)
+		}
+	}
+}
+
+func (db *DB) rewriteFormat(bucketName, key string, raw []byte) error {
+	skipped := false
+	err := db.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.ErrBucketMissing
+		}
+
+		current := b.Get([]byte(key))
+		if !bytes.Equal(current, raw) {
+			skipped = true
+			return nil
+		}
+		value := db.encode(bucketName, compression.DecompressData(raw))
+		if err := db.admit(tx, bucketName, key, current, value); err != nil {
+			return err
+		}
+		return b.Put([]byte(key), value)
+	})
+
+	m := &db.migrations
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	switch {
+	case err != nil:
+		m.stats.Failed++
+		m.stats.LastError = fmt.Sprintf("%s/%s: %v", bucketName, key, err)
+		return fmt.Errorf("format migration of %s/%s: %w", bucketName, key, err)
+	case skipped:
+		m.stats.Skipped++
+	default:
+		m.stats.Migrated++
+	}
+	return nil
+}
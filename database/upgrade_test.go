@@ -0,0 +1,55 @@
+package database
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andr1ww/odin/internal/logger"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestInlineMigrationFailureDoesNotFailRead(t *testing.T) {
+	logger.DisableLogging()
+	name := "upgrade-inline"
+
+	if err := Connect(name, filepath.Join(t.TempDir(), "main.db"), WithCompression(true), WithMigrationPolicy(MigrationInline)); err != nil {
+		t.Fatal(err)
+	}
+	defer Close(name)
+
+	db, err := GetNamed(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateBucket("items"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetBucketCompressionThreshold("items", 1<<20); err != nil {
+		t.Fatal(err)
+	}
+
+	legacy := append([]byte{0}, `{"name":"`+strings.Repeat("x", 64)+`"}`...)
+	if err := db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte("items")).Put([]byte("a"), legacy)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, size, err := db.QuotaUsage("items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetQuota("items", Quota{MaxBytes: size})
+
+	var record map[string]string
+	if err := db.Get("items", "a", &record); err != nil {
+		t.Fatalf("read failed because its format rewrite failed: %v", err)
+	}
+	if record["name"] != strings.Repeat("x", 64) {
+		t.Fatalf("unexpected record %v", record)
+	}
+	if stats := db.MigrationStats(); stats.Failed != 1 || stats.Migrated != 0 {
+		t.Fatalf("expected one failed migration, got %+v", stats)
+	}
+}
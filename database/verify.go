@@ -0,0 +1,175 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/andr1ww/odin/internal/compression"
+	"github.com/andr1ww/odin/internal/keys"
+	bolt "go.etcd.io/bbolt"
+)
+
+const maxBackupProblems = 100
+
+type BackupProblem struct {
+	Bucket string
+	Key    string
+	Error  string
+}
+
+type BackupReport struct {
+	Path       string
+	Size       int64
+	Buckets    int
+	Records    int
+	Compressed int
+	Cold       int
+	Failed     int
+	Problems   []BackupProblem
+	Elapsed    time.Duration
+}
+
+func (r *BackupReport) OK() bool {
+	return r.Failed == 0
+}
+
+func (r *BackupReport) String() string {
+	status := "ok"
+	if !r.OK() {
+		status = fmt.Sprintf("%d problems", r.Failed)
+	}
+	return fmt.Sprintf("%s: %s, %d buckets, %d records (%d compressed, %d cold), %d bytes in %s",
+		r.Path, status, r.Buckets, r.Records, r.Compressed, r.Cold, r.Size, r.Elapsed.Round(time.Millisecond))
+}
+
+func (r *BackupReport) problem(bucketName, key string, problem error) {
+	r.Failed++
+	if len(r.Problems) < maxBackupProblems {
+		r.Problems = append(r.Problems, BackupProblem{Bucket: bucketName, Key: key, Error: problem.Error()})
+	}
+}
+
+func VerifyBackup(path string) (*BackupReport, error) {
+	return VerifyArchive(path, nil)
+}
+
+func VerifyArchive(path string, key []byte) (*BackupReport, error) {
+	start := time.Now()
+	info, statErr := os.Stat(path)
+	if statErr != nil {
+		return nil, statErr
+	}
+
+	file, openErr := os.Open(path)
+	if openErr != nil {
+		return nil, openErr
+	}
+	defer file.Close()
+
+	header := make([]byte, len(archiveMagic))
+	n, _ := io.ReadFull(file, header)
+	snapshot := path
+	if isArchive(header[:n]) {
+		if _, seekErr := file.Seek(0, io.SeekStart); seekErr != nil {
+			return nil, seekErr
+		}
+		staged, stageErr := stageArchive(file, key)
+		if stageErr != nil {
+			return nil, stageErr
+		}
+		defer os.Remove(staged)
+		snapshot = staged
+	}
+
+	report := &BackupReport{Path: path, Size: info.Size()}
+	if verifyErr := verifySnapshot(snapshot, report); verifyErr != nil {
+		return nil, verifyErr
+	}
+	report.Elapsed = time.Since(start)
+	return report, nil
+}
+
+func isArchive(header []byte) bool {
+	return bytes.HasPrefix(header, archiveMagic) || bytes.HasPrefix(header, gzipMagic) || bytes.HasPrefix(header, zstdMagic)
+}
+
+func stageArchive(r io.Reader, key []byte) (string, error) {
+	reader, closeFn, openErr := openArchive(r, key)
+	if openErr != nil {
+		return "", openErr
+	}
+	defer closeFn()
+
+	staged, createErr := os.CreateTemp("", "odin-verify-*.db")
+	if createErr != nil {
+		return "", createErr
+	}
+	_, copyErr := io.Copy(staged, reader)
+	if closeErr := staged.Close(); copyErr == nil {
+		copyErr = closeErr
+	}
+	if copyErr != nil {
+		os.Remove(staged.Name())
+		return "", fmt.Errorf("read archive: %w", copyErr)
+	}
+	return staged.Name(), nil
+}
+
+func verifySnapshot(path string, report *BackupReport) error {
+	snapshot, openErr := bolt.Open(path, 0400, &bolt.Options{ReadOnly: true, Timeout: time.Second})
+	if openErr != nil {
+		return fmt.Errorf("open backup: %w", openErr)
+	}
+	defer snapshot.Close()
+
+	return snapshot.View(func(tx *bolt.Tx) error {
+		for checkErr := range tx.Check() {
+			report.problem("", "", checkErr)
+		}
+
+		return tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			bucketName := string(name)
+			trash := isTrashBucket(bucketName)
+			decode := !strings.HasPrefix(bucketName, "__") || trash
+			report.Buckets++
+
+			return b.ForEach(func(k, v []byte) error {
+				if v == nil {
+					return nil
+				}
+				report.Records++
+				if !decode {
+					return nil
+				}
+				if trash {
+					if len(v) < keys.TimeSize {
+						report.problem(bucketName, string(k), fmt.Errorf("trash entry is missing its deletion time"))
+						return nil
+					}
+					v = v[keys.TimeSize:]
+				}
+				if isColdStub(v) {
+					report.Cold++
+					return nil
+				}
+
+				if codec := strings.TrimPrefix(compression.CodecName(v), "legacy-"); codec != "none" && codec != "raw" {
+					report.Compressed++
+				}
+				data, decodeErr := compression.Decompress(v)
+				if decodeErr != nil {
+					report.problem(bucketName, string(k), fmt.Errorf("decompress: %w", decodeErr))
+					return nil
+				}
+				if !js.Valid(data) {
+					report.problem(bucketName, string(k), fmt.Errorf("value is not valid JSON"))
+				}
+				return nil
+			})
+		})
+	})
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andr1ww/odin/internal/logger"
+)
+
+func TestBackupWithTrashVerifiesAndRestores(t *testing.T) {
+	logger.DisableLogging()
+	dir := t.TempDir()
+	name := "verify-trash"
+
+	if err := Connect(name, filepath.Join(dir, "main.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer Close(name)
+
+	db, err := GetNamed(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.EnableRecycleBin(time.Hour)
+	if err := db.CreateBucket("items"); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"kept", "trashed"} {
+		if err := db.Put("items", key, map[string]string{"name": key}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Delete("items", "trashed"); err != nil {
+		t.Fatal(err)
+	}
+	if trashed, err := db.ListTrash("items"); err != nil || len(trashed) != 1 {
+		t.Fatalf("expected one trashed record, got %v (%v)", trashed, err)
+	}
+
+	backup := filepath.Join(dir, "main.db.bak")
+	if err := db.Backup(backup); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := VerifyBackup(backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() {
+		t.Fatalf("backup with a trashed record failed verification: %v", report.Problems)
+	}
+
+	if err := Restore(name, backup, RestoreOptions{Overwrite: true}); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := GetNamed(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var kept map[string]string
+	if err := restored.Get("items", "kept", &kept); err != nil || kept["name"] != "kept" {
+		t.Fatalf("expected kept record after restore, got %v (%v)", kept, err)
+	}
+	if err := restored.Restore("items", "trashed"); err != nil {
+		t.Fatalf("restore trashed record: %v", err)
+	}
+	var trashed map[string]string
+	if err := restored.Get("items", "trashed", &trashed); err != nil || trashed["name"] != "trashed" {
+		t.Fatalf("expected trashed record back, got %v (%v)", trashed, err)
+	}
+}
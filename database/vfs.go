@@ -0,0 +1,53 @@
+package database
+
+import (
+	"io"
+	"os"
+)
+
+type File interface {
+	io.Reader
+	io.Writer
+	io.Closer
+	Sync() error
+}
+
+type FS interface {
+	OpenFile(name string, flag int, perm os.FileMode) (File, error)
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	Stat(name string) (os.FileInfo, error)
+}
+
+type osFS struct{}
+
+var OSFS FS = osFS{}
+
+func (osFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	return os.OpenFile(name, flag, perm)
+}
+
+func (osFS) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+func (osFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (osFS) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func WithFS(filesystem FS) Option {
+	return func(o *Options) {
+		o.FS = filesystem
+	}
+}
+
+func (o Options) fs() FS {
+	if o.FS == nil {
+		return OSFS
+	}
+	return o.FS
+}
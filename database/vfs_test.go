@@ -0,0 +1,132 @@
+package database
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andr1ww/odin/internal/logger"
+)
+
+type memFS struct {
+	mutex sync.Mutex
+	files map[string][]byte
+}
+
+type memFile struct {
+	bytes.Buffer
+	fs   *memFS
+	name string
+}
+
+type memInfo struct {
+	name string
+	size int64
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return i.size }
+func (i memInfo) Mode() fs.FileMode  { return 0600 }
+func (i memInfo) ModTime() time.Time { return time.Time{} }
+func (i memInfo) IsDir() bool        { return false }
+func (i memInfo) Sys() interface{}   { return nil }
+
+func (m *memFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	data, exists := m.files[name]
+	if !exists && flag&os.O_CREATE == 0 {
+		return nil, os.ErrNotExist
+	}
+	file := &memFile{fs: m, name: name}
+	if flag&os.O_TRUNC == 0 {
+		file.Write(data)
+	}
+	return file, nil
+}
+
+func (m *memFS) Rename(oldpath, newpath string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.files[newpath] = m.files[oldpath]
+	delete(m.files, oldpath)
+	return nil
+}
+
+func (m *memFS) Remove(name string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.files, name)
+	return nil
+}
+
+func (m *memFS) Stat(name string) (os.FileInfo, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	data, exists := m.files[name]
+	if !exists {
+		return nil, os.ErrNotExist
+	}
+	return memInfo{name: filepath.Base(name), size: int64(len(data))}, nil
+}
+
+func (f *memFile) Sync() error { return nil }
+
+func (f *memFile) Close() error {
+	f.fs.mutex.Lock()
+	defer f.fs.mutex.Unlock()
+	f.fs.files[f.name] = append([]byte(nil), f.Bytes()...)
+	return nil
+}
+
+func TestBackupsGoThroughFS(t *testing.T) {
+	logger.DisableLogging()
+	dir := t.TempDir()
+	name := "vfs-backups"
+	memory := &memFS{files: make(map[string][]byte)}
+
+	if err := Connect(name, filepath.Join(dir, "main.db"), WithFS(memory)); err != nil {
+		t.Fatal(err)
+	}
+	defer Close(name)
+
+	db, err := GetNamed(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateBucket("items"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put("items", "a", map[string]string{"name": "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	backup := filepath.Join(dir, "main.db.bak")
+	if err := db.Backup(backup); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(backup); !os.IsNotExist(err) {
+		t.Fatalf("backup reached the disk instead of the FS: %v", err)
+	}
+	if _, err := memory.Stat(backup); err != nil {
+		t.Fatal("backup was not written through the FS")
+	}
+
+	if err := db.Delete("items", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Restore(name, backup, RestoreOptions{Overwrite: true}); err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := db.Has("items", "a"); err != nil || !exists {
+		t.Fatalf("expected the record back from the in-memory backup, got %v (%v)", exists, err)
+	}
+	if err := db.Health(); err != nil {
+		t.Fatalf("health check looked at the FS instead of the data file: %v", err)
+	}
+}
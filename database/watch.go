@@ -0,0 +1,146 @@
+package database
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/andr1ww/odin/internal/logger"
+)
+
+type ChangeType int
+
+const (
+	ChangeInsert ChangeType = iota
+	ChangeUpdate
+	ChangeDelete
+	ChangeExpire
+	ChangeEvict
+)
+
+func (t ChangeType) String() string {
+	switch t {
+	case ChangeInsert:
+		return "insert"
+	case ChangeUpdate:
+		return "update"
+	case ChangeDelete:
+		return "delete"
+	case ChangeExpire:
+		return "expire"
+	case ChangeEvict:
+		return "evict"
+	}
+	return "unknown"
+}
+
+type Change struct {
+	Seq    uint64          `json:"seq"`
+	Bucket string          `json:"bucket"`
+	Key    string          `json:"key"`
+	Type   ChangeType      `json:"type"`
+	Old    json.RawMessage `json:"old,omitempty"`
+	Value  json.RawMessage `json:"value,omitempty"`
+	Time   time.Time       `json:"time"`
+}
+
+type watcher struct {
+	bucket string
+	ch     chan Change
+}
+
+func (db *DB) Watch(bucketName string, buffer int) (<-chan Change, func()) {
+	if buffer <= 0 {
+		buffer = 64
+	}
+
+	w := &watcher{bucket: bucketName, ch: make(chan Change, buffer)}
+
+	db.watchMutex.Lock()
+	db.nextWatcher++
+	id := db.nextWatcher
+	db.watchers[id] = w
+	db.watchMutex.Unlock()
+
+	cancel := func() {
+		db.watchMutex.Lock()
+		defer db.watchMutex.Unlock()
+		if _, exists := db.watchers[id]; exists {
+			delete(db.watchers, id)
+			close(w.ch)
+		}
+	}
+	return w.ch, cancel
+}
+
+func (db *DB) hasWatchers(bucketName string) bool {
+	db.watchMutex.RLock()
+	defer db.watchMutex.RUnlock()
+
+	for _, w := range db.watchers {
+		if w.bucket == "" || w.bucket == bucketName {
+			return true
+		}
+	}
+	return false
+}
+
+func (db *DB) hasObservers(bucketName string) bool {
+	return db.hasTriggers(bucketName) || db.hasWatchers(bucketName) || db.hasSubscribers(bucketName) || db.hasCommitHooks(bucketName)
+}
+
+func (db *DB) sequenceChanges(changes []Change) {
+	db.pubsubMutex.Lock()
+	defer db.pubsubMutex.Unlock()
+
+	for i := range changes {
+		db.changeSeq++
+		changes[i].Seq = db.changeSeq
+	}
+}
+
+func (db *DB) publishChanges(changes []Change) {
+	db.pubsubMutex.Lock()
+	defer db.pubsubMutex.Unlock()
+
+	for _, change := range changes {
+		db.recordHistory(change)
+		db.deliverSubscribers(change)
+	}
+	db.publishedSeq = changes[len(changes)-1].Seq
+}
+
+func (db *DB) deliverWatchers(change Change) {
+	db.watchMutex.RLock()
+	defer db.watchMutex.RUnlock()
+
+	for _, w := range db.watchers {
+		if w.bucket != "" && w.bucket != change.Bucket {
+			continue
+		}
+		select {
+		case w.ch <- change:
+		default:
+			logger.Warning("watch channel for bucket '%s' is full, dropping %s event for key '%s'", change.Bucket, change.Type, change.Key)
+		}
+	}
+}
+
+func changeTypeOf(old, data []byte) ChangeType {
+	switch {
+	case data == nil:
+		return ChangeDelete
+	case old == nil:
+		return ChangeInsert
+	}
+	return ChangeUpdate
+}
+
+func (db *DB) closeWatchers() {
+	db.watchMutex.Lock()
+	defer db.watchMutex.Unlock()
+
+	for id, w := range db.watchers {
+		delete(db.watchers, id)
+		close(w.ch)
+	}
+}
@@ -3,11 +3,45 @@ package errors
 import "errors"
 
 var (
-	ErrNotFound          = errors.New("record not found")
-	ErrBucketMissing     = errors.New("bucket does not exist")
-	ErrInvalidData       = errors.New("invalid data format")
-	ErrNilValue          = errors.New("nil value provided")
-	ErrDatabaseNotFound  = errors.New("database not found")
-	ErrDatabaseExists    = errors.New("database already exists")
-	ErrNoDefaultDatabase = errors.New("no default database set")
+	ErrNotFound            = errors.New("record not found")
+	ErrBucketMissing       = errors.New("bucket does not exist")
+	ErrInvalidData         = errors.New("invalid data format")
+	ErrNilValue            = errors.New("nil value provided")
+	ErrDatabaseNotFound    = errors.New("database not found")
+	ErrDatabaseExists      = errors.New("database already exists")
+	ErrNoDefaultDatabase   = errors.New("no default database set")
+	ErrVersionConflict     = errors.New("stream version conflict")
+	ErrConditionFailed     = errors.New("write condition not met")
+	ErrNotVersioned        = errors.New("bucket is not versioned")
+	ErrDatabasePathInUse   = errors.New("database file already in use")
+	ErrDatabaseLocked      = errors.New("database file is locked by another process")
+	ErrScopeNotFound       = errors.New("scope not registered")
+	ErrModelNotRegistered  = errors.New("bucket model not registered")
+	ErrQueueEmpty          = errors.New("queue is empty")
+	ErrLeaseExpired        = errors.New("message lease expired")
+	ErrSlowConsumer        = errors.New("subscriber fell behind and was disconnected")
+	ErrSequenceTruncated   = errors.New("change sequence no longer in history")
+	ErrLeaseHeld           = errors.New("lease is held by another owner")
+	ErrLeaseLost           = errors.New("lease is no longer held")
+	ErrQuotaExceeded       = errors.New("bucket quota exceeded")
+	ErrStandby             = errors.New("database is a read-only standby")
+	ErrNotLeader           = errors.New("node is not the cluster leader")
+	ErrReplica             = errors.New("database is a cluster replica; write through the cluster node")
+	ErrArchiveKeyRequired  = errors.New("backup archive is encrypted; a key is required")
+	ErrInvalidArchiveKey   = errors.New("wrong archive key or tampered archive")
+	ErrArchiveCorrupt      = errors.New("backup archive is truncated or corrupt")
+	ErrBackupInvalid       = errors.New("backup failed verification")
+	ErrAliasCycle          = errors.New("database alias would form a cycle")
+	ErrDatabaseReplaced    = errors.New("database file was replaced or removed on disk")
+	ErrReopenFailed        = errors.New("database could not be reopened")
+	ErrReadOnly            = errors.New("database was opened read-only")
+	ErrCorruptValue        = errors.New("stored value is corrupt")
+	ErrDecodeFailed        = errors.New("stored value could not be decoded")
+	ErrUnknownFormat       = errors.New("stored value uses an unregistered format")
+	ErrUnsupportedEnvelope = errors.New("stored value envelope is not supported")
+	ErrFormatRegistered    = errors.New("value format id already registered")
+	ErrInvalidKey          = errors.New("key does not decode as the requested type")
+	ErrMissingKey          = errors.New("record key field is empty")
+	ErrAmbiguousKey        = errors.New("model has more than one key field")
+	ErrOverloaded          = errors.New("database is overloaded")
 )
@@ -0,0 +1,98 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"math"
+	"sync/atomic"
+)
+
+type Filter struct {
+	words  []uint64
+	bits   uint64
+	hashes uint64
+}
+
+func New(expected int, falsePositiveRate float64) *Filter {
+	if expected < 1 {
+		expected = 1
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.01
+	}
+
+	bits := uint64(math.Ceil(-float64(expected) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	bits = max(64, (bits+63)/64*64)
+	hashes := uint64(math.Round(float64(bits) / float64(expected) * math.Ln2))
+	hashes = min(max(1, hashes), 30)
+
+	return &Filter{words: make([]uint64, bits/64), bits: bits, hashes: hashes}
+}
+
+func (f *Filter) Bits() uint64 {
+	return f.bits
+}
+
+func (f *Filter) Hashes() uint64 {
+	return f.hashes
+}
+
+func (f *Filter) locations(key []byte) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write(key)
+	h1 := h.Sum64()
+	h2 := h1>>33 | h1<<31
+	return h1, h2 | 1
+}
+
+func (f *Filter) Add(key []byte) {
+	h1, h2 := f.locations(key)
+	for i := uint64(0); i < f.hashes; i++ {
+		bit := (h1 + i*h2) % f.bits
+		word, mask := &f.words[bit/64], uint64(1)<<(bit%64)
+		for {
+			old := atomic.LoadUint64(word)
+			if old&mask != 0 || atomic.CompareAndSwapUint64(word, old, old|mask) {
+				break
+			}
+		}
+	}
+}
+
+func (f *Filter) Test(key []byte) bool {
+	h1, h2 := f.locations(key)
+	for i := uint64(0); i < f.hashes; i++ {
+		bit := (h1 + i*h2) % f.bits
+		if atomic.LoadUint64(&f.words[bit/64])&(uint64(1)<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *Filter) MarshalBinary() []byte {
+	data := make([]byte, 16+8*len(f.words))
+	binary.BigEndian.PutUint64(data[0:], f.bits)
+	binary.BigEndian.PutUint64(data[8:], f.hashes)
+	for i := range f.words {
+		binary.BigEndian.PutUint64(data[16+8*i:], atomic.LoadUint64(&f.words[i]))
+	}
+	return data
+}
+
+func Unmarshal(data []byte) (*Filter, error) {
+	if len(data) < 16 {
+		return nil, errors.New("bloom filter data too short")
+	}
+	bits, hashes := binary.BigEndian.Uint64(data[0:]), binary.BigEndian.Uint64(data[8:])
+	if bits == 0 || bits%64 != 0 || uint64(len(data)-16) != bits/8 {
+		return nil, errors.New("bloom filter data is corrupt")
+	}
+
+	f := &Filter{words: make([]uint64, bits/64), bits: bits, hashes: hashes}
+	for i := range f.words {
+		f.words[i] = binary.BigEndian.Uint64(data[16+8*i:])
+	}
+	return f, nil
+}
@@ -6,8 +6,10 @@ import (
 	"compress/gzip"
 	"compress/lzw"
 	"compress/zlib"
+	"fmt"
 	"io"
-	"sync"
+
+	"github.com/andr1ww/odin/internal/pool"
 )
 
 const (
@@ -16,73 +18,69 @@ const (
 	Zlib
 	Flate
 	LZW
-	threshold = 50
 )
 
+const (
+	HuffmanOnly        = flate.HuffmanOnly
+	DefaultCompression = flate.DefaultCompression
+	NoCompression      = flate.NoCompression
+	BestSpeed          = flate.BestSpeed
+	BestCompression    = flate.BestCompression
+
+	DefaultThreshold = 50
+)
+
+type Settings struct {
+	Level     int
+	Threshold int
+}
+
+func Defaults() Settings {
+	return Settings{Level: DefaultCompression, Threshold: DefaultThreshold}
+}
+
+func ValidLevel(level int) bool {
+	return level >= HuffmanOnly && level <= BestCompression
+}
+
 var (
-	gzipReaderPool = sync.Pool{
-		New: func() interface{} {
-			return &gzip.Reader{}
-		},
-	}
-	zlibReaderPool = sync.Pool{
-		New: func() interface{} {
-			r, _ := zlib.NewReader(nil)
-			return r
-		},
-	}
-	flateReaderPool = sync.Pool{
-		New: func() interface{} {
-			return flate.NewReader(nil)
-		},
-	}
-	lzwReaderPool = sync.Pool{
-		New: func() interface{} {
-			return lzw.NewReader(nil, lzw.LSB, 8)
-		},
-	}
-	bufferPool = sync.Pool{
-		New: func() interface{} {
-			return make([]byte, 0, 1024)
-		},
-	}
+	gzipReaderPool = pool.New("compression.gzip", func() *gzip.Reader {
+		return &gzip.Reader{}
+	}, nil)
+	zlibReaderPool = pool.New[io.ReadCloser]("compression.zlib", func() io.ReadCloser {
+		return nil
+	}, nil)
+	flateReaderPool = pool.New("compression.flate", func() io.ReadCloser {
+		return flate.NewReader(nil)
+	}, nil)
 )
 
 func CompressData(data []byte) []byte {
-	if len(data) < threshold {
-		result := make([]byte, len(data)+1)
-		result[0] = None
-		copy(result[1:], data)
-		return result
+	return CompressWith(data, Defaults())
+}
+
+func CompressWith(data []byte, settings Settings) []byte {
+	if !ValidLevel(settings.Level) {
+		settings.Level = DefaultCompression
 	}
 
-	compressors := []struct {
-		id   byte
-		comp func([]byte) ([]byte, error)
-	}{
-		{Gzip, compressGzip},
-		{Zlib, compressZlib},
-		{Flate, compressFlate},
-		{LZW, compressLZW},
+	if len(data) < settings.Threshold || settings.Level == NoCompression {
+		return seal(None, data)
 	}
 
 	best := data
 	bestType := byte(None)
 
-	for _, c := range compressors {
-		if compressed, err := c.comp(data); err == nil && len(compressed) < len(best) {
+	for _, f := range formatEncoders() {
+		if compressed, err := f.Encode(data, settings.Level); err == nil && len(compressed) < len(best) {
 			best = compressed
-			bestType = c.id
+			bestType = f.ID
 		}
 	}
-
-	result := make([]byte, len(best)+1)
-	result[0] = bestType
-	copy(result[1:], best)
-	return result
+	return seal(bestType, best)
 }
 
-func compressLZW(data []byte) ([]byte, error) {
+func compressLZW(data []byte, _ int) ([]byte, error) {
 	var buf bytes.Buffer
 	writer := lzw.NewWriter(&buf, lzw.LSB, 8)
 	_, err := writer.Write(data)
@@ -90,25 +88,25 @@ func compressLZW(data []byte) ([]byte, error) {
 	return buf.Bytes(), err
 }
 
-func compressFlate(data []byte) ([]byte, error) {
+func compressFlate(data []byte, level int) ([]byte, error) {
 	var buf bytes.Buffer
-	writer, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+	writer, _ := flate.NewWriter(&buf, level)
 	_, err := writer.Write(data)
 	writer.Close()
 	return buf.Bytes(), err
 }
 
-func compressZlib(data []byte) ([]byte, error) {
+func compressZlib(data []byte, level int) ([]byte, error) {
 	var buf bytes.Buffer
-	writer, _ := zlib.NewWriterLevel(&buf, zlib.DefaultCompression)
+	writer, _ := zlib.NewWriterLevel(&buf, level)
 	_, err := writer.Write(data)
 	writer.Close()
 	return buf.Bytes(), err
 }
 
-func compressGzip(data []byte) ([]byte, error) {
+func compressGzip(data []byte, level int) ([]byte, error) {
 	var buf bytes.Buffer
-	writer, _ := gzip.NewWriterLevel(&buf, gzip.DefaultCompression)
+	writer, _ := gzip.NewWriterLevel(&buf, level)
 	_, err := writer.Write(data)
 	writer.Close()
 	return buf.Bytes(), err
@@ -119,15 +117,19 @@ func DecompressData(data []byte) []byte {
 		return data
 	}
 
+	if IsEnveloped(data) {
+		if result, err := open(data); err == nil {
+			return result
+		}
+		return data
+	}
+
 	if len(data) > 0 && (data[0] == 0 || data[0] == 1) {
 		if data[0] == 1 {
-			reader := gzipReaderPool.Get().(*gzip.Reader)
+			reader := gzipReaderPool.Get()
 			defer gzipReaderPool.Put(reader)
 
 			if err := reader.Reset(bytes.NewReader(data[1:])); err == nil {
-				buf := bufferPool.Get().([]byte)
-				defer bufferPool.Put(buf[:0])
-
 				if result, err := io.ReadAll(reader); err == nil {
 					reader.Close()
 					return result
@@ -146,7 +148,7 @@ func DecompressData(data []byte) []byte {
 		case None:
 			return compressedData
 		case Gzip:
-			reader := gzipReaderPool.Get().(*gzip.Reader)
+			reader := gzipReaderPool.Get()
 			defer gzipReaderPool.Put(reader)
 
 			if err := reader.Reset(bytes.NewReader(compressedData)); err == nil {
@@ -157,27 +159,25 @@ func DecompressData(data []byte) []byte {
 				reader.Close()
 			}
 		case Zlib:
-			reader := zlibReaderPool.Get().(io.ReadCloser)
-			defer zlibReaderPool.Put(reader)
-
-			if zlibReader, ok := reader.(interface{ Reset(io.Reader, []byte) error }); ok {
-				if err := zlibReader.Reset(bytes.NewReader(compressedData), nil); err == nil {
-					if result, err := io.ReadAll(reader); err == nil {
-						reader.Close()
-						return result
-					}
-					reader.Close()
-				}
+			source := bytes.NewReader(compressedData)
+			reader := zlibReaderPool.Get()
+
+			var err error
+			if reader == nil {
+				reader, err = zlib.NewReader(source)
 			} else {
-				if reader, err := zlib.NewReader(bytes.NewReader(compressedData)); err == nil {
-					defer reader.Close()
-					if result, err := io.ReadAll(reader); err == nil {
-						return result
-					}
+				err = reader.(zlib.Resetter).Reset(source, nil)
+			}
+			if err == nil {
+				result, readErr := io.ReadAll(reader)
+				reader.Close()
+				zlibReaderPool.Put(reader)
+				if readErr == nil {
+					return result
 				}
 			}
 		case Flate:
-			reader := flateReaderPool.Get().(io.ReadCloser)
+			reader := flateReaderPool.Get()
 			defer flateReaderPool.Put(reader)
 
 			if flateReader, ok := reader.(flate.Resetter); ok {
@@ -202,7 +202,7 @@ func DecompressData(data []byte) []byte {
 	}
 
 	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
-		reader := gzipReaderPool.Get().(*gzip.Reader)
+		reader := gzipReaderPool.Get()
 		defer gzipReaderPool.Put(reader)
 
 		if err := reader.Reset(bytes.NewReader(data)); err == nil {
@@ -216,3 +216,141 @@ func DecompressData(data []byte) []byte {
 
 	return data
 }
+
+func Decompress(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+
+	if IsEnveloped(data) {
+		return open(data)
+	}
+	if data[0] <= LZW {
+		return decodeTagged(data[0], data[1:])
+	}
+	if isRawGzip(data) {
+		return decodeGzip(data)
+	}
+	return data, nil
+}
+
+func DecompressInto(buf *bytes.Buffer, data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+
+	if IsEnveloped(data) {
+		return openInto(data, buf)
+	}
+	if data[0] <= LZW {
+		return readInto(data[0], data[1:], buf)
+	}
+	if isRawGzip(data) {
+		return readInto(Gzip, data, buf)
+	}
+	return data, nil
+}
+
+func isRawGzip(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+func decodeTagged(codec byte, payload []byte) ([]byte, error) {
+	switch codec {
+	case Gzip, Zlib, Flate, LZW:
+		return readInto(codec, payload, &bytes.Buffer{})
+	}
+	return payload, nil
+}
+
+func decodeFlate(payload []byte) ([]byte, error) {
+	return readInto(Flate, payload, &bytes.Buffer{})
+}
+
+func decodeLZW(payload []byte) ([]byte, error) {
+	return readInto(LZW, payload, &bytes.Buffer{})
+}
+
+func decodeGzip(payload []byte) ([]byte, error) {
+	return readInto(Gzip, payload, &bytes.Buffer{})
+}
+
+func decodeZlib(payload []byte) ([]byte, error) {
+	return readInto(Zlib, payload, &bytes.Buffer{})
+}
+
+func readInto(codec byte, payload []byte, buf *bytes.Buffer) ([]byte, error) {
+	buf.Reset()
+	source := bytes.NewReader(payload)
+
+	switch codec {
+	case Gzip:
+		reader := gzipReaderPool.Get()
+		defer gzipReaderPool.Put(reader)
+		if err := reader.Reset(source); err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		if _, err := buf.ReadFrom(reader); err != nil {
+			return nil, err
+		}
+	case Zlib:
+		reader := zlibReaderPool.Get()
+		var err error
+		if reader == nil {
+			reader, err = zlib.NewReader(source)
+		} else {
+			err = reader.(zlib.Resetter).Reset(source, nil)
+		}
+		if err != nil {
+			return nil, err
+		}
+		_, err = buf.ReadFrom(reader)
+		reader.Close()
+		zlibReaderPool.Put(reader)
+		if err != nil {
+			return nil, err
+		}
+	case Flate:
+		reader := flateReaderPool.Get()
+		defer flateReaderPool.Put(reader)
+		defer reader.Close()
+		reader.(flate.Resetter).Reset(source, nil)
+		if _, err := buf.ReadFrom(reader); err != nil {
+			return nil, err
+		}
+	case LZW:
+		reader := lzw.NewReader(source, lzw.LSB, 8)
+		defer reader.Close()
+		if _, err := buf.ReadFrom(reader); err != nil {
+			return nil, err
+		}
+	default:
+		return payload, nil
+	}
+	return buf.Bytes(), nil
+}
+
+func CodecName(data []byte) string {
+	if len(data) == 0 {
+		return "empty"
+	}
+
+	if header, ok := ParseHeader(data); ok {
+		if header.Version != EnvelopeVersion {
+			return fmt.Sprintf("unsupported-v%d", header.Version)
+		}
+		if f, known := lookupFormat(header.Codec); known {
+			return f.Name
+		}
+		return fmt.Sprintf("unknown-%d", header.Codec)
+	}
+	if data[0] <= LZW {
+		f, _ := lookupFormat(data[0])
+		return "legacy-" + f.Name
+	}
+	if isRawGzip(data) {
+		return "legacy-gzip-raw"
+	}
+	return "raw"
+}
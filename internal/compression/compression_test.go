@@ -0,0 +1,60 @@
+package compression
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLegacyFormatsRoundTrip(t *testing.T) {
+	data := []byte(`{"name":"` + strings.Repeat("legacy ", 32) + `"}`)
+
+	encoders := map[string]func([]byte, int) ([]byte, error){
+		"gzip":  compressGzip,
+		"zlib":  compressZlib,
+		"flate": compressFlate,
+		"lzw":   compressLZW,
+	}
+	legacy := map[string][]byte{
+		"none": append([]byte{None}, data...),
+	}
+	for name, codec := range map[string]byte{"gzip": Gzip, "zlib": Zlib, "flate": Flate, "lzw": LZW} {
+		payload, err := encoders[name](data, DefaultCompression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		legacy[name] = append([]byte{codec}, payload...)
+	}
+	rawGzip, err := compressGzip(data, DefaultCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy["raw gzip"] = rawGzip
+
+	for name, stored := range legacy {
+		if got := DecompressData(stored); !bytes.Equal(got, data) {
+			t.Errorf("DecompressData(%s) = %q", name, got)
+		}
+		if got, err := Decompress(stored); err != nil || !bytes.Equal(got, data) {
+			t.Errorf("Decompress(%s) = %q, %v", name, got, err)
+		}
+	}
+}
+
+func TestLegacyFallbacks(t *testing.T) {
+	cases := map[string]struct {
+		stored []byte
+		want   []byte
+	}{
+		"corrupt gzip tag":  {stored: []byte{Gzip, 'x', 'y'}, want: []byte("xy")},
+		"corrupt zlib tag":  {stored: []byte{Zlib, 'x', 'y'}, want: []byte{Zlib, 'x', 'y'}},
+		"corrupt raw gzip":  {stored: []byte{0x1f, 0x8b, 'x'}, want: []byte{0x1f, 0x8b, 'x'}},
+		"plain json":        {stored: []byte(`{"a":1}`), want: []byte(`{"a":1}`)},
+		"empty none tagged": {stored: []byte{None}, want: []byte{}},
+	}
+	for name, c := range cases {
+		if got := DecompressData(c.stored); !bytes.Equal(got, c.want) {
+			t.Errorf("DecompressData(%s) = %q, want %q", name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package compression
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/andr1ww/odin/errors"
+)
+
+const (
+	EnvelopeVersion = 1
+
+	envelopeMagic0 = 0xC0
+	envelopeMagic1 = 0xDE
+	envelopeHeader = 5
+
+	reservedFormats = 16
+)
+
+type Format struct {
+	ID     byte
+	Name   string
+	Encode func(data []byte, level int) ([]byte, error)
+	Decode func(payload []byte) ([]byte, error)
+}
+
+type Header struct {
+	Version byte
+	Codec   byte
+	Flags   byte
+}
+
+var registry = struct {
+	mutex    sync.RWMutex
+	formats  map[byte]Format
+	encoders []Format
+}{formats: make(map[byte]Format)}
+
+func init() {
+	for _, f := range []Format{
+		{ID: None, Name: "none"},
+		{ID: Gzip, Name: "gzip", Encode: compressGzip, Decode: decodeGzip},
+		{ID: Zlib, Name: "zlib", Encode: compressZlib, Decode: decodeZlib},
+		{ID: Flate, Name: "flate", Encode: compressFlate, Decode: decodeFlate},
+		{ID: LZW, Name: "lzw", Encode: compressLZW, Decode: decodeLZW},
+	} {
+		registry.formats[f.ID] = f
+	}
+	registry.encoders = sortedEncoders()
+}
+
+func RegisterFormat(f Format) error {
+	if f.ID < reservedFormats {
+		return fmt.Errorf("%w: format ids below %d are reserved", errors.ErrFormatRegistered, reservedFormats)
+	}
+	if f.Name == "" || f.Encode == nil || f.Decode == nil {
+		return fmt.Errorf("format %d needs a name, an encoder and a decoder", f.ID)
+	}
+
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	if existing, taken := registry.formats[f.ID]; taken {
+		return fmt.Errorf("%w: id %d is used by '%s'", errors.ErrFormatRegistered, f.ID, existing.Name)
+	}
+	registry.formats[f.ID] = f
+	registry.encoders = sortedEncoders()
+	return nil
+}
+
+func Formats() []Format {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	formats := make([]Format, 0, len(registry.formats))
+	for _, f := range registry.formats {
+		formats = append(formats, f)
+	}
+	sort.Slice(formats, func(i, j int) bool {
+		return formats[i].ID < formats[j].ID
+	})
+	return formats
+}
+
+func sortedEncoders() []Format {
+	var encoders []Format
+	for _, f := range registry.formats {
+		if f.Encode != nil {
+			encoders = append(encoders, f)
+		}
+	}
+	sort.Slice(encoders, func(i, j int) bool {
+		return encoders[i].ID < encoders[j].ID
+	})
+	return encoders
+}
+
+func formatEncoders() []Format {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	return registry.encoders
+}
+
+func lookupFormat(id byte) (Format, bool) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	f, ok := registry.formats[id]
+	return f, ok
+}
+
+func seal(codec byte, payload []byte) []byte {
+	result := make([]byte, envelopeHeader+len(payload))
+	result[0], result[1] = envelopeMagic0, envelopeMagic1
+	result[2] = EnvelopeVersion
+	result[3] = codec
+	copy(result[envelopeHeader:], payload)
+	return result
+}
+
+func IsEnveloped(data []byte) bool {
+	return len(data) >= envelopeHeader && data[0] == envelopeMagic0 && data[1] == envelopeMagic1
+}
+
+func IsLegacy(data []byte) bool {
+	return len(data) > 0 && (data[0] <= LZW || isRawGzip(data))
+}
+
+func ParseHeader(data []byte) (Header, bool) {
+	if !IsEnveloped(data) {
+		return Header{}, false
+	}
+	return Header{Version: data[2], Codec: data[3], Flags: data[4]}, true
+}
+
+func open(data []byte) ([]byte, error) {
+	return openInto(data, nil)
+}
+
+func openInto(data []byte, buf *bytes.Buffer) ([]byte, error) {
+	header, _ := ParseHeader(data)
+	if header.Version != EnvelopeVersion {
+		return nil, fmt.Errorf("%w: version %d", errors.ErrUnsupportedEnvelope, header.Version)
+	}
+	if header.Flags != 0 {
+		return nil, fmt.Errorf("%w: flags %#x", errors.ErrUnsupportedEnvelope, header.Flags)
+	}
+
+	f, ok := lookupFormat(header.Codec)
+	if !ok {
+		return nil, fmt.Errorf("%w: codec %d", errors.ErrUnknownFormat, header.Codec)
+	}
+	payload := data[envelopeHeader:]
+	if f.Decode == nil {
+		return payload, nil
+	}
+	if buf != nil && f.ID <= LZW {
+		return readInto(f.ID, payload, buf)
+	}
+	return f.Decode(payload)
+}
@@ -0,0 +1,134 @@
+package geo
+
+import (
+	"math"
+	"strings"
+)
+
+const (
+	base32       = "0123456789bcdefghjkmnpqrstuvwxyz"
+	MaxPrecision = 12
+	earthRadius  = 6371008.8
+	maxCells     = 32
+)
+
+func Encode(lat, lng float64, precision int) string {
+	if precision <= 0 || precision > MaxPrecision {
+		precision = MaxPrecision
+	}
+
+	latRange := [2]float64{-90, 90}
+	lngRange := [2]float64{-180, 180}
+
+	var hash strings.Builder
+	bit, ch, even := 0, 0, true
+	for hash.Len() < precision {
+		if even {
+			mid := (lngRange[0] + lngRange[1]) / 2
+			if lng >= mid {
+				ch |= 1 << (4 - bit)
+				lngRange[0] = mid
+			} else {
+				lngRange[1] = mid
+			}
+		} else {
+			mid := (latRange[0] + latRange[1]) / 2
+			if lat >= mid {
+				ch |= 1 << (4 - bit)
+				latRange[0] = mid
+			} else {
+				latRange[1] = mid
+			}
+		}
+		even = !even
+
+		if bit < 4 {
+			bit++
+			continue
+		}
+		hash.WriteByte(base32[ch])
+		bit, ch = 0, 0
+	}
+	return hash.String()
+}
+
+func cellSize(precision int) (latDeg, lngDeg float64) {
+	bits := 5 * precision
+	lngBits := (bits + 1) / 2
+	latBits := bits / 2
+	return 180 / math.Pow(2, float64(latBits)), 360 / math.Pow(2, float64(lngBits))
+}
+
+func Cover(lat, lng, radius float64) []string {
+	dLat := radius / earthRadius * 180 / math.Pi
+	cosLat := math.Cos(lat * math.Pi / 180)
+	dLng := 180.0
+	if cosLat > 1e-9 {
+		dLng = math.Min(180, dLat/cosLat)
+	}
+
+	minLat, maxLat := math.Max(-90, lat-dLat), math.Min(90, lat+dLat)
+	minLng, maxLng := lng-dLng, lng+dLng
+
+	precision := 1
+	for p := MaxPrecision; p >= 1; p-- {
+		cellLat, cellLng := cellSize(p)
+		rows := math.Ceil((maxLat-minLat)/cellLat) + 1
+		cols := math.Ceil((maxLng-minLng)/cellLng) + 1
+		if rows*cols <= maxCells {
+			precision = p
+			break
+		}
+	}
+
+	cellLat, cellLng := cellSize(precision)
+	seen := make(map[string]bool)
+	var cells []string
+	for y := minLat; ; y += cellLat {
+		if y > maxLat {
+			y = maxLat
+		}
+		for x := minLng; ; x += cellLng {
+			if x > maxLng {
+				x = maxLng
+			}
+
+			hash := Encode(y, wrapLng(x), precision)
+			if !seen[hash] {
+				seen[hash] = true
+				cells = append(cells, hash)
+			}
+			if x >= maxLng {
+				break
+			}
+		}
+		if y >= maxLat {
+			break
+		}
+	}
+	return cells
+}
+
+func wrapLng(lng float64) float64 {
+	for lng < -180 {
+		lng += 360
+	}
+	for lng >= 180 {
+		lng -= 360
+	}
+	return lng
+}
+
+func Distance(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLng := (lng2 - lng1) * toRad
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+func Valid(lat, lng float64) bool {
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180
+}
@@ -0,0 +1,406 @@
+package indexing
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/andr1ww/odin/internal/logger"
+	"github.com/andr1ww/odin/internal/reflection"
+	jsoniter "github.com/json-iterator/go"
+	bolt "go.etcd.io/bbolt"
+)
+
+const diskIndexPrefix = "__idx_"
+
+var (
+	js = jsoniter.ConfigCompatibleWithStandardLibrary
+
+	postingsBucket = []byte("postings")
+	entriesBucket  = []byte("entries")
+
+	diskMutex  sync.RWMutex
+	diskStores = make(map[string]*diskStore)
+)
+
+const (
+	valuePosting   = 'v'
+	elementPosting = 'e'
+)
+
+type diskStore struct {
+	owner  string
+	open   func() *bolt.DB
+	bucket []byte
+	cache  *postingCache
+}
+
+func DiskIndexBucket(bucketName string) string {
+	return diskIndexPrefix + bucketName
+}
+
+func EnableDiskIndex(owner string, open func() *bolt.DB, bucketName string, cacheEntries int) error {
+	store := &diskStore{
+		owner:  owner,
+		open:   open,
+		bucket: []byte(DiskIndexBucket(bucketName)),
+		cache:  newPostingCache(cacheEntries),
+	}
+
+	err := open().Update(func(tx *bolt.Tx) error {
+		root, err := tx.CreateBucketIfNotExists(store.bucket)
+		if err != nil {
+			return err
+		}
+		if _, err := root.CreateBucketIfNotExists(postingsBucket); err != nil {
+			return err
+		}
+		_, err = root.CreateBucketIfNotExists(entriesBucket)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("create disk index for %s: %w", bucketName, err)
+	}
+
+	diskMutex.Lock()
+	diskStores[bucketName] = store
+	diskMutex.Unlock()
+
+	indexMutex.Lock()
+	delete(bucketIndexes, bucketName)
+	delete(elementIndexes, bucketName)
+	indexMutex.Unlock()
+	return nil
+}
+
+func DisableDiskIndex(bucketName string) {
+	diskMutex.Lock()
+	defer diskMutex.Unlock()
+	delete(diskStores, bucketName)
+}
+
+func DisableDiskIndexes(owner string) {
+	diskMutex.Lock()
+	defer diskMutex.Unlock()
+
+	for bucketName, store := range diskStores {
+		if store.owner == owner {
+			delete(diskStores, bucketName)
+		}
+	}
+}
+
+func DiskIndexes(owner string) map[string]int {
+	diskMutex.RLock()
+	defer diskMutex.RUnlock()
+
+	indexes := make(map[string]int)
+	for bucketName, store := range diskStores {
+		if store.owner == owner {
+			indexes[bucketName] = store.cache.capacity
+		}
+	}
+	return indexes
+}
+
+func DiskIndexed(bucketName string) bool {
+	return diskStoreFor(bucketName) != nil
+}
+
+func diskStoreFor(bucketName string) *diskStore {
+	diskMutex.RLock()
+	defer diskMutex.RUnlock()
+	return diskStores[bucketName]
+}
+
+func encodeIndexValue(value interface{}) ([]byte, bool) {
+	value, ok := indexValue(value)
+	if !ok {
+		return nil, false
+	}
+
+	data, err := js.Marshal(value)
+	if err != nil {
+		return nil, false
+	}
+
+	typeName := "nil"
+	if value != nil {
+		typeName = reflect.TypeOf(value).String()
+	}
+	return append(append([]byte(typeName), 0x1f), data...), true
+}
+
+func postingPrefix(field string, kind byte, encoded []byte) []byte {
+	prefix := make([]byte, 0, len(field)+len(encoded)+4)
+	prefix = append(prefix, field...)
+	prefix = append(prefix, 0, kind, 0)
+	prefix = append(prefix, encoded...)
+	return append(prefix, 0)
+}
+
+func (s *diskStore) postings(fields []fieldEntry) [][]byte {
+	var prefixes [][]byte
+	for _, field := range fields {
+		if elements, ok := sliceElements(field.value); ok {
+			for _, element := range elements {
+				if encoded, ok := encodeIndexValue(element); ok {
+					prefixes = append(prefixes, postingPrefix(field.name, elementPosting, encoded))
+				}
+			}
+			continue
+		}
+
+		if encoded, ok := encodeIndexValue(field.value); ok {
+			prefixes = append(prefixes, postingPrefix(field.name, valuePosting, encoded))
+		}
+	}
+	return prefixes
+}
+
+func UpdateDiskIndex(tx *bolt.Tx, bucketName, key string, entity interface{}) error {
+	store := diskStoreFor(bucketName)
+	if store == nil {
+		return nil
+	}
+	return store.updateTx(tx, key, entityFields(entity))
+}
+
+func RemoveFromDiskIndex(tx *bolt.Tx, bucketName, key string) error {
+	store := diskStoreFor(bucketName)
+	if store == nil {
+		return nil
+	}
+	return store.removeTx(tx, key)
+}
+
+func (s *diskStore) update(key string, fields []fieldEntry) {
+	err := s.open().Update(func(tx *bolt.Tx) error {
+		return s.updateTx(tx, key, fields)
+	})
+	if err != nil {
+		logger.Error("updating disk index %s for key %s: %v", s.bucket, key, err)
+	}
+}
+
+func (s *diskStore) updateTx(tx *bolt.Tx, key string, fields []fieldEntry) error {
+	next := s.postings(fields)
+
+	root := tx.Bucket(s.bucket)
+	if root == nil {
+		return fmt.Errorf("disk index bucket %s missing", s.bucket)
+	}
+	postings, entries := root.Bucket(postingsBucket), root.Bucket(entriesBucket)
+
+	if err := s.removePostings(tx, postings, entries, key); err != nil {
+		return err
+	}
+
+	for _, prefix := range next {
+		if err := postings.Put(append(append([]byte(nil), prefix...), key...), nil); err != nil {
+			return err
+		}
+		s.invalidateOnCommit(tx, prefix)
+	}
+
+	data, err := js.Marshal(next)
+	if err != nil {
+		return err
+	}
+	return entries.Put([]byte(key), data)
+}
+
+func (s *diskStore) remove(key string) {
+	err := s.open().Update(func(tx *bolt.Tx) error {
+		return s.removeTx(tx, key)
+	})
+	if err != nil {
+		logger.Error("removing %s from disk index %s: %v", key, s.bucket, err)
+	}
+}
+
+func (s *diskStore) removeTx(tx *bolt.Tx, key string) error {
+	root := tx.Bucket(s.bucket)
+	if root == nil {
+		return nil
+	}
+	return s.removePostings(tx, root.Bucket(postingsBucket), root.Bucket(entriesBucket), key)
+}
+
+func (s *diskStore) invalidateOnCommit(tx *bolt.Tx, prefix []byte) {
+	s.cache.invalidate(string(prefix))
+	tx.OnCommit(func() { s.cache.invalidate(string(prefix)) })
+}
+
+func (s *diskStore) removePostings(tx *bolt.Tx, postings, entries *bolt.Bucket, key string) error {
+	data := entries.Get([]byte(key))
+	if data == nil {
+		return nil
+	}
+
+	var previous [][]byte
+	if err := js.Unmarshal(data, &previous); err != nil {
+		return err
+	}
+
+	for _, prefix := range previous {
+		if err := postings.Delete(append(append([]byte(nil), prefix...), key...)); err != nil {
+			return err
+		}
+		s.invalidateOnCommit(tx, prefix)
+	}
+	return entries.Delete([]byte(key))
+}
+
+func (s *diskStore) scan(prefix []byte) ([]string, error) {
+	if keys, ok := s.cache.get(string(prefix)); ok {
+		return keys, nil
+	}
+
+	var keys []string
+	err := s.open().View(func(tx *bolt.Tx) error {
+		root := tx.Bucket(s.bucket)
+		if root == nil {
+			return nil
+		}
+
+		c := root.Bucket(postingsBucket).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			keys = append(keys, string(k[len(prefix):]))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.put(string(prefix), keys)
+	return keys, nil
+}
+
+func (s *diskStore) lookup(field string, value interface{}) ([]string, bool) {
+	switch lookup := value.(type) {
+	case reflection.ElementLookup:
+		return s.union(field, elementPosting, lookup.ElementValues(), false)
+	case reflection.ValueSetLookup:
+		return s.union(field, valuePosting, lookup.IndexValues(), true)
+	case reflection.Operator:
+		return nil, false
+	}
+
+	keys, found := s.union(field, valuePosting, []interface{}{value}, true)
+	return keys, found
+}
+
+func (s *diskStore) union(field string, kind byte, values []interface{}, requireAll bool) ([]string, bool) {
+	if len(values) == 0 {
+		return nil, false
+	}
+
+	seen := make(map[string]bool)
+	var result []string
+	found := false
+	for _, value := range values {
+		encoded, ok := encodeIndexValue(value)
+		if !ok {
+			return nil, false
+		}
+
+		keys, err := s.scan(postingPrefix(field, kind, encoded))
+		if err != nil {
+			logger.Error("reading disk index %s: %v", s.bucket, err)
+			return nil, false
+		}
+		if len(keys) == 0 {
+			if requireAll {
+				return nil, false
+			}
+			continue
+		}
+
+		found = true
+		for _, key := range keys {
+			if !seen[key] {
+				seen[key] = true
+				result = append(result, key)
+			}
+		}
+	}
+	return result, found
+}
+
+type postingCache struct {
+	mutex    sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+type cacheEntry struct {
+	prefix string
+	keys   []string
+}
+
+func newPostingCache(capacity int) *postingCache {
+	return &postingCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (c *postingCache) get(prefix string) ([]string, bool) {
+	if c.capacity <= 0 {
+		return nil, false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, exists := c.entries[prefix]
+	if !exists {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+
+	keys := element.Value.(*cacheEntry).keys
+	return append([]string(nil), keys...), true
+}
+
+func (c *postingCache) put(prefix string, keys []string) {
+	if c.capacity <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, exists := c.entries[prefix]; exists {
+		element.Value.(*cacheEntry).keys = keys
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[prefix] = c.order.PushFront(&cacheEntry{prefix: prefix, keys: keys})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).prefix)
+	}
+}
+
+func (c *postingCache) invalidate(prefix string) {
+	if c.capacity <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, exists := c.entries[prefix]; exists {
+		c.order.Remove(element)
+		delete(c.entries, prefix)
+	}
+}
@@ -9,14 +9,60 @@ import (
 )
 
 var bucketIndexes = make(map[string]map[string]map[interface{}][]string)
+var elementIndexes = make(map[string]map[string]map[interface{}][]string)
 var indexMutex sync.RWMutex
 
-func UpdateIndex(bucketName, key string, entity interface{}) {
-	indexMutex.Lock()
-	defer indexMutex.Unlock()
+var (
+	epochMutex     sync.Mutex
+	bucketEpochs   = make(map[string]uint64)
+	pendingChanges = make(map[string]int)
+)
 
-	if _, exists := bucketIndexes[bucketName]; !exists {
-		bucketIndexes[bucketName] = make(map[string]map[interface{}][]string)
+func Epoch(bucketName string) (uint64, bool) {
+	epochMutex.Lock()
+	defer epochMutex.Unlock()
+	return bucketEpochs[bucketName], pendingChanges[bucketName] == 0
+}
+
+func BeginChange(bucketName string) {
+	epochMutex.Lock()
+	defer epochMutex.Unlock()
+	bucketEpochs[bucketName]++
+	pendingChanges[bucketName]++
+}
+
+func EndChange(bucketName string) {
+	epochMutex.Lock()
+	defer epochMutex.Unlock()
+	bucketEpochs[bucketName]++
+	if pendingChanges[bucketName] > 1 {
+		pendingChanges[bucketName]--
+	} else {
+		delete(pendingChanges, bucketName)
+	}
+}
+
+func bumpEpoch(bucketName string) {
+	epochMutex.Lock()
+	defer epochMutex.Unlock()
+	bucketEpochs[bucketName]++
+}
+
+type fieldEntry struct {
+	name  string
+	value interface{}
+}
+
+func entityFields(entity interface{}) []fieldEntry {
+	if accessor, ok := entity.(reflection.FieldAccessor); ok {
+		names := accessor.OdinFieldNames()
+		entries := make([]fieldEntry, 0, len(names))
+		for _, name := range names {
+			if value, found := accessor.OdinField(name); found {
+				entries = append(entries, fieldEntry{name: name, value: value})
+			}
+		}
+		return appendFlattened(entries, entity)
 	}
 
 	entityValue := reflect.ValueOf(entity)
@@ -26,8 +72,12 @@ func UpdateIndex(bucketName, key string, entity interface{}) {
 	entityType := entityValue.Type()
 	matcher := reflection.GetFieldMatcher(entityType)
 
+	entries := make([]fieldEntry, 0, entityType.NumField())
 	for i := 0; i < entityType.NumField(); i++ {
 		field := entityType.Field(i)
+		if embedded, ok := reflection.Flattenable(field); ok && embedded.Name() != "Bucket" {
+			continue
+		}
 		fieldName := field.Name
 
 		jsonTag := field.Tag.Get("json")
@@ -40,83 +90,181 @@ func UpdateIndex(bucketName, key string, entity interface{}) {
 			}
 		}
 
-		if _, exists := bucketIndexes[bucketName][fieldName]; !exists {
-			bucketIndexes[bucketName][fieldName] = make(map[interface{}][]string)
+		if fieldValue, found := matcher.GetFieldValue(entityValue, fieldName); found {
+			entries = append(entries, fieldEntry{name: fieldName, value: fieldValue})
 		}
+	}
+	return appendFlattened(entries, entity)
+}
 
-		if fieldValue, found := matcher.GetFieldValue(entityValue, fieldName); found {
-			if !isHashable(fieldValue) {
-				continue
-			}
+func appendFlattened(entries []fieldEntry, entity interface{}) []fieldEntry {
+	entityValue := reflect.ValueOf(entity)
+	if entityValue.Kind() == reflect.Ptr {
+		entityValue = entityValue.Elem()
+	}
+	if entityValue.Kind() != reflect.Struct {
+		return entries
+	}
 
-			fieldIndex := bucketIndexes[bucketName][fieldName]
-			keys := fieldIndex[fieldValue]
-			keyExists := false
-			for _, k := range keys {
-				if k == key {
-					keyExists = true
-					break
-				}
-			}
-			if !keyExists {
-				fieldIndex[fieldValue] = append(keys, key)
-			}
+	flattened := reflection.GetFieldMatcher(entityValue.Type()).Flattened
+	if len(flattened) == 0 {
+		return entries
+	}
+
+	present := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		present[entry.name] = true
+	}
+	for _, flat := range flattened {
+		if present[flat.Name] {
+			continue
+		}
+		if field, ok := reflection.FieldByPath(entityValue, flat.Index); ok {
+			entries = append(entries, fieldEntry{name: flat.Name, value: field.Interface()})
 		}
 	}
+	return entries
 }
 
-func RemoveFromIndex(bucketName, key string, entity interface{}) {
+func UpdateIndex(bucketName, key string, entity interface{}) {
+	fields := entityFields(entity)
+	defer bumpEpoch(bucketName)
+
+	if store := diskStoreFor(bucketName); store != nil {
+		store.update(key, fields)
+		return
+	}
+
 	indexMutex.Lock()
 	defer indexMutex.Unlock()
 
 	if _, exists := bucketIndexes[bucketName]; !exists {
+		bucketIndexes[bucketName] = make(map[string]map[interface{}][]string)
+	}
+
+	for _, field := range fields {
+		if _, exists := bucketIndexes[bucketName][field.name]; !exists {
+			bucketIndexes[bucketName][field.name] = make(map[interface{}][]string)
+		}
+
+		if elements, ok := sliceElements(field.value); ok {
+			if _, exists := elementIndexes[bucketName]; !exists {
+				elementIndexes[bucketName] = make(map[string]map[interface{}][]string)
+			}
+			if _, exists := elementIndexes[bucketName][field.name]; !exists {
+				elementIndexes[bucketName][field.name] = make(map[interface{}][]string)
+			}
+			for _, element := range elements {
+				addKey(elementIndexes[bucketName][field.name], element, key)
+			}
+			continue
+		}
+
+		addKey(bucketIndexes[bucketName][field.name], field.value, key)
+	}
+}
+
+func addKey(fieldIndex map[interface{}][]string, value interface{}, key string) {
+	value, ok := indexValue(value)
+	if !ok {
 		return
 	}
 
-	entityValue := reflect.ValueOf(entity)
-	if entityValue.Kind() == reflect.Ptr {
-		entityValue = entityValue.Elem()
+	keys := fieldIndex[value]
+	for _, k := range keys {
+		if k == key {
+			return
+		}
 	}
-	entityType := entityValue.Type()
-	matcher := reflection.GetFieldMatcher(entityType)
+	fieldIndex[value] = append(keys, key)
+}
 
-	for i := 0; i < entityType.NumField(); i++ {
-		field := entityType.Field(i)
-		fieldName := field.Name
+func removeKey(fieldIndex map[interface{}][]string, value interface{}, key string) {
+	value, ok := indexValue(value)
+	if !ok {
+		return
+	}
 
-		jsonTag := field.Tag.Get("json")
-		if jsonTag != "" {
-			if comma := strings.Index(jsonTag, ","); comma != -1 {
-				jsonTag = jsonTag[:comma]
-			}
-			if jsonTag != "" && jsonTag != "-" {
-				fieldName = jsonTag
+	if keys, exists := fieldIndex[value]; exists {
+		for i, k := range keys {
+			if k == key {
+				fieldIndex[value] = append(keys[:i], keys[i+1:]...)
+				break
 			}
 		}
+		if len(fieldIndex[value]) == 0 {
+			delete(fieldIndex, value)
+		}
+	}
+}
 
-		if fieldIndex, exists := bucketIndexes[bucketName][fieldName]; exists {
-			if fieldValue, found := matcher.GetFieldValue(entityValue, fieldName); found {
-				if !isHashable(fieldValue) {
-					continue
-				}
+func RemoveFromIndex(bucketName, key string, entity interface{}) {
+	defer bumpEpoch(bucketName)
+
+	if store := diskStoreFor(bucketName); store != nil {
+		store.remove(key)
+		return
+	}
+
+	fields := entityFields(entity)
+
+	indexMutex.Lock()
+	defer indexMutex.Unlock()
 
-				if keys, exists := fieldIndex[fieldValue]; exists {
-					for i, k := range keys {
-						if k == key {
-							fieldIndex[fieldValue] = append(keys[:i], keys[i+1:]...)
-							break
-						}
-					}
-					if len(fieldIndex[fieldValue]) == 0 {
-						delete(fieldIndex, fieldValue)
-					}
+	if _, exists := bucketIndexes[bucketName]; !exists {
+		return
+	}
+
+	for _, field := range fields {
+		if elements, ok := sliceElements(field.value); ok {
+			if elementIndex, exists := elementIndexes[bucketName][field.name]; exists {
+				for _, element := range elements {
+					removeKey(elementIndex, element, key)
 				}
 			}
+			continue
 		}
+
+		fieldIndex, exists := bucketIndexes[bucketName][field.name]
+		if !exists {
+			continue
+		}
+
+		removeKey(fieldIndex, field.value, key)
 	}
 }
 
 func GetIndexedKeys(bucketName, field string, value interface{}) ([]string, bool) {
+	keys, found := lookupKeys(bucketName, field, value)
+	if !strings.HasPrefix(field, "$") {
+		recordLookup(bucketName, field, found)
+	}
+	return keys, found
+}
+
+func LookupKeys(bucketName, field string, value interface{}) ([]string, bool) {
+	return lookupKeys(bucketName, field, value)
+}
+
+func lookupKeys(bucketName, field string, value interface{}) ([]string, bool) {
+	if store := diskStoreFor(bucketName); store != nil {
+		return store.lookup(field, value)
+	}
+
+	if lookup, ok := value.(reflection.ElementLookup); ok {
+		return getElementKeys(bucketName, field, lookup.ElementValues())
+	}
+	if lookup, ok := value.(reflection.ValueSetLookup); ok {
+		return getValueSetKeys(bucketName, field, lookup.IndexValues())
+	}
+	if _, ok := value.(reflection.Operator); ok {
+		return nil, false
+	}
+	value, indexable := indexValue(value)
+	if !indexable {
+		return nil, false
+	}
+
 	indexMutex.RLock()
 	defer indexMutex.RUnlock()
 
@@ -140,33 +288,149 @@ func GetIndexedKeys(bucketName, field string, value interface{}) ([]string, bool
 	return keysCopy, true
 }
 
+func getValueSetKeys(bucketName, field string, values []interface{}) ([]string, bool) {
+	indexMutex.RLock()
+	defer indexMutex.RUnlock()
+
+	fieldIndex, exists := bucketIndexes[bucketName][field]
+	if !exists || len(values) == 0 {
+		return nil, false
+	}
+
+	seen := make(map[string]bool)
+	var result []string
+	for _, value := range values {
+		value, ok := indexValue(value)
+		if !ok {
+			return nil, false
+		}
+		keys, exists := fieldIndex[value]
+		if !exists {
+			return nil, false
+		}
+		for _, key := range keys {
+			if !seen[key] {
+				seen[key] = true
+				result = append(result, key)
+			}
+		}
+	}
+	return result, true
+}
+
+func getElementKeys(bucketName, field string, values []interface{}) ([]string, bool) {
+	indexMutex.RLock()
+	defer indexMutex.RUnlock()
+
+	elementIndex, exists := elementIndexes[bucketName][field]
+	if !exists {
+		return nil, false
+	}
+
+	seen := make(map[string]bool)
+	var result []string
+	found := false
+	for _, value := range values {
+		value, ok := indexValue(value)
+		if !ok {
+			return nil, false
+		}
+		keys, exists := elementIndex[value]
+		if !exists {
+			continue
+		}
+		found = true
+		for _, key := range keys {
+			if !seen[key] {
+				seen[key] = true
+				result = append(result, key)
+			}
+		}
+	}
+	return result, found
+}
+
+func sliceElements(value interface{}) ([]interface{}, bool) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+
+	elements := make([]interface{}, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		element := v.Index(i).Interface()
+		if _, ok := indexValue(element); !ok {
+			return nil, false
+		}
+		elements = append(elements, element)
+	}
+	return elements, true
+}
+
+func DropIndex(bucketName string) {
+	defer bumpEpoch(bucketName)
+
+	indexMutex.Lock()
+	defer indexMutex.Unlock()
+	delete(bucketIndexes, bucketName)
+	delete(elementIndexes, bucketName)
+}
+
 func HasIndex(bucketName string) bool {
+	if diskStoreFor(bucketName) != nil {
+		return true
+	}
+
 	indexMutex.RLock()
 	defer indexMutex.RUnlock()
 	_, exists := bucketIndexes[bucketName]
 	return exists
 }
 
+func FieldIndexed(bucketName, field string) bool {
+	if diskStoreFor(bucketName) != nil {
+		return true
+	}
+
+	indexMutex.RLock()
+	defer indexMutex.RUnlock()
+	_, values := bucketIndexes[bucketName][field]
+	_, elements := elementIndexes[bucketName][field]
+	return values || elements
+}
+
+func indexValue(value interface{}) (interface{}, bool) {
+	key, ok := reflection.IndexKey(value)
+	if !ok || !isHashable(key) {
+		return nil, false
+	}
+	return key, true
+}
+
 func isHashable(v interface{}) bool {
 	if v == nil {
 		return true
 	}
 
-	return isTypeHashable(reflect.TypeOf(v))
+	return isTypeHashable(reflect.TypeOf(v), map[reflect.Type]bool{})
 }
 
-func isTypeHashable(t reflect.Type) bool {
+func isTypeHashable(t reflect.Type, seen map[reflect.Type]bool) bool {
 	switch t.Kind() {
 	case reflect.Slice, reflect.Map, reflect.Func:
 		return false
 	case reflect.Ptr:
-		return isTypeHashable(t.Elem())
+		return isTypeHashable(t.Elem(), seen)
 	case reflect.Array:
-		return isTypeHashable(t.Elem())
+		return isTypeHashable(t.Elem(), seen)
 	case reflect.Struct:
+		if seen[t] {
+			return true
+		}
+		seen[t] = true
 		for i := 0; i < t.NumField(); i++ {
 			field := t.Field(i)
-			if !isTypeHashable(field.Type) {
+			if !isTypeHashable(field.Type, seen) {
 				return false
 			}
 		}
@@ -0,0 +1,58 @@
+package indexing
+
+import "unsafe"
+
+const (
+	stringHeader = int64(unsafe.Sizeof(""))
+	mapEntry     = 48
+)
+
+func MemoryBytes() int64 {
+	indexMutex.RLock()
+	defer indexMutex.RUnlock()
+
+	var total int64
+	for _, indexes := range []map[string]map[string]map[interface{}][]string{bucketIndexes, elementIndexes} {
+		for _, fields := range indexes {
+			for field, values := range fields {
+				total += int64(len(field)) + stringHeader + mapEntry
+				for _, keys := range values {
+					total += mapEntry + int64(cap(keys))*stringHeader
+					for _, key := range keys {
+						total += int64(len(key))
+					}
+				}
+			}
+		}
+	}
+	return total
+}
+
+func CacheBytes() int64 {
+	diskMutex.RLock()
+	stores := make([]*diskStore, 0, len(diskStores))
+	for _, store := range diskStores {
+		stores = append(stores, store)
+	}
+	diskMutex.RUnlock()
+
+	var total int64
+	for _, store := range stores {
+		total += store.cache.bytes()
+	}
+	return total
+}
+
+func (c *postingCache) bytes() int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var total int64
+	for prefix, element := range c.entries {
+		total += int64(len(prefix)) + stringHeader + mapEntry
+		for _, key := range element.Value.(*cacheEntry).keys {
+			total += int64(len(key)) + stringHeader
+		}
+	}
+	return total
+}
@@ -0,0 +1,101 @@
+package indexing
+
+import "sync"
+
+type FieldStats struct {
+	Hits               uint64
+	Misses             uint64
+	Intersections      uint64
+	IntersectionInput  uint64
+	IntersectionOutput uint64
+}
+
+type Stats struct {
+	Bucket    string
+	FullScans uint64
+	Fields    map[string]FieldStats
+}
+
+var (
+	statsMutex  sync.Mutex
+	bucketStats = make(map[string]*Stats)
+)
+
+func statsFor(bucketName string) *Stats {
+	stats, exists := bucketStats[bucketName]
+	if !exists {
+		stats = &Stats{Bucket: bucketName, Fields: make(map[string]FieldStats)}
+		bucketStats[bucketName] = stats
+	}
+	return stats
+}
+
+func recordLookup(bucketName, field string, hit bool) {
+	statsMutex.Lock()
+	defer statsMutex.Unlock()
+
+	stats := statsFor(bucketName)
+	fieldStats := stats.Fields[field]
+	if hit {
+		fieldStats.Hits++
+	} else {
+		fieldStats.Misses++
+	}
+	stats.Fields[field] = fieldStats
+}
+
+func RecordFullScan(bucketName string) {
+	statsMutex.Lock()
+	defer statsMutex.Unlock()
+
+	statsFor(bucketName).FullScans++
+}
+
+func RecordIntersection(bucketName, field string, input, output int) {
+	statsMutex.Lock()
+	defer statsMutex.Unlock()
+
+	stats := statsFor(bucketName)
+	fieldStats := stats.Fields[field]
+	fieldStats.Intersections++
+	fieldStats.IntersectionInput += uint64(input)
+	fieldStats.IntersectionOutput += uint64(output)
+	stats.Fields[field] = fieldStats
+}
+
+func BucketIndexStats(bucketName string) Stats {
+	statsMutex.Lock()
+	defer statsMutex.Unlock()
+
+	stats, exists := bucketStats[bucketName]
+	if !exists {
+		return Stats{Bucket: bucketName, Fields: make(map[string]FieldStats)}
+	}
+	return stats.copy()
+}
+
+func AllIndexStats() map[string]Stats {
+	statsMutex.Lock()
+	defer statsMutex.Unlock()
+
+	result := make(map[string]Stats, len(bucketStats))
+	for name, stats := range bucketStats {
+		result[name] = stats.copy()
+	}
+	return result
+}
+
+func ResetIndexStats() {
+	statsMutex.Lock()
+	defer statsMutex.Unlock()
+
+	bucketStats = make(map[string]*Stats)
+}
+
+func (s *Stats) copy() Stats {
+	c := Stats{Bucket: s.Bucket, FullScans: s.FullScans, Fields: make(map[string]FieldStats, len(s.Fields))}
+	for field, fieldStats := range s.Fields {
+		c.Fields[field] = fieldStats
+	}
+	return c
+}
@@ -0,0 +1,156 @@
+package keys
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"time"
+)
+
+const TimeSize = 8
+
+var (
+	minTime = time.Unix(0, math.MinInt64)
+	maxTime = time.Unix(0, math.MaxInt64)
+)
+
+func EncodeUint64(v uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, v)
+	return buf
+}
+
+func DecodeUint64(b []byte) uint64 {
+	if len(b) < 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+func EncodeInt64(v int64) []byte {
+	return EncodeUint64(uint64(v) ^ (1 << 63))
+}
+
+func DecodeInt64(b []byte) int64 {
+	return int64(DecodeUint64(b) ^ (1 << 63))
+}
+
+func EncodeFloat64(v float64) []byte {
+	bits := math.Float64bits(v)
+	if bits&(1<<63) != 0 {
+		bits = ^bits
+	} else {
+		bits |= 1 << 63
+	}
+	return EncodeUint64(bits)
+}
+
+func DecodeFloat64(b []byte) float64 {
+	bits := DecodeUint64(b)
+	if bits&(1<<63) != 0 {
+		bits &^= 1 << 63
+	} else {
+		bits = ^bits
+	}
+	return math.Float64frombits(bits)
+}
+
+// ValidTime reports whether t is set and fits in a time key. UnixNano
+// overflows silently outside the years 1678 to 2262.
+func ValidTime(t time.Time) bool {
+	return !t.IsZero() && !t.Before(minTime) && !t.After(maxTime)
+}
+
+func EncodeTime(t time.Time) []byte {
+	return EncodeInt64(t.UnixNano())
+}
+
+func DecodeTime(b []byte) time.Time {
+	return time.Unix(0, DecodeInt64(b))
+}
+
+func TimeKey(t time.Time, key []byte) []byte {
+	buf := make([]byte, TimeSize+len(key))
+	copy(buf, EncodeTime(t))
+	copy(buf[TimeSize:], key)
+	return buf
+}
+
+func SplitTimeKey(b []byte) (time.Time, []byte) {
+	if len(b) < TimeSize {
+		return time.Time{}, nil
+	}
+	return DecodeTime(b[:TimeSize]), b[TimeSize:]
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func Encode(v interface{}) (string, bool) {
+	return EncodeValue(reflect.ValueOf(v))
+}
+
+func EncodeValue(v reflect.Value) (string, bool) {
+	if !v.IsValid() {
+		return "", false
+	}
+	if v.Type() == timeType {
+		return string(EncodeTime(v.Interface().(time.Time))), true
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes()), true
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return string(EncodeUint64(v.Uint())), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return string(EncodeInt64(v.Int())), true
+	}
+	return "", false
+}
+
+func DecodeValue(target reflect.Value, encoded string) bool {
+	if target.Type() == timeType {
+		if len(encoded) != TimeSize {
+			return false
+		}
+		target.Set(reflect.ValueOf(DecodeTime([]byte(encoded))))
+		return true
+	}
+
+	switch target.Kind() {
+	case reflect.String:
+		target.SetString(encoded)
+		return true
+	case reflect.Slice:
+		if target.Type().Elem().Kind() != reflect.Uint8 {
+			return false
+		}
+		target.SetBytes([]byte(encoded))
+		return true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if len(encoded) != 8 {
+			return false
+		}
+		value := DecodeUint64([]byte(encoded))
+		if target.OverflowUint(value) {
+			return false
+		}
+		target.SetUint(value)
+		return true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if len(encoded) != 8 {
+			return false
+		}
+		value := DecodeInt64([]byte(encoded))
+		if target.OverflowInt(value) {
+			return false
+		}
+		target.SetInt(value)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package pool
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+const DefaultMaxItemBytes = 256 * 1024
+
+var (
+	maxItemBytes atomic.Int64
+
+	registryMutex sync.Mutex
+	registry      []stater
+)
+
+func init() {
+	maxItemBytes.Store(DefaultMaxItemBytes)
+}
+
+type Stats struct {
+	Name    string
+	Gets    uint64
+	Puts    uint64
+	Misses  uint64
+	Dropped uint64
+}
+
+type stater interface {
+	stats() Stats
+}
+
+type Pool[T any] struct {
+	name    string
+	pool    sync.Pool
+	newFn   func() T
+	size    func(T) int
+	gets    atomic.Uint64
+	puts    atomic.Uint64
+	misses  atomic.Uint64
+	dropped atomic.Uint64
+}
+
+func New[T any](name string, newFn func() T, size func(T) int) *Pool[T] {
+	p := &Pool[T]{name: name, newFn: newFn, size: size}
+
+	registryMutex.Lock()
+	registry = append(registry, p)
+	registryMutex.Unlock()
+	return p
+}
+
+func (p *Pool[T]) Get() T {
+	p.gets.Add(1)
+	if pooled, ok := p.pool.Get().(T); ok {
+		return pooled
+	}
+	p.misses.Add(1)
+	return p.newFn()
+}
+
+func (p *Pool[T]) Put(value T) {
+	if limit := maxItemBytes.Load(); limit > 0 && int64(p.sizeOf(value)) > limit {
+		p.dropped.Add(1)
+		return
+	}
+
+	p.puts.Add(1)
+	p.pool.Put(value)
+}
+
+func (p *Pool[T]) sizeOf(value T) int {
+	if p.size == nil {
+		return 0
+	}
+	return p.size(value)
+}
+
+func (p *Pool[T]) stats() Stats {
+	return Stats{
+		Name:    p.name,
+		Gets:    p.gets.Load(),
+		Puts:    p.puts.Load(),
+		Misses:  p.misses.Load(),
+		Dropped: p.dropped.Load(),
+	}
+}
+
+func SetMaxItemBytes(bytes int) {
+	maxItemBytes.Store(int64(bytes))
+}
+
+func MaxItemBytes() int {
+	return int(maxItemBytes.Load())
+}
+
+func All() []Stats {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	result := make([]Stats, 0, len(registry))
+	for _, p := range registry {
+		result = append(result, p.stats())
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
@@ -0,0 +1,10 @@
+package reflection
+
+type FieldAccessor interface {
+	OdinFieldNames() []string
+	OdinField(name string) (interface{}, bool)
+}
+
+type KeyProvider interface {
+	OdinKey() string
+}
@@ -0,0 +1,75 @@
+package reflection
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+type Comparator struct {
+	Equal   func(a, b interface{}) bool
+	Compare func(a, b interface{}) (int, bool)
+	Key     func(value interface{}) interface{}
+}
+
+var (
+	comparators    = sync.Map{}
+	hasComparators atomic.Bool
+)
+
+func RegisterComparator(sample interface{}, comparator Comparator) error {
+	if sample == nil {
+		return fmt.Errorf("comparator sample must not be nil")
+	}
+	if comparator.Equal == nil && comparator.Compare == nil && comparator.Key == nil {
+		return fmt.Errorf("comparator for %T defines no functions", sample)
+	}
+
+	comparators.Store(reflect.TypeOf(sample), comparator)
+	hasComparators.Store(true)
+	return nil
+}
+
+func UnregisterComparator(sample interface{}) {
+	if sample != nil {
+		comparators.Delete(reflect.TypeOf(sample))
+	}
+}
+
+func comparatorFor(a, b interface{}) (Comparator, bool) {
+	if !hasComparators.Load() {
+		return Comparator{}, false
+	}
+	for _, value := range []interface{}{a, b} {
+		if value == nil {
+			continue
+		}
+		if found, ok := comparators.Load(reflect.TypeOf(value)); ok {
+			return found.(Comparator), true
+		}
+	}
+	return Comparator{}, false
+}
+
+func (c Comparator) equal(a, b interface{}) bool {
+	switch {
+	case c.Equal != nil:
+		return c.Equal(a, b)
+	case c.Compare != nil:
+		cmp, ok := c.Compare(a, b)
+		return ok && cmp == 0
+	}
+	return reflect.DeepEqual(c.Key(a), c.Key(b))
+}
+
+func IndexKey(value interface{}) (interface{}, bool) {
+	comparator, ok := comparatorFor(value, nil)
+	if !ok {
+		return value, true
+	}
+	if comparator.Key == nil {
+		return nil, false
+	}
+	return comparator.Key(value), true
+}
@@ -0,0 +1,184 @@
+package reflection
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+func ApplyDefaults(entity interface{}) error {
+	val := reflect.ValueOf(entity)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return nil
+	}
+
+	val = val.Elem()
+	if val.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return applyStructDefaults(val, nil)
+}
+
+func ApplyFieldDefaults(entity interface{}, fields []string) error {
+	val := reflect.ValueOf(entity)
+	if val.Kind() != reflect.Ptr || val.IsNil() || len(fields) == 0 {
+		return nil
+	}
+
+	val = val.Elem()
+	if val.Kind() != reflect.Struct {
+		return nil
+	}
+
+	only := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		only[field] = true
+	}
+	return applyStructDefaults(val, only)
+}
+
+func applyStructDefaults(val reflect.Value, only map[string]bool) error {
+	typ := val.Type()
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldValue := val.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := applyStructDefaults(fieldValue, only); err != nil {
+				return err
+			}
+			continue
+		}
+
+		tag, ok := field.Tag.Lookup("default")
+		if !ok || !fieldValue.IsZero() {
+			continue
+		}
+		if only != nil && !only[serializedName(field)] {
+			continue
+		}
+
+		if err := setDefault(fieldValue, tag); err != nil {
+			return fmt.Errorf("invalid default %q for field %s: %w", tag, field.Name, err)
+		}
+	}
+	return nil
+}
+
+func setDefault(field reflect.Value, tag string) error {
+	if field.Kind() == reflect.Ptr {
+		elem := reflect.New(field.Type().Elem())
+		if err := setDefault(elem.Elem(), tag); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+
+	switch field.Type() {
+	case timeType:
+		if tag == "now" {
+			field.Set(reflect.ValueOf(time.Now()))
+			return nil
+		}
+		t, err := time.Parse(time.RFC3339, tag)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	case durationType:
+		d, err := time.ParseDuration(tag)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(tag)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(tag)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(tag, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(tag, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(tag, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+func SerializedFields(typ reflect.Type) []string {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var names []string
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct {
+			names = append(names, SerializedFields(field.Type)...)
+			continue
+		}
+
+		_, options, _ := strings.Cut(tag, ",")
+		if strings.Contains(options, "omitempty") {
+			continue
+		}
+		names = append(names, serializedName(field))
+	}
+	return names
+}
+
+func serializedName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
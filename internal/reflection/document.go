@@ -0,0 +1,67 @@
+package reflection
+
+import (
+	"reflect"
+	"strings"
+)
+
+func GetDocumentValue(doc map[string]interface{}, key string) (interface{}, bool) {
+	if value, exists := doc[key]; exists {
+		return value, true
+	}
+
+	if !strings.Contains(key, ".") {
+		return nil, false
+	}
+
+	var current interface{} = doc
+	for _, part := range strings.Split(key, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func MatchesDocument(doc map[string]interface{}, criteria map[string]interface{}) bool {
+	return matchFilter(func(key string) (interface{}, bool) {
+		return GetDocumentValue(doc, key)
+	}, criteria)
+}
+
+func ValuesEqual(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return isNil(a) && isNil(b)
+	}
+	if comparator, ok := comparatorFor(a, b); ok {
+		return comparator.equal(a, b)
+	}
+
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	if af, ok := toFloat(av); ok {
+		if bf, ok := toFloat(bv); ok {
+			return af == bf
+		}
+	}
+
+	if av.Type().Comparable() && av.Type() == bv.Type() {
+		return a == b
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
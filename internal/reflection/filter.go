@@ -0,0 +1,299 @@
+package reflection
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+type fieldGetter func(key string) (interface{}, bool)
+
+func matchFilter(get fieldGetter, criteria map[string]interface{}) bool {
+	for key, expectedValue := range criteria {
+		if strings.HasPrefix(key, "$") {
+			if !matchLogical(get, key, expectedValue) {
+				return false
+			}
+			continue
+		}
+
+		if doc, ok := operatorDocument(expectedValue); ok {
+			op, err := compileOperatorDocument(doc)
+			if err != nil {
+				return false
+			}
+			expectedValue = op
+		}
+
+		fieldValue, found := get(key)
+		if !found {
+			if matchesMissing(expectedValue) {
+				continue
+			}
+			return false
+		}
+
+		if !MatchesValue(fieldValue, expectedValue) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchLogical(get fieldGetter, key string, value interface{}) bool {
+	clauses, err := logicalClauses(key, value)
+	if err != nil {
+		return false
+	}
+
+	switch key {
+	case "$and":
+		for _, clause := range clauses {
+			if !matchFilter(get, clause) {
+				return false
+			}
+		}
+		return true
+	case "$or":
+		for _, clause := range clauses {
+			if matchFilter(get, clause) {
+				return true
+			}
+		}
+		return false
+	case "$nor":
+		for _, clause := range clauses {
+			if matchFilter(get, clause) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func logicalClauses(key string, value interface{}) ([]map[string]interface{}, error) {
+	if key != "$and" && key != "$or" && key != "$nor" {
+		return nil, fmt.Errorf("unknown filter operator %s", key)
+	}
+
+	var clauses []map[string]interface{}
+	switch v := value.(type) {
+	case []map[string]interface{}:
+		clauses = v
+	case []interface{}:
+		clauses = make([]map[string]interface{}, 0, len(v))
+		for _, item := range v {
+			clause, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s expects an array of filter documents", key)
+			}
+			clauses = append(clauses, clause)
+		}
+	default:
+		return nil, fmt.Errorf("%s expects an array of filter documents", key)
+	}
+
+	if len(clauses) == 0 {
+		return nil, fmt.Errorf("%s expects a non-empty array", key)
+	}
+	return clauses, nil
+}
+
+func CompileFilter(criteria map[string]interface{}) (map[string]interface{}, error) {
+	if criteria == nil {
+		return nil, nil
+	}
+
+	compiled := make(map[string]interface{}, len(criteria))
+	for key, value := range criteria {
+		if strings.HasPrefix(key, "$") {
+			clauses, err := logicalClauses(key, value)
+			if err != nil {
+				return nil, err
+			}
+
+			compiledClauses := make([]map[string]interface{}, len(clauses))
+			for i, clause := range clauses {
+				if compiledClauses[i], err = CompileFilter(clause); err != nil {
+					return nil, err
+				}
+			}
+			compiled[key] = compiledClauses
+			continue
+		}
+
+		if doc, ok := operatorDocument(value); ok {
+			op, err := compileOperatorDocument(doc)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", key, err)
+			}
+			compiled[key] = op
+			continue
+		}
+		compiled[key] = value
+	}
+	return compiled, nil
+}
+
+func operatorDocument(value interface{}) (map[string]interface{}, bool) {
+	doc, ok := value.(map[string]interface{})
+	if !ok || len(doc) == 0 {
+		return nil, false
+	}
+	for key := range doc {
+		if !strings.HasPrefix(key, "$") {
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+func compileOperatorDocument(doc map[string]interface{}) (Operator, error) {
+	ops := make([]Operator, 0, len(doc))
+
+	for key, value := range doc {
+		var op Operator
+		var err error
+
+		switch key {
+		case "$eq":
+			op = equalOperator{value: value}
+		case "$ne":
+			op = Ne(value)
+		case "$gt":
+			op = Gt(value)
+		case "$gte":
+			op = Gte(value)
+		case "$lt":
+			op = Lt(value)
+		case "$lte":
+			op = Lte(value)
+		case "$in", "$nin", "$all":
+			values, ok := toSlice(value)
+			if !ok {
+				return nil, fmt.Errorf("%s expects an array", key)
+			}
+			switch key {
+			case "$in":
+				op = In(values...)
+			case "$nin":
+				op = NotIn(values...)
+			default:
+				op = allElementsOperator{values: values}
+			}
+		case "$exists":
+			exists, ok := value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("$exists expects a boolean")
+			}
+			if exists {
+				op = Exists()
+			} else {
+				op = Missing()
+			}
+		case "$regex":
+			op, err = compileRegex(value, doc["$options"])
+		case "$options":
+			if _, ok := doc["$regex"]; !ok {
+				return nil, fmt.Errorf("$options requires $regex")
+			}
+			continue
+		case "$size":
+			size, ok := toFloat(reflect.ValueOf(value))
+			if !ok {
+				return nil, fmt.Errorf("$size expects a number")
+			}
+			op = sizeOperator{size: int(size)}
+		case "$not":
+			var inner Operator
+			if re, ok := value.(*regexp.Regexp); ok {
+				inner = Match(re)
+			} else if sub, ok := operatorDocument(value); ok {
+				inner, err = compileOperatorDocument(sub)
+			} else {
+				err = fmt.Errorf("$not expects an operator document or regexp")
+			}
+			op = notOperator{op: inner}
+		default:
+			return nil, fmt.Errorf("unknown filter operator %s", key)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+
+	if len(ops) == 1 {
+		return ops[0], nil
+	}
+	return allOperator{ops: ops}, nil
+}
+
+func compileRegex(pattern, options interface{}) (Operator, error) {
+	if re, ok := pattern.(*regexp.Regexp); ok {
+		return Match(re), nil
+	}
+
+	expr, ok := pattern.(string)
+	if !ok {
+		return nil, fmt.Errorf("$regex expects a string")
+	}
+
+	if options != nil {
+		flags, ok := options.(string)
+		if !ok {
+			return nil, fmt.Errorf("$options expects a string")
+		}
+		for _, flag := range flags {
+			if !strings.ContainsRune("ims", flag) {
+				return nil, fmt.Errorf("unsupported $options flag %q", flag)
+			}
+		}
+		if flags != "" {
+			expr = "(?" + flags + ")" + expr
+		}
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("$regex: %w", err)
+	}
+	return Match(re), nil
+}
+
+func toSlice(value interface{}) ([]interface{}, bool) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, false
+	}
+
+	values := make([]interface{}, v.Len())
+	for i := range values {
+		values[i] = v.Index(i).Interface()
+	}
+	return values, true
+}
+
+func And(criteria ...map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"$and": criteria}
+}
+
+func Or(criteria ...map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"$or": criteria}
+}
+
+func Not(criteria map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"$nor": []map[string]interface{}{criteria}}
+}
+
+func HasLogical(criteria map[string]interface{}) bool {
+	for key := range criteria {
+		if strings.HasPrefix(key, "$") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package reflection
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/keys"
+)
+
+type keyField struct {
+	index []int
+	err   error
+}
+
+var (
+	keyFieldCache    = sync.Map{}
+	keyFieldRegistry = sync.Map{}
+)
+
+func IsKeyTag(tag reflect.StructTag) bool {
+	for _, option := range strings.Split(tag.Get("odin"), ",") {
+		if strings.TrimSpace(option) == "key" {
+			return true
+		}
+	}
+	return false
+}
+
+func RegisterKeyField(model interface{}, fieldName string) error {
+	modelType := structType(reflect.TypeOf(model))
+	if modelType == nil {
+		return fmt.Errorf("key field model must be a struct, got %T", model)
+	}
+	field, found := modelType.FieldByName(fieldName)
+	if !found {
+		return fmt.Errorf("%s has no field %q", modelType, fieldName)
+	}
+	if _, encodable := keys.EncodeValue(reflect.New(indirectType(field.Type)).Elem()); !encodable {
+		return fmt.Errorf("field %s.%s of type %s cannot be a key", modelType, fieldName, field.Type)
+	}
+
+	keyFieldRegistry.Store(modelType, field.Index)
+	keyFieldCache.Delete(modelType)
+	return nil
+}
+
+func lookupKeyField(modelType reflect.Type) keyField {
+	if cached, ok := keyFieldCache.Load(modelType); ok {
+		return cached.(keyField)
+	}
+
+	var found keyField
+	if index, registered := keyFieldRegistry.Load(modelType); registered {
+		found.index = index.([]int)
+	} else {
+		for i := 0; i < modelType.NumField(); i++ {
+			field := modelType.Field(i)
+			if !IsKeyTag(field.Tag) {
+				continue
+			}
+			if found.index != nil {
+				found = keyField{err: fmt.Errorf("%w: %s", errors.ErrAmbiguousKey, modelType)}
+				break
+			}
+			found.index = field.Index
+		}
+	}
+
+	keyFieldCache.Store(modelType, found)
+	return found
+}
+
+func RecordKey(entity interface{}) (string, bool, error) {
+	val := reflect.ValueOf(entity)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return "", false, nil
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return "", false, nil
+	}
+
+	field := lookupKeyField(val.Type())
+	if field.err != nil {
+		return "", true, field.err
+	}
+	if field.index == nil {
+		return "", false, nil
+	}
+
+	value := val.FieldByIndex(field.index)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return "", true, fmt.Errorf("%w: key field of %s is nil", errors.ErrMissingKey, val.Type())
+		}
+		value = value.Elem()
+	}
+	if value.IsZero() {
+		return "", true, fmt.Errorf("%w: key field of %s is empty", errors.ErrMissingKey, val.Type())
+	}
+
+	encoded, ok := keys.EncodeValue(value)
+	if !ok {
+		return "", true, fmt.Errorf("key field of %s has unsupported type %s", val.Type(), value.Type())
+	}
+	return encoded, true, nil
+}
+
+func structType(t reflect.Type) reflect.Type {
+	t = indirectType(t)
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,118 @@
+package reflection
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+type NamingStrategy func(typeName string) string
+
+var (
+	namingMutex      sync.RWMutex
+	namingStrategies = make(map[string]NamingStrategy)
+	defaultDatabase  string
+)
+
+type bucketNameKey struct {
+	typ reflect.Type
+	db  string
+}
+
+func SetNamingStrategy(dbName string, strategy NamingStrategy) {
+	namingMutex.Lock()
+	if strategy == nil {
+		delete(namingStrategies, dbName)
+	} else {
+		namingStrategies[dbName] = strategy
+	}
+	namingMutex.Unlock()
+
+	bucketNameCache.Range(func(key, _ interface{}) bool {
+		bucketNameCache.Delete(key)
+		return true
+	})
+}
+
+func SetDefaultDatabase(dbName string) {
+	namingMutex.Lock()
+	defer namingMutex.Unlock()
+	defaultDatabase = dbName
+}
+
+func resolveDatabase(dbName string) string {
+	if dbName != "" {
+		return dbName
+	}
+	namingMutex.RLock()
+	defer namingMutex.RUnlock()
+	return defaultDatabase
+}
+
+func namingStrategyFor(dbName string) NamingStrategy {
+	namingMutex.RLock()
+	defer namingMutex.RUnlock()
+
+	if strategy, exists := namingStrategies[dbName]; exists {
+		return strategy
+	}
+	if strategy, exists := namingStrategies[""]; exists {
+		return strategy
+	}
+	return StripEntitySuffix
+}
+
+func StripEntitySuffix(typeName string) string {
+	if len(typeName) > 6 && strings.HasSuffix(typeName, "Entity") {
+		return typeName[:len(typeName)-6]
+	}
+	return typeName
+}
+
+func SnakeCase(typeName string) string {
+	runes := []rune(typeName)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func Pluralize(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case name == "":
+		return name
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return name + "es"
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return name[:len(name)-1] + "ies"
+	}
+	return name + "s"
+}
+
+func PrefixNames(prefix string) NamingStrategy {
+	return func(name string) string {
+		return prefix + name
+	}
+}
+
+func ChainNaming(strategies ...NamingStrategy) NamingStrategy {
+	return func(name string) string {
+		for _, strategy := range strategies {
+			name = strategy(name)
+		}
+		return name
+	}
+}
+
+var SnakePlural NamingStrategy = ChainNaming(StripEntitySuffix, SnakeCase, Pluralize)
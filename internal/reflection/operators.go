@@ -0,0 +1,470 @@
+package reflection
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type Operator interface {
+	Matches(fieldValue interface{}) bool
+}
+
+type ElementLookup interface {
+	ElementValues() []interface{}
+}
+
+type ValueSetLookup interface {
+	IndexValues() []interface{}
+}
+
+type TimeRangeLookup interface {
+	TimeRange() (from, to time.Time)
+}
+
+func MatchesValue(fieldValue, expectedValue interface{}) bool {
+	if op, ok := expectedValue.(Operator); ok {
+		return op.Matches(fieldValue)
+	}
+	return ValuesEqual(fieldValue, expectedValue)
+}
+
+type containsOperator struct {
+	value interface{}
+}
+
+func Contains(value interface{}) Operator {
+	return containsOperator{value: value}
+}
+
+func (op containsOperator) Matches(fieldValue interface{}) bool {
+	return anyElement(fieldValue, func(element interface{}) bool {
+		return ValuesEqual(element, op.value)
+	})
+}
+
+func (op containsOperator) ElementValues() []interface{} {
+	return []interface{}{op.value}
+}
+
+type overlapsOperator struct {
+	values []interface{}
+}
+
+func Overlaps(values ...interface{}) Operator {
+	return overlapsOperator{values: values}
+}
+
+func (op overlapsOperator) Matches(fieldValue interface{}) bool {
+	return anyElement(fieldValue, func(element interface{}) bool {
+		for _, value := range op.values {
+			if ValuesEqual(element, value) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (op overlapsOperator) ElementValues() []interface{} {
+	return op.values
+}
+
+func anyElement(fieldValue interface{}, fn func(element interface{}) bool) bool {
+	v := reflect.ValueOf(fieldValue)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return false
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		if fn(v.Index(i).Interface()) {
+			return true
+		}
+	}
+	return false
+}
+
+type regexpOperator struct {
+	re *regexp.Regexp
+}
+
+func Like(pattern string) Operator {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	return regexpOperator{re: regexp.MustCompile(expr.String())}
+}
+
+func Match(re *regexp.Regexp) Operator {
+	return regexpOperator{re: re}
+}
+
+func (op regexpOperator) Matches(fieldValue interface{}) bool {
+	if op.re == nil {
+		return false
+	}
+
+	switch v := fieldValue.(type) {
+	case string:
+		return op.re.MatchString(v)
+	case *string:
+		return v != nil && op.re.MatchString(*v)
+	}
+
+	v := reflect.ValueOf(fieldValue)
+	if v.Kind() == reflect.String {
+		return op.re.MatchString(v.String())
+	}
+	return false
+}
+
+type equalFoldOperator struct {
+	value string
+}
+
+func EqualFold(value string) Operator {
+	return equalFoldOperator{value: value}
+}
+
+func (op equalFoldOperator) Matches(fieldValue interface{}) bool {
+	switch v := fieldValue.(type) {
+	case string:
+		return strings.EqualFold(v, op.value)
+	case *string:
+		return v != nil && strings.EqualFold(*v, op.value)
+	}
+
+	v := reflect.ValueOf(fieldValue)
+	return v.Kind() == reflect.String && strings.EqualFold(v.String(), op.value)
+}
+
+func CaseInsensitive(criteria map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(criteria))
+	for key, value := range criteria {
+		if s, ok := value.(string); ok {
+			result[key] = EqualFold(s)
+			continue
+		}
+		result[key] = value
+	}
+	return result
+}
+
+type timeRangeOperator struct {
+	from, to time.Time
+}
+
+func Between(from, to time.Time) Operator {
+	return timeRangeOperator{from: from, to: to}
+}
+
+func Since(from time.Time) Operator {
+	return timeRangeOperator{from: from}
+}
+
+func Until(to time.Time) Operator {
+	return timeRangeOperator{to: to}
+}
+
+func (op timeRangeOperator) TimeRange() (time.Time, time.Time) {
+	return op.from, op.to
+}
+
+func (op timeRangeOperator) Matches(fieldValue interface{}) bool {
+	t, ok := toTime(fieldValue)
+	if !ok {
+		return false
+	}
+	if !op.from.IsZero() && t.Before(op.from) {
+		return false
+	}
+	if !op.to.IsZero() && !t.Before(op.to) {
+		return false
+	}
+	return true
+}
+
+func toTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, !v.IsZero()
+	case *time.Time:
+		if v == nil {
+			return time.Time{}, false
+		}
+		return *v, !v.IsZero()
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		return t, err == nil && !t.IsZero()
+	}
+	return time.Time{}, false
+}
+
+type absenceMatcher interface {
+	matchesAbsent() bool
+}
+
+func matchesMissing(expectedValue interface{}) bool {
+	m, ok := expectedValue.(absenceMatcher)
+	return ok && m.matchesAbsent()
+}
+
+type nullOperator struct{}
+
+func IsNull() Operator {
+	return nullOperator{}
+}
+
+func (nullOperator) Matches(fieldValue interface{}) bool {
+	return isNil(fieldValue)
+}
+
+type zeroOperator struct {
+	zero bool
+}
+
+func IsZero() Operator {
+	return zeroOperator{zero: true}
+}
+
+func NotZero() Operator {
+	return zeroOperator{zero: false}
+}
+
+func (op zeroOperator) Matches(fieldValue interface{}) bool {
+	return isZero(fieldValue) == op.zero
+}
+
+type presenceOperator struct {
+	present bool
+}
+
+func Exists() Operator {
+	return presenceOperator{present: true}
+}
+
+func Missing() Operator {
+	return presenceOperator{present: false}
+}
+
+func (op presenceOperator) Matches(interface{}) bool {
+	return op.present
+}
+
+func (op presenceOperator) matchesAbsent() bool {
+	return !op.present
+}
+
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	}
+	return false
+}
+
+func isZero(value interface{}) bool {
+	if isNil(value) {
+		return true
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	if t, ok := value.(time.Time); ok {
+		return t.IsZero()
+	}
+	return v.IsZero()
+}
+
+type equalOperator struct {
+	value interface{}
+}
+
+func (op equalOperator) Matches(fieldValue interface{}) bool {
+	return ValuesEqual(fieldValue, op.value)
+}
+
+type notOperator struct {
+	op Operator
+}
+
+func Ne(value interface{}) Operator {
+	return notOperator{op: equalOperator{value: value}}
+}
+
+func (op notOperator) Matches(fieldValue interface{}) bool {
+	return !op.op.Matches(fieldValue)
+}
+
+func (op notOperator) matchesAbsent() bool {
+	return !matchesMissing(op.op)
+}
+
+type allOperator struct {
+	ops []Operator
+}
+
+func (op allOperator) Matches(fieldValue interface{}) bool {
+	for _, inner := range op.ops {
+		if !inner.Matches(fieldValue) {
+			return false
+		}
+	}
+	return true
+}
+
+func (op allOperator) matchesAbsent() bool {
+	for _, inner := range op.ops {
+		if !matchesMissing(inner) {
+			return false
+		}
+	}
+	return true
+}
+
+type compareOperator struct {
+	value interface{}
+	test  func(cmp int) bool
+}
+
+func Gt(value interface{}) Operator {
+	return compareOperator{value: value, test: func(cmp int) bool { return cmp > 0 }}
+}
+
+func Gte(value interface{}) Operator {
+	return compareOperator{value: value, test: func(cmp int) bool { return cmp >= 0 }}
+}
+
+func Lt(value interface{}) Operator {
+	return compareOperator{value: value, test: func(cmp int) bool { return cmp < 0 }}
+}
+
+func Lte(value interface{}) Operator {
+	return compareOperator{value: value, test: func(cmp int) bool { return cmp <= 0 }}
+}
+
+func (op compareOperator) Matches(fieldValue interface{}) bool {
+	cmp, ok := CompareValues(fieldValue, op.value)
+	return ok && op.test(cmp)
+}
+
+type inOperator struct {
+	values []interface{}
+}
+
+func In(values ...interface{}) Operator {
+	return inOperator{values: values}
+}
+
+func NotIn(values ...interface{}) Operator {
+	return notOperator{op: inOperator{values: values}}
+}
+
+func (op inOperator) IndexValues() []interface{} {
+	return op.values
+}
+
+func (op inOperator) Matches(fieldValue interface{}) bool {
+	if _, isBytes := fieldValue.([]byte); !isBytes && reflect.ValueOf(fieldValue).Kind() == reflect.Slice {
+		return Overlaps(op.values...).Matches(fieldValue)
+	}
+	for _, value := range op.values {
+		if ValuesEqual(fieldValue, value) {
+			return true
+		}
+	}
+	return false
+}
+
+type allElementsOperator struct {
+	values []interface{}
+}
+
+func (op allElementsOperator) Matches(fieldValue interface{}) bool {
+	for _, value := range op.values {
+		if !Contains(value).Matches(fieldValue) {
+			return false
+		}
+	}
+	return true
+}
+
+type sizeOperator struct {
+	size int
+}
+
+func (op sizeOperator) Matches(fieldValue interface{}) bool {
+	v := reflect.ValueOf(fieldValue)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len() == op.size
+	}
+	return false
+}
+
+func CompareValues(a, b interface{}) (int, bool) {
+	if isNil(a) || isNil(b) {
+		return 0, false
+	}
+	if comparator, ok := comparatorFor(a, b); ok && comparator.Compare != nil {
+		return comparator.Compare(a, b)
+	}
+
+	if af, ok := toFloat(reflect.ValueOf(a)); ok {
+		bf, ok := toFloat(reflect.ValueOf(b))
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case af < bf:
+			return -1, true
+		case af > bf:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	_, aTime := a.(time.Time)
+	_, bTime := b.(time.Time)
+	if aTime || bTime {
+		at, okA := toTime(a)
+		bt, okB := toTime(b)
+		if !okA || !okB {
+			return 0, false
+		}
+		return at.Compare(bt), true
+	}
+
+	as, okA := a.(string)
+	bs, okB := b.(string)
+	if okA && okB {
+		return strings.Compare(as, bs), true
+	}
+	return 0, false
+}
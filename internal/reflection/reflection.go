@@ -9,7 +9,9 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/andr1ww/odin/internal/keys"
 	"github.com/andr1ww/odin/internal/logger"
 	bolt "go.etcd.io/bbolt"
 )
@@ -17,9 +19,16 @@ import (
 var bucketNameCache = sync.Map{}
 
 type FieldMatcher struct {
-	FieldMap map[string]int
-	JsonMap  map[string]int
-	Fields   []reflect.StructField
+	FieldMap  map[string]int
+	JsonMap   map[string]int
+	Promoted  map[string][]int
+	Fields    []reflect.StructField
+	Flattened []FlatField
+}
+
+type FlatField struct {
+	Name  string
+	Index []int
 }
 
 var matcherCache = sync.Map{}
@@ -33,6 +42,7 @@ func GetFieldMatcher(typ reflect.Type) *FieldMatcher {
 	matcher := &FieldMatcher{
 		FieldMap: make(map[string]int, numFields),
 		JsonMap:  make(map[string]int, numFields),
+		Promoted: make(map[string][]int),
 		Fields:   make([]reflect.StructField, numFields),
 	}
 
@@ -52,12 +62,95 @@ func GetFieldMatcher(typ reflect.Type) *FieldMatcher {
 		}
 	}
 
+	seen := map[reflect.Type]bool{typ: true}
+	for i := 0; i < numFields; i++ {
+		field := typ.Field(i)
+		if embedded, ok := Flattenable(field); ok {
+			matcher.addPromoted(embedded, []int{i}, seen, embedded.Name() != "Bucket")
+		}
+	}
+
 	if cached, loaded := matcherCache.LoadOrStore(typ, matcher); loaded {
 		return cached.(*FieldMatcher)
 	}
 	return matcher
 }
 
+func Flattenable(field reflect.StructField) (reflect.Type, bool) {
+	if !field.Anonymous || !field.IsExported() || field.Tag.Get("json") != "" {
+		return nil, false
+	}
+	typ := field.Type
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ, typ.Kind() == reflect.Struct
+}
+
+func (fm *FieldMatcher) addPromoted(typ reflect.Type, index []int, seen map[reflect.Type]bool, flatten bool) {
+	if seen[typ] {
+		return
+	}
+	seen[typ] = true
+	defer delete(seen, typ)
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		path := append(append([]int(nil), index...), i)
+		if embedded, ok := Flattenable(field); ok {
+			fm.addPromoted(embedded, path, seen, flatten)
+			continue
+		}
+
+		names := []string{field.Name}
+		if jsonTag := field.Tag.Get("json"); jsonTag != "" {
+			if comma := strings.IndexByte(jsonTag, ','); comma != -1 {
+				jsonTag = jsonTag[:comma]
+			}
+			if jsonTag == "-" {
+				continue
+			}
+			if jsonTag != "" {
+				names = append(names, jsonTag)
+			}
+		}
+
+		added := false
+		for _, name := range names {
+			if _, exists := fm.JsonMap[name]; exists {
+				continue
+			}
+			if _, exists := fm.FieldMap[name]; exists {
+				continue
+			}
+			if _, exists := fm.Promoted[name]; !exists {
+				fm.Promoted[name] = path
+				added = true
+			}
+		}
+		if added && flatten {
+			fm.Flattened = append(fm.Flattened, FlatField{Name: names[len(names)-1], Index: path})
+		}
+	}
+}
+
+func FieldByPath(value reflect.Value, path []int) (reflect.Value, bool) {
+	for _, i := range path {
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				return reflect.Value{}, false
+			}
+			value = value.Elem()
+		}
+		value = value.Field(i)
+	}
+	return value, true
+}
+
 func (fm *FieldMatcher) GetFieldValue(entityValue reflect.Value, key string) (interface{}, bool) {
 	if idx, exists := fm.JsonMap[key]; exists {
 		return entityValue.Field(idx).Interface(), true
@@ -65,38 +158,76 @@ func (fm *FieldMatcher) GetFieldValue(entityValue reflect.Value, key string) (in
 	if idx, exists := fm.FieldMap[key]; exists {
 		return entityValue.Field(idx).Interface(), true
 	}
+	if path, exists := fm.Promoted[key]; exists {
+		if field, ok := FieldByPath(entityValue, path); ok {
+			return field.Interface(), true
+		}
+		return nil, true
+	}
 	return nil, false
 }
 
+func JSONFieldName(typ reflect.Type, name string) (string, bool) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	field, found := typ.FieldByName(name)
+	if !found {
+		matcher := GetFieldMatcher(typ)
+		if _, exists := matcher.JsonMap[name]; exists {
+			return name, true
+		}
+		path, exists := matcher.Promoted[name]
+		if !exists {
+			return "", false
+		}
+		field = fieldTypeByPath(typ, path)
+	}
+
+	jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch jsonName {
+	case "-":
+		return "", false
+	case "":
+		return field.Name, true
+	}
+	return jsonName, true
+}
+
+func fieldTypeByPath(typ reflect.Type, path []int) reflect.StructField {
+	var field reflect.StructField
+	for _, i := range path {
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		field = typ.Field(i)
+		typ = field.Type
+	}
+	return field
+}
+
 func MatchesCriteria(entity interface{}, criteria map[string]interface{}, matcher *FieldMatcher) bool {
 	entityValue := reflect.ValueOf(entity)
 	if entityValue.Kind() == reflect.Ptr {
 		entityValue = entityValue.Elem()
 	}
 
-	for key, expectedValue := range criteria {
-		var fieldValue interface{}
-		var found bool
-
-		if idx, exists := matcher.JsonMap[key]; exists {
-			fieldValue = entityValue.Field(idx).Interface()
-			found = true
-		} else if idx, exists := matcher.FieldMap[key]; exists {
-			fieldValue = entityValue.Field(idx).Interface()
-			found = true
+	if accessor, ok := entity.(FieldAccessor); ok {
+		if matcher == nil || len(matcher.Flattened) == 0 {
+			return matchFilter(accessor.OdinField, criteria)
 		}
-
-		if !found {
-			return false
-		}
-
-		if fieldValue != expectedValue {
-			if !reflect.DeepEqual(fieldValue, expectedValue) {
-				return false
+		return matchFilter(func(key string) (interface{}, bool) {
+			if value, found := accessor.OdinField(key); found {
+				return value, true
 			}
-		}
+			return matcher.GetFieldValue(entityValue, key)
+		}, criteria)
 	}
-	return true
+
+	return matchFilter(func(key string) (interface{}, bool) {
+		return matcher.GetFieldValue(entityValue, key)
+	}, criteria)
 }
 
 func GetBucketName(v interface{}) (string, error) {
@@ -114,7 +245,10 @@ func GetBucketName(v interface{}) (string, error) {
 	}
 
 	typ := val.Type()
-	if cached, exists := bucketNameCache.Load(typ); exists {
+	dbName, _ := databaseTag(typ)
+	dbName = resolveDatabase(dbName)
+	cacheKey := bucketNameKey{typ: typ, db: dbName}
+	if cached, exists := bucketNameCache.Load(cacheKey); exists {
 		return cached.(string), nil
 	}
 
@@ -141,14 +275,10 @@ func GetBucketName(v interface{}) (string, error) {
 	}
 
 	if !found {
-		structName := typ.Name()
-		if len(structName) > 6 && structName[len(structName)-6:] == "Entity" {
-			structName = structName[:len(structName)-6]
-		}
-		bucketName = structName
+		bucketName = namingStrategyFor(dbName)(typ.Name())
 	}
 
-	if cached, loaded := bucketNameCache.LoadOrStore(typ, bucketName); loaded {
+	if cached, loaded := bucketNameCache.LoadOrStore(cacheKey, bucketName); loaded {
 		return cached.(string), nil
 	}
 	return bucketName, nil
@@ -168,24 +298,21 @@ func GetBucketDatabase(v interface{}) (string, error) {
 		return "", fmt.Errorf("expected struct, got %s", val.Kind())
 	}
 
-	typ := val.Type()
+	if dbName, ok := databaseTag(val.Type()); ok {
+		return dbName, nil
+	}
+	return "", errors.New("no database tag found")
+}
+
+func databaseTag(typ reflect.Type) (string, bool) {
 	numFields := typ.NumField()
 
 	for i := 0; i < numFields; i++ {
-		field := typ.Field(i)
-
-		if field.Type.Name() == "Bucket" {
-			if dbName, ok := field.Tag.Lookup("database"); ok {
-				return dbName, nil
-			}
-		}
-
-		if dbName, ok := field.Tag.Lookup("database"); ok {
-			return dbName, nil
+		if dbName, ok := typ.Field(i).Tag.Lookup("database"); ok {
+			return dbName, true
 		}
 	}
-
-	return "", errors.New("no database tag found")
+	return "", false
 }
 
 func FindAndInitBuckets(db *bolt.DB, dbName string) error {
@@ -296,3 +423,111 @@ func scanForBuckets(rootDir string, buckets map[string]struct{}, targetDB string
 		return nil
 	})
 }
+
+func GetExpireField(v interface{}) (string, bool) {
+	val := reflect.ValueOf(v)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return "", false
+	}
+
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		if field, ok := typ.Field(i).Tag.Lookup("expire"); ok && field != "" {
+			return field, true
+		}
+	}
+	return "", false
+}
+
+func GetExpireTime(v interface{}) (time.Time, bool) {
+	field, ok := GetExpireField(v)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	val := reflect.ValueOf(v)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+
+	value, found := GetFieldMatcher(val.Type()).GetFieldValue(val, field)
+	if !found {
+		return time.Time{}, false
+	}
+
+	switch t := value.(type) {
+	case time.Time:
+		return t, true
+	case *time.Time:
+		if t == nil {
+			return time.Time{}, true
+		}
+		return *t, true
+	}
+	return time.Time{}, false
+}
+
+func GetGeoPoint(v interface{}) (lat, lng float64, tagged, ok bool) {
+	val := reflect.ValueOf(v)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return 0, 0, false, false
+	}
+
+	var tag string
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		if value, exists := typ.Field(i).Tag.Lookup("geo"); exists && value != "" {
+			tag = value
+			break
+		}
+	}
+	if tag == "" {
+		return 0, 0, false, false
+	}
+
+	parts := strings.Split(tag, ",")
+	if len(parts) != 2 {
+		return 0, 0, true, false
+	}
+
+	matcher := GetFieldMatcher(typ)
+	latValue, latFound := matcher.GetFieldValue(val, strings.TrimSpace(parts[0]))
+	lngValue, lngFound := matcher.GetFieldValue(val, strings.TrimSpace(parts[1]))
+	if !latFound || !lngFound {
+		return 0, 0, true, false
+	}
+
+	lat, latOK := toFloat(reflect.ValueOf(latValue))
+	lng, lngOK := toFloat(reflect.ValueOf(lngValue))
+	return lat, lng, true, latOK && lngOK
+}
+
+func SetRecordKey(v interface{}, key string) bool {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return false
+	}
+	val = val.Elem()
+	if val.Kind() != reflect.Struct {
+		return false
+	}
+
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if _, ok := field.Tag.Lookup("odinkey"); !ok {
+			continue
+		}
+		if !val.Field(i).CanSet() {
+			return false
+		}
+		return keys.DecodeValue(val.Field(i), key)
+	}
+	return false
+}
@@ -0,0 +1,274 @@
+package schema
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+type Schema = map[string]interface{}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	bytesType    = reflect.TypeOf([]byte(nil))
+)
+
+func Generate(typ reflect.Type, title string) Schema {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	schema := typeSchema(typ, make(map[reflect.Type]bool))
+	schema["$schema"] = Draft
+	if title != "" {
+		schema["title"] = title
+	}
+	return schema
+}
+
+func typeSchema(typ reflect.Type, seen map[reflect.Type]bool) Schema {
+	switch typ {
+	case timeType:
+		return Schema{"type": "string", "format": "date-time"}
+	case durationType:
+		return Schema{"type": "integer"}
+	case bytesType:
+		return Schema{"type": "string", "contentEncoding": "base64"}
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Schema{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Ptr:
+		schema := typeSchema(typ.Elem(), seen)
+		if t, ok := schema["type"].(string); ok {
+			schema["type"] = []string{t, "null"}
+		}
+		return schema
+	case reflect.Slice, reflect.Array:
+		schema := Schema{"type": "array", "items": typeSchema(typ.Elem(), seen)}
+		if typ.Kind() == reflect.Array {
+			schema["minItems"] = typ.Len()
+			schema["maxItems"] = typ.Len()
+		}
+		return schema
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": typeSchema(typ.Elem(), seen)}
+	case reflect.Struct:
+		if seen[typ] {
+			return Schema{"type": "object"}
+		}
+		seen[typ] = true
+		defer delete(seen, typ)
+
+		properties := Schema{}
+		var required []string
+		collectFields(typ, properties, &required, seen)
+
+		schema := Schema{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+
+	return Schema{}
+}
+
+func collectFields(typ reflect.Type, properties Schema, required *[]string, seen map[reflect.Type]bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitEmpty, skip := jsonName(field)
+		if skip {
+			continue
+		}
+
+		fieldType := field.Type
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			for fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				collectFields(fieldType, properties, required, seen)
+				continue
+			}
+		}
+
+		schema := typeSchema(fieldType, seen)
+		rules := parseRules(field.Tag.Get("validate"))
+		applyRules(schema, fieldType, rules)
+
+		if def, ok := field.Tag.Lookup("default"); ok {
+			if value, ok := defaultValue(fieldType, def); ok {
+				schema["default"] = value
+			}
+		}
+		if description, ok := field.Tag.Lookup("description"); ok {
+			schema["description"] = description
+		}
+
+		properties[name] = schema
+
+		_, explicit := rules["required"]
+		if explicit || (!omitEmpty && fieldType.Kind() != reflect.Ptr) {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func jsonName(field reflect.StructField) (string, bool, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	name := field.Name
+	omitEmpty := false
+	if tag != "" {
+		parts := strings.Split(tag, ",")
+		if parts[0] != "" {
+			name = parts[0]
+		}
+		for _, opt := range parts[1:] {
+			if opt == "omitempty" {
+				omitEmpty = true
+			}
+		}
+	}
+	return name, omitEmpty, false
+}
+
+func parseRules(tag string) map[string]string {
+	rules := make(map[string]string)
+	if tag == "" {
+		return rules
+	}
+
+	for _, rule := range strings.Split(tag, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		if eq := strings.IndexByte(rule, '='); eq != -1 {
+			rules[rule[:eq]] = rule[eq+1:]
+		} else {
+			rules[rule] = ""
+		}
+	}
+	return rules
+}
+
+func applyRules(schema Schema, typ reflect.Type, rules map[string]string) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	minKey, maxKey := "minimum", "maximum"
+	switch typ.Kind() {
+	case reflect.String:
+		minKey, maxKey = "minLength", "maxLength"
+	case reflect.Slice, reflect.Array:
+		minKey, maxKey = "minItems", "maxItems"
+	case reflect.Map:
+		minKey, maxKey = "minProperties", "maxProperties"
+	}
+
+	for rule, arg := range rules {
+		switch rule {
+		case "min", "gte":
+			if n, err := strconv.ParseFloat(arg, 64); err == nil {
+				schema[minKey] = n
+			}
+		case "max", "lte":
+			if n, err := strconv.ParseFloat(arg, 64); err == nil {
+				schema[maxKey] = n
+			}
+		case "gt":
+			if n, err := strconv.ParseFloat(arg, 64); err == nil {
+				schema["exclusiveMinimum"] = n
+			}
+		case "lt":
+			if n, err := strconv.ParseFloat(arg, 64); err == nil {
+				schema["exclusiveMaximum"] = n
+			}
+		case "len":
+			if n, err := strconv.ParseFloat(arg, 64); err == nil {
+				schema[minKey] = n
+				schema[maxKey] = n
+			}
+		case "oneof":
+			values := strings.Fields(arg)
+			enum := make([]interface{}, 0, len(values))
+			for _, v := range values {
+				if value, ok := defaultValue(typ, v); ok {
+					enum = append(enum, value)
+				}
+			}
+			schema["enum"] = enum
+		case "email":
+			schema["format"] = "email"
+		case "url", "uri":
+			schema["format"] = "uri"
+		case "uuid":
+			schema["format"] = "uuid"
+		case "hostname":
+			schema["format"] = "hostname"
+		case "ipv4", "ipv6":
+			schema["format"] = rule
+		case "pattern":
+			schema["pattern"] = arg
+		}
+	}
+}
+
+func defaultValue(typ reflect.Type, raw string) (interface{}, bool) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if typ == timeType {
+		if raw == "now" {
+			return nil, false
+		}
+		return raw, true
+	}
+	if typ == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, false
+		}
+		return int64(d), true
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		return b, err == nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		return n, err == nil
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		return f, err == nil
+	case reflect.String:
+		return raw, true
+	}
+	return nil, false
+}
@@ -3,28 +3,300 @@ package odin
 import (
 	"github.com/andr1ww/odin/bucket"
 	"github.com/andr1ww/odin/database"
+	"github.com/andr1ww/odin/internal/indexing"
 	"github.com/andr1ww/odin/internal/logger"
+	"github.com/andr1ww/odin/internal/reflection"
 )
 
 type Bucket = bucket.Bucket
 type DB = database.DB
+type Tx = database.Tx
+type Standby = database.Standby
+type Document = database.Document
+type Keyed = bucket.Keyed
+type Join = bucket.Join
+type Joined = bucket.Joined
+type ExportProfile = bucket.ExportProfile
+type Redaction = bucket.Redaction
+type ErasureReport = bucket.ErasureReport
+type Schema = bucket.Schema
+type Plan = bucket.Plan
+type Result = bucket.Result
+type FieldPlan = bucket.FieldPlan
+type Operator = reflection.Operator
+type NamingStrategy = reflection.NamingStrategy
+type Comparator = reflection.Comparator
+type Progress = database.Progress
+type Quota = database.Quota
+type QuotaError = database.QuotaError
+type Cap = database.Cap
+type TieringPolicy = database.TieringPolicy
+type BloomStats = database.BloomStats
+type SlowOpStats = database.SlowOpStats
+type OpInfo = database.OpInfo
+type Interceptor = database.Interceptor
+type MemoryUsage = database.MemoryUsage
+type PoolStats = database.PoolStats
+type FS = database.FS
+type File = database.File
+type ArchiveOptions = database.ArchiveOptions
+type ArchiveCompression = database.ArchiveCompression
+type BackupReport = database.BackupReport
+type BackupProblem = database.BackupProblem
+type RestoreOptions = database.RestoreOptions
+type ReopenPolicy = database.ReopenPolicy
+type DecodeError = database.DecodeError
+type ScanCounts = database.ScanCounts
+type QuarantineRecord = database.QuarantineRecord
+type QuarantineStats = database.QuarantineStats
+type ValueFormat = database.ValueFormat
+type CommitHook = database.CommitHook
+type Key = database.Key
+type Priority = database.Priority
+type MaintenanceStats = database.MaintenanceStats
+type OverloadError = database.OverloadError
+type LimitStats = database.LimitStats
+
+const (
+	HuffmanOnly        = database.HuffmanOnly
+	DefaultCompression = database.DefaultCompression
+	BestSpeed          = database.BestSpeed
+	BestCompression    = database.BestCompression
+
+	RedactDrop = bucket.RedactDrop
+	RedactMask = bucket.RedactMask
+	RedactHash = bucket.RedactHash
+	RedactKeep = bucket.RedactKeep
+
+	PlanIndex    = bucket.PlanIndex
+	PlanTimeline = bucket.PlanTimeline
+	PlanFullScan = bucket.PlanFullScan
+
+	OpGet          = database.OpGet
+	OpHas          = database.OpHas
+	OpPut          = database.OpPut
+	OpPutMany      = database.OpPutMany
+	OpDelete       = database.OpDelete
+	OpGetAll       = database.OpGetAll
+	OpFindWhere    = database.OpFindWhere
+	OpAtomic       = database.OpAtomic
+	OpUpdate       = database.OpUpdate
+	OpMerge        = database.OpMerge
+	OpPatch        = database.OpPatch
+	OpPutIf        = database.OpPutIf
+	OpForEach      = database.OpForEach
+	OpList         = database.OpList
+	OpCount        = database.OpCount
+	OpClear        = database.OpClear
+	OpCreateBucket = database.OpCreateBucket
+	OpDeleteBucket = database.OpDeleteBucket
+
+	ArchiveNone = database.ArchiveNone
+	ArchiveGzip = database.ArchiveGzip
+	ArchiveZstd = database.ArchiveZstd
+
+	StageIndex = database.StageIndex
+	StageCache = database.StageCache
+	StageWatch = database.StageWatch
+	StageCDC   = database.StageCDC
+
+	PriorityLow    = database.PriorityLow
+	PriorityNormal = database.PriorityNormal
+	PriorityHigh   = database.PriorityHigh
+
+	JobRecompress = database.JobRecompress
+	JobExpiry     = database.JobExpiry
+	JobReindex    = database.JobReindex
+	JobRetention  = database.JobRetention
+
+	LimitWrites = database.LimitWrites
+	LimitScans  = database.LimitScans
+)
 
 var (
-	Connect        = database.Connect
-	ConnectDefault = database.ConnectDefault
-	SetDefault     = database.SetDefault
-	Get            = database.Get
-	GetNamed       = database.GetNamed
-	GetAll         = database.GetAll
-	ListDatabases  = database.ListDatabases
-	Close          = database.Close
-	CloseAll       = database.CloseAll
-
-	Find      = bucket.Find
-	FindWhere = bucket.FindWhere
-	Create    = bucket.Create
-	FindAll   = bucket.FindAll
+	Connect           = database.Connect
+	ConnectDefault    = database.ConnectDefault
+	SetDefault        = database.SetDefault
+	Get               = database.Get
+	GetNamed          = database.GetNamed
+	GetAll            = database.GetAll
+	ListDatabases     = database.ListDatabases
+	Close             = database.Close
+	CloseAll          = database.CloseAll
+	Declare           = database.Declare
+	Alias             = database.Alias
+	Unalias           = database.Unalias
+	Aliases           = database.Aliases
+	Resolve           = database.Resolve
+	SetIdleTimeout    = database.SetIdleTimeout
+	Shutdown          = database.Shutdown
+	RegisterCloseHook = database.RegisterCloseHook
+	DependsOn         = database.DependsOn
+	OnConnect         = database.OnConnect
+	OnClose           = database.OnClose
+	OnDefaultChanged  = database.OnDefaultChanged
+	OnRestore         = database.OnRestore
+	OnReopen          = database.OnReopen
+	OnUnhealthy       = database.OnUnhealthy
+	SetAutoReopen     = database.SetAutoReopen
+	Restore           = database.Restore
+	Follow            = database.Follow
+
+	SetCompression           = database.SetCompression
+	RegisterValueFormat      = database.RegisterValueFormat
+	ValueFormats             = database.ValueFormats
+	SetMaxPooledItemBytes    = database.SetMaxPooledItemBytes
+	MemoryStats              = database.MemoryStats
+	WithMmapSize             = database.WithMmapSize
+	WithFS                   = database.WithFS
+	WithMaintenanceDir       = database.WithMaintenanceDir
+	WithReadOnly             = database.WithReadOnly
+	WithNamingStrategy       = database.WithNamingStrategy
+	WithStrictDecoding       = database.WithStrictDecoding
+	WithSkipHandler          = database.WithSkipHandler
+	WithQuarantine           = database.WithQuarantine
+	WithScanWorkers          = database.WithScanWorkers
+	WithPooledDecoding       = database.WithPooledDecoding
+	WithMaintenanceLatency   = database.WithMaintenanceLatency
+	WithMaintenancePriority  = database.WithMaintenancePriority
+	WithWriteLimit           = database.WithWriteLimit
+	WithScanLimit            = database.WithScanLimit
+	WithQueueLimit           = database.WithQueueLimit
+	QuarantineBucketName     = database.QuarantineBucketName
+	OSFS                     = database.OSFS
+	ArchiveCompressionFor    = database.ArchiveCompressionFor
+	VerifyBackup             = database.VerifyBackup
+	VerifyArchive            = database.VerifyArchive
+	WithCompression          = database.WithCompression
+	WithCompressionLevel     = database.WithCompressionLevel
+	WithCompressionThreshold = database.WithCompressionThreshold
+	WithProgressReporter     = database.WithProgressReporter
+	WithSlowThreshold        = database.WithSlowThreshold
+	WithInterceptor          = database.WithInterceptor
+	ProgressChannel          = database.ProgressChannel
+
+	Find        = bucket.Find
+	FindWhere   = bucket.FindWhere
+	Create      = bucket.Create
+	FindAll     = bucket.FindAll
+	FindNear    = bucket.FindNear
+	FindAfter   = bucket.FindAfter
+	FindRecent  = bucket.FindRecent
+	FindBetween = bucket.FindBetween
+
+	FindAllInto    = bucket.FindAllInto
+	FindWhereInto  = bucket.FindWhereInto
+	FindAllKeyed   = bucket.FindAllKeyed
+	FindWhereKeyed = bucket.FindWhereKeyed
+
+	FindWhereJoined = bucket.FindWhereJoined
+	Explain         = bucket.Explain
+
+	FindWhereResult = bucket.FindWhereResult
+	FindAllResult   = bucket.FindAllResult
+
+	RegisterBucketModel = bucket.RegisterBucketModel
+	RegisterKeyField    = bucket.RegisterKeyField
+	RegisterScope       = bucket.RegisterScope
+	AutoMigrate         = bucket.AutoMigrate
+	RebuildIndexes      = bucket.RebuildIndexes
+	Fields              = bucket.Fields
+	Export              = bucket.Export
+	EraseSubject        = bucket.EraseSubject
+	Anonymize           = bucket.Anonymize
+	SignWith            = bucket.SignWith
+
+	SetMorph     = bucket.SetMorph
+	ResolveMorph = bucket.ResolveMorph
+	PreloadMorph = bucket.PreloadMorph
+
+	SchemaFor = bucket.SchemaFor
+	SchemaAll = bucket.SchemaAll
+
+	Contains = reflection.Contains
+	Overlaps = reflection.Overlaps
+	Like     = reflection.Like
+	Match    = reflection.Match
+
+	EqualFold       = reflection.EqualFold
+	CaseInsensitive = reflection.CaseInsensitive
+
+	RegisterComparator   = reflection.RegisterComparator
+	UnregisterComparator = reflection.UnregisterComparator
+
+	Between = reflection.Between
+	Since   = reflection.Since
+	Until   = reflection.Until
+
+	IsNull  = reflection.IsNull
+	IsZero  = reflection.IsZero
+	NotZero = reflection.NotZero
+	Exists  = reflection.Exists
+	Missing = reflection.Missing
+
+	Gt    = reflection.Gt
+	Gte   = reflection.Gte
+	Lt    = reflection.Lt
+	Lte   = reflection.Lte
+	Ne    = reflection.Ne
+	In    = reflection.In
+	NotIn = reflection.NotIn
+
+	And = reflection.And
+	Or  = reflection.Or
+	Not = reflection.Not
+
+	SetNamingStrategy = reflection.SetNamingStrategy
+	StripEntitySuffix = reflection.StripEntitySuffix
+	SnakeCase         = reflection.SnakeCase
+	Pluralize         = reflection.Pluralize
+	SnakePlural       = reflection.SnakePlural
+	PrefixNames       = reflection.PrefixNames
+	ChainNaming       = reflection.ChainNaming
+
+	IndexStats      = indexing.AllIndexStats
+	ResetIndexStats = indexing.ResetIndexStats
 
 	SetLogger      = logger.SetLogger
 	DisableLogging = logger.DisableLogging
 )
+
+func RepoFor[T any]() *bucket.Repo[T] {
+	return bucket.RepoFor[T]()
+}
+
+func GetAllAs[T any](db *DB, bucketName string) ([]T, error) {
+	return database.GetAllAs[T](db, bucketName)
+}
+
+func JoinFor[L, R any]() *bucket.ManyToMany[L, R] {
+	return bucket.JoinFor[L, R]()
+}
+
+func EncodeKey[K Key](key K) string {
+	return database.EncodeKey(key)
+}
+
+func DecodeKey[K Key](encoded string) (K, error) {
+	return database.DecodeKey[K](encoded)
+}
+
+func PutKey[K Key](db *DB, bucketName string, key K, value interface{}) error {
+	return database.PutKey(db, bucketName, key, value)
+}
+
+func GetKey[K Key](db *DB, bucketName string, key K, target interface{}) error {
+	return database.GetKey(db, bucketName, key, target)
+}
+
+func HasKey[K Key](db *DB, bucketName string, key K) (bool, error) {
+	return database.HasKey(db, bucketName, key)
+}
+
+func DeleteKey[K Key](db *DB, bucketName string, key K) error {
+	return database.DeleteKey(db, bucketName, key)
+}
+
+func KeysBetween[K Key](db *DB, bucketName string, from, to K) ([]K, error) {
+	return database.KeysBetween(db, bucketName, from, to)
+}
@@ -0,0 +1,207 @@
+package syncer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/andr1ww/odin/database"
+	"github.com/andr1ww/odin/errors"
+	jsoniter "github.com/json-iterator/go"
+)
+
+const (
+	statePrefix = "__syncstate_"
+	cursorKey   = "\x00cursor"
+)
+
+type Client struct {
+	db      *database.DB
+	url     string
+	http    *http.Client
+	mutex   sync.Mutex
+	buckets map[string]bool
+}
+
+type cursor struct {
+	Since  uint64 `json:"since"`
+	Pushed uint64 `json:"pushed"`
+}
+
+type keyState struct {
+	Base    uint64 `json:"base"`
+	Applied uint64 `json:"applied,omitempty"`
+}
+
+type Report struct {
+	Bucket    string
+	Pushed    int
+	Pulled    int
+	Conflicts int
+}
+
+func NewClient(db *database.DB, url string) *Client {
+	return &Client{db: db, url: url, http: http.DefaultClient, buckets: make(map[string]bool)}
+}
+
+func (c *Client) WithHTTPClient(client *http.Client) *Client {
+	c.http = client
+	return c
+}
+
+func (c *Client) Track(bucketName string) error {
+	if err := c.db.CreateBucket(bucketName); err != nil {
+		return err
+	}
+	if err := c.db.CreateBucket(statePrefix + bucketName); err != nil {
+		return err
+	}
+	if err := c.db.EnableChangeLog(bucketName); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.buckets[bucketName] = true
+	return nil
+}
+
+func (c *Client) Sync(ctx context.Context) ([]Report, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	names := make([]string, 0, len(c.buckets))
+	for bucketName := range c.buckets {
+		names = append(names, bucketName)
+	}
+	sort.Strings(names)
+
+	reports := make([]Report, 0, len(names))
+	for _, bucketName := range names {
+		report, err := c.syncBucket(ctx, bucketName)
+		if err != nil {
+			return reports, fmt.Errorf("sync bucket '%s': %w", bucketName, err)
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func (c *Client) syncBucket(ctx context.Context, bucketName string) (Report, error) {
+	report := Report{Bucket: bucketName}
+	stateBucket := statePrefix + bucketName
+
+	var cur cursor
+	if err := c.db.Get(stateBucket, cursorKey, &cur); err != nil && err != errors.ErrNotFound {
+		return report, err
+	}
+
+	local, err := c.db.ChangesSince(bucketName, cur.Pushed, 0)
+	if err != nil {
+		return report, err
+	}
+
+	req := Request{Bucket: bucketName, Since: cur.Since}
+	pushedUpTo := cur.Pushed
+	for _, change := range local {
+		pushedUpTo = change.Seq
+
+		state := c.keyState(stateBucket, change.Key)
+		if state.Applied == change.Seq {
+			continue
+		}
+		req.Changes = append(req.Changes, Record{Key: change.Key, Value: change.Value, Deleted: change.Deleted, At: change.At, Base: state.Base})
+	}
+
+	resp, err := c.exchange(ctx, req)
+	if err != nil {
+		return report, err
+	}
+	report.Pushed, report.Conflicts = len(req.Changes), resp.Conflicts
+
+	states := make(map[string]interface{}, len(resp.Changes)+1)
+	var written []string
+	err = c.db.Atomic(func(tx *database.Tx) error {
+		for _, record := range resp.Changes {
+			states[record.Key] = keyState{Base: record.Seq}
+
+			var current jsoniter.RawMessage
+			getErr := tx.Get(bucketName, record.Key, &current)
+			switch {
+			case record.Deleted && getErr == errors.ErrNotFound:
+				continue
+			case !record.Deleted && getErr == nil && bytes.Equal(current, record.Value):
+				continue
+			case getErr != nil && getErr != errors.ErrNotFound:
+				return getErr
+			}
+
+			written = append(written, record.Key)
+			if record.Deleted {
+				if err := tx.Delete(bucketName, record.Key); err != nil {
+					return err
+				}
+			} else if err := tx.Put(bucketName, record.Key, record.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+	report.Pulled = len(written)
+
+	for _, key := range written {
+		seq, _, found, err := c.db.KeyChange(bucketName, key)
+		if err != nil {
+			return report, err
+		}
+		if found {
+			state := states[key].(keyState)
+			state.Applied = seq
+			states[key] = state
+		}
+	}
+
+	states[cursorKey] = cursor{Since: resp.Seq, Pushed: pushedUpTo}
+	return report, c.db.PutMany(stateBucket, states)
+}
+
+func (c *Client) keyState(stateBucket, key string) keyState {
+	var state keyState
+	c.db.Get(stateBucket, key, &state)
+	return state
+}
+
+func (c *Client) exchange(ctx context.Context, req Request) (*Response, error) {
+	body, err := js.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := c.http.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("sync server answered %s", httpResp.Status)
+	}
+
+	var resp Response
+	if err := js.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,38 @@
+package syncer
+
+import (
+	"bytes"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+var js = jsoniter.ConfigCompatibleWithStandardLibrary
+
+type Record struct {
+	Key     string              `json:"key"`
+	Value   jsoniter.RawMessage `json:"value,omitempty"`
+	Deleted bool                `json:"deleted,omitempty"`
+	At      time.Time           `json:"at"`
+	Base    uint64              `json:"base,omitempty"`
+	Seq     uint64              `json:"seq,omitempty"`
+}
+
+type Request struct {
+	Bucket  string   `json:"bucket"`
+	Since   uint64   `json:"since"`
+	Changes []Record `json:"changes,omitempty"`
+}
+
+type Response struct {
+	Seq       uint64   `json:"seq"`
+	Changes   []Record `json:"changes,omitempty"`
+	Conflicts int      `json:"conflicts,omitempty"`
+}
+
+func sameContent(a, b Record) bool {
+	if a.Deleted || b.Deleted {
+		return a.Deleted == b.Deleted
+	}
+	return bytes.Equal(a.Value, b.Value)
+}
@@ -0,0 +1,87 @@
+package syncer
+
+import "github.com/andr1ww/odin/crdt"
+
+type Resolver func(key string, server, client Record) (Record, error)
+
+func LastWriteWins(key string, server, client Record) (Record, error) {
+	if client.At.After(server.At) {
+		return client, nil
+	}
+	return server, nil
+}
+
+func ServerWins(key string, server, client Record) (Record, error) {
+	return server, nil
+}
+
+func ClientWins(key string, server, client Record) (Record, error) {
+	return client, nil
+}
+
+func FieldMerge(key string, server, client Record) (Record, error) {
+	if server.Deleted || client.Deleted {
+		return LastWriteWins(key, server, client)
+	}
+
+	var serverFields, clientFields map[string]interface{}
+	if js.Unmarshal(server.Value, &serverFields) != nil || js.Unmarshal(client.Value, &clientFields) != nil {
+		return LastWriteWins(key, server, client)
+	}
+
+	older, newer := serverFields, clientFields
+	if !client.At.After(server.At) {
+		older, newer = clientFields, serverFields
+	}
+
+	merged := make(map[string]interface{}, len(older)+len(newer))
+	for field, value := range older {
+		merged[field] = value
+	}
+	for field, value := range newer {
+		merged[field] = value
+	}
+
+	data, err := js.Marshal(merged)
+	if err != nil {
+		return Record{}, err
+	}
+	result := client
+	if server.At.After(client.At) {
+		result.At = server.At
+	}
+	result.Value = data
+	return result, nil
+}
+
+func CRDT(constructor func() interface{}) Resolver {
+	return func(key string, server, client Record) (Record, error) {
+		if server.Deleted || client.Deleted {
+			return LastWriteWins(key, server, client)
+		}
+
+		newer, older := client, server
+		if !client.At.After(server.At) {
+			newer, older = server, client
+		}
+
+		merged, other := constructor(), constructor()
+		if err := js.Unmarshal(newer.Value, merged); err != nil {
+			return Record{}, err
+		}
+		if err := js.Unmarshal(older.Value, other); err != nil {
+			return Record{}, err
+		}
+		if err := crdt.Merge(merged, other); err != nil {
+			return Record{}, err
+		}
+
+		data, err := js.Marshal(merged)
+		if err != nil {
+			return Record{}, err
+		}
+		result := newer
+		result.Value = data
+		return result, nil
+	}
+}
@@ -0,0 +1,157 @@
+package syncer
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/andr1ww/odin/database"
+	"github.com/andr1ww/odin/errors"
+	"github.com/andr1ww/odin/internal/logger"
+	jsoniter "github.com/json-iterator/go"
+)
+
+type Server struct {
+	db        *database.DB
+	mutex     sync.Mutex
+	resolvers map[string]Resolver
+}
+
+func NewServer(db *database.DB) *Server {
+	return &Server{db: db, resolvers: make(map[string]Resolver)}
+}
+
+func (s *Server) Register(bucketName string, resolver Resolver) error {
+	if resolver == nil {
+		resolver = LastWriteWins
+	}
+	if err := s.db.CreateBucket(bucketName); err != nil {
+		return err
+	}
+	if err := s.db.EnableChangeLog(bucketName); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.resolvers[bucketName] = resolver
+	return nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req Request
+	if err := js.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := s.Exchange(req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == errors.ErrBucketMissing {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := js.NewEncoder(w).Encode(resp); err != nil {
+		logger.Error("failed to send sync response for bucket '%s': %v", req.Bucket, err)
+	}
+}
+
+func (s *Server) Exchange(req Request) (*Response, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	resolver, registered := s.resolvers[req.Bucket]
+	if !registered {
+		return nil, errors.ErrBucketMissing
+	}
+
+	resp := &Response{}
+	pushed := make(map[string]bool, len(req.Changes))
+	for _, change := range req.Changes {
+		pushed[change.Key] = true
+
+		current, err := s.current(req.Bucket, change.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		result := change
+		if current.Seq > change.Base {
+			resp.Conflicts++
+			if result, err = resolver(change.Key, current, change); err != nil {
+				return nil, fmt.Errorf("resolve conflict on '%s': %w", change.Key, err)
+			}
+		}
+		if sameContent(result, current) {
+			continue
+		}
+
+		if result.Deleted {
+			err = s.db.Delete(req.Bucket, change.Key)
+			if err == errors.ErrNotFound {
+				err = nil
+			}
+		} else {
+			err = s.db.Put(req.Bucket, change.Key, result.Value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	changes, err := s.db.ChangesSince(req.Bucket, req.Since, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		delete(pushed, change.Key)
+		resp.Changes = append(resp.Changes, Record{Key: change.Key, Value: change.Value, Deleted: change.Deleted, At: change.At, Seq: change.Seq})
+	}
+	for key := range pushed {
+		current, err := s.current(req.Bucket, key)
+		if err != nil {
+			return nil, err
+		}
+		resp.Changes = append(resp.Changes, current)
+	}
+
+	if resp.Seq, err = s.db.ChangeLogSeq(req.Bucket); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *Server) current(bucketName, key string) (Record, error) {
+	seq, at, found, err := s.db.KeyChange(bucketName, key)
+	if err != nil {
+		return Record{}, err
+	}
+
+	record := Record{Key: key, Seq: seq, At: at}
+	if !found {
+		record.Deleted = true
+		return record, nil
+	}
+
+	var value jsoniter.RawMessage
+	switch err := s.db.Get(bucketName, key, &value); err {
+	case nil:
+		record.Value = value
+	case errors.ErrNotFound:
+		record.Deleted = true
+	default:
+		return Record{}, err
+	}
+	return record, nil
+}